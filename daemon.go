@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/common"
+	"github.com/QRY91/wherewasi/internal/config"
+	"github.com/QRY91/wherewasi/internal/tracker"
+	"github.com/QRY91/wherewasi/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+// daemonStartTimeout is how long start waits for the spawned daemon to record its PID
+const daemonStartTimeout = 5 * time.Second
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop background tracking",
+	Long:  "Stop the background tracking daemon started with 'wherewasi start'",
+	Run: func(cmd *cobra.Command, args []string) {
+		stopDaemon()
+	},
+}
+
+var restartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Restart background tracking",
+	Long:  "Stop the background tracking daemon if running and start it again",
+	Run: func(cmd *cobra.Command, args []string) {
+		stopDaemon()
+		runStart(cmd)
+	},
+}
+
+// runStart launches the tracking daemon, or runs the tracker inline with --foreground
+func runStart(cmd *cobra.Command) {
+	foreground, _ := cmd.Flags().GetBool("foreground")
+	interval, _ := cmd.Flags().GetDuration("interval")
+	roots, _ := cmd.Flags().GetStringSlice("root")
+
+	// Roots given on the command line replace the configured ones; the daemon inherits them as absolute paths
+	opts := workspaceOptions()
+	if len(roots) > 0 {
+		opts.Roots = nil
+		for i, root := range roots {
+			abs, err := workspace.ExpandPath(root)
+			if err != nil {
+				fail("%v", err)
+			}
+			roots[i] = abs
+			opts.Roots = append(opts.Roots, config.Root{Path: abs})
+		}
+	}
+
+	if foreground {
+		// The daemon spawned by start gets here before it has recorded its PID
+		if pid, running := daemonStatus(); running && pid != os.Getpid() {
+			fail("Tracking already active (PID %d); stop it first with 'wherewasi stop'", pid)
+		}
+		if err := runTracker(opts, interval); err != nil {
+			fail("Tracking failed: %v", err)
+		}
+		return
+	}
+
+	fmt.Println("🚀 wherewasi shadow mode starting...")
+
+	if pid, running := daemonStatus(); running {
+		fmt.Printf("🥷 Passive tracking already active (PID %d)\n", pid)
+		fmt.Println("🪂 Ready for ripcord deployment: wherewasi pull")
+		return
+	}
+
+	pid, err := spawnDaemon(roots, interval)
+	if err != nil {
+		fail("Could not start background tracking: %v", err)
+	}
+
+	fmt.Printf("🥷 Passive tracking enabled (PID %d)\n", pid)
+	fmt.Printf("📝 Log: %s\n", common.GetDaemonLogPath())
+	fmt.Println("🪂 Ready for ripcord deployment: wherewasi pull")
+}
+
+// spawnDaemon re-executes the binary in foreground mode as a detached process
+func spawnDaemon(roots []string, interval time.Duration) (int, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	if err := os.MkdirAll(common.GetDataDir(), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	logFile, err := os.OpenFile(common.GetDaemonLogPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer logFile.Close()
+
+	args := []string{"start", "--foreground", "--interval", interval.String()}
+	for _, root := range roots {
+		args = append(args, "--root", root)
+	}
+
+	child := exec.Command(executable, args...)
+	child.Stdout = logFile
+	child.Stderr = logFile
+	child.SysProcAttr = daemonSysProcAttr()
+
+	if err := child.Start(); err != nil {
+		return 0, fmt.Errorf("failed to spawn daemon: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- child.Wait() }()
+
+	// The daemon writes its own PID file once it runs; wait for it so start only reports
+	// success for a daemon that stop can find
+	pid := child.Process.Pid
+	deadline := time.After(daemonStartTimeout)
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if recorded, running := daemonStatus(); running && recorded == pid {
+			return pid, nil
+		}
+		select {
+		case err := <-exited:
+			return 0, fmt.Errorf("daemon exited during startup (%v); see %s", err, common.GetDaemonLogPath())
+		case <-deadline:
+			child.Process.Kill()
+			return 0, fmt.Errorf("daemon did not start within %s; see %s", daemonStartTimeout, common.GetDaemonLogPath())
+		case <-ticker.C:
+		}
+	}
+}
+
+// runTracker blocks until interrupted, polling the ecosystem for activity
+func runTracker(opts workspace.Options, interval time.Duration) error {
+	if db == nil {
+		return fmt.Errorf("database not available")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pid := os.Getpid()
+	if err := writePIDFile(pid); err != nil {
+		return err
+	}
+	defer removePIDFile(pid)
+
+	t := tracker.New(db, tracker.Config{
+		Workspace: opts,
+		Interval:  interval,
+		Logger:    log.New(os.Stdout, "wherewasi: ", log.LstdFlags),
+	})
+	return t.Run(ctx)
+}
+
+// stopDaemon signals a running daemon and waits for it to exit
+func stopDaemon() {
+	pid, running := daemonStatus()
+	if !running {
+		if pid != 0 {
+			removePIDFile(pid)
+		}
+		fmt.Println("💤 Shadow mode not running")
+		return
+	}
+
+	if err := terminateProcess(pid); err != nil {
+		fmt.Printf("⚠️  Could not stop tracking daemon (PID %d): %v\n", pid, err)
+		return
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for processAlive(pid) && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	removePIDFile(pid)
+
+	fmt.Printf("🛑 Shadow mode stopped (PID %d)\n", pid)
+}
+
+// daemonStatus returns the recorded daemon PID and whether that process is alive
+func daemonStatus() (int, bool) {
+	data, err := os.ReadFile(common.GetDaemonPIDPath())
+	if err != nil {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+
+	return pid, processAlive(pid)
+}
+
+func writePIDFile(pid int) error {
+	if err := os.WriteFile(common.GetDaemonPIDPath(), []byte(strconv.Itoa(pid)+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+	return nil
+}
+
+// removePIDFile deletes the PID file only if it still belongs to pid
+func removePIDFile(pid int) {
+	data, err := os.ReadFile(common.GetDaemonPIDPath())
+	if err != nil {
+		return
+	}
+	if strings.TrimSpace(string(data)) == strconv.Itoa(pid) {
+		os.Remove(common.GetDaemonPIDPath())
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Maintain the context database",
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending schema migrations",
+	Long: `Apply pending schema migrations for the shared ecosystem tables and wherewasi's own tables.
+
+Migrations normally run automatically when wherewasi starts. Use --status to see
+which versions are applied and --dry-run to preview pending SQL. wherewasi refuses
+to use a database that has migrations from a newer version.`,
+	Annotations: map[string]string{skipMigrationsAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		status, _ := cmd.Flags().GetBool("status")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if db == nil {
+			fail("Database not available")
+		}
+
+		if status {
+			showMigrationStatus()
+			return
+		}
+
+		pending, err := db.PendingMigrations(ecosystem.ToolWherewasi)
+		if err != nil {
+			reportMigrationError(err)
+		}
+		if len(pending) == 0 {
+			fmt.Println("✅ Schema is up to date")
+			return
+		}
+
+		if dryRun {
+			fmt.Printf("🔍 %d pending migration(s), not applied:\n", len(pending))
+			for _, m := range pending {
+				fmt.Printf("\n-- v%d %s: %s\n%s\n", m.Version, m.Tool, m.Description, dedent(m.SQL))
+			}
+			return
+		}
+
+		applied, err := db.Migrate(ecosystem.ToolWherewasi)
+		for _, m := range applied {
+			fmt.Printf("  ✅ v%d %s: %s\n", m.Version, m.Tool, m.Description)
+		}
+		if err != nil {
+			reportMigrationError(err)
+		}
+		fmt.Printf("🗄️  Applied %d migration(s)\n", len(applied))
+	},
+}
+
+var dbPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old saved contexts and reclaim space",
+	Long: `Delete saved contexts past the retention limits, then VACUUM the database.
+
+Each limit applies on its own: a context is pruned when it is older than --older-than
+or beyond the newest --keep-per-project of its project. Contexts linked to open notes
+and checkpoints are kept. --compress gzip-compresses the remaining contexts; compressed
+contexts are searched by session info and keywords only.`,
+	Example: "  wherewasi db prune --older-than 90d --keep-per-project 50",
+	Run: func(cmd *cobra.Command, args []string) {
+		olderThan, _ := cmd.Flags().GetString("older-than")
+		keep, _ := cmd.Flags().GetInt("keep-per-project")
+		compress, _ := cmd.Flags().GetBool("compress")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		cutoff, err := parseSince(olderThan)
+		if err != nil {
+			fail("--older-than: %v", err)
+		}
+		if keep < 0 {
+			fail("--keep-per-project must be zero (no limit) or positive")
+		}
+		if cutoff.IsZero() && keep == 0 && !compress {
+			fail("Nothing to do: give --older-than, --keep-per-project or --compress")
+		}
+		requireDB()
+
+		prune := ecosystem.ContextPrune{OlderThan: cutoff, KeepPerProject: keep, DryRun: dryRun}
+		if !cutoff.IsZero() || keep > 0 {
+			pruned, err := db.PruneContexts(prune)
+			if err != nil {
+				fail("%v", err)
+			}
+			if dryRun {
+				fmt.Printf("🔍 Would prune %d context(s)\n", pruned)
+			} else {
+				fmt.Printf("🧹 Pruned %d context(s)\n", pruned)
+			}
+		}
+		if dryRun {
+			return
+		}
+
+		if compress {
+			compressed, err := db.CompressContexts()
+			if err != nil {
+				fail("%v", err)
+			}
+			fmt.Printf("🗜️  Compressed %d context(s)\n", compressed)
+		}
+
+		result, err := db.Vacuum()
+		if err != nil {
+			fail("%v", err)
+		}
+		fmt.Printf("💾 Reclaimed %s (%s → %s)\n", formatBytes(result.Reclaimed()), formatBytes(result.Before), formatBytes(result.After))
+	},
+}
+
+func showMigrationStatus() {
+	statuses, err := db.MigrationStatus(ecosystem.ToolWherewasi)
+	if err != nil {
+		reportMigrationError(err)
+	}
+
+	fmt.Printf("🗄️  Schema migrations (%s):\n", db.DatabasePath())
+	for _, status := range statuses {
+		var state string
+		switch {
+		case status.Unknown:
+			state = "❓ unknown (from a newer version)"
+		case !status.Applied:
+			state = "⏳ pending"
+		case status.Modified():
+			state = "⚠️  applied, SQL changed since"
+		default:
+			state = "✅ applied"
+		}
+		if status.AppliedAt != nil {
+			state += " " + status.AppliedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("  • v%d %-10s %s | %s\n", status.Version, status.Tool, state, status.Description)
+	}
+}
+
+// dedent strips the indentation migrations carry from being embedded in Go source
+func dedent(sql string) string {
+	lines := strings.Split(strings.Trim(sql, "\n\t "), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, "\t")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// vacuumInterval is how often pulls compact the database
+const vacuumInterval = 7 * 24 * time.Hour
+
+// formatBytes renders a size in B, KB or MB
+func formatBytes(n int64) string {
+	switch {
+	case n < 0:
+		return "-" + formatBytes(-n)
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	}
+}
+
+func reportMigrationError(err error) {
+	if errors.Is(err, ecosystem.ErrSchemaTooNew) {
+		fmt.Printf("⛔ %v\n", err)
+		exitWithError(err)
+	}
+	fail("Migration failed: %w", err)
+}
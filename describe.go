@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/QRY91/wherewasi/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var describeCmd = &cobra.Command{
+	Use:   "describe [description]",
+	Short: "Show or set ecosystem and project descriptions",
+	Long: `Show or set the descriptions at the top of every context.
+
+Without arguments, shows the ecosystem description and the current project's
+description with its source. With arguments, sets the project description in
+the user config (or .wherewasi.yaml with --repo). Projects without a
+description fall back to the first paragraph of their README.`,
+	Example: `  wherewasi describe
+  wherewasi describe "CLI that generates AI context from git and chat history"
+  wherewasi describe --repo "Shared description committed with the code"
+  wherewasi describe --ecosystem --name ACME "Payments platform" "Go services, React frontend"
+  wherewasi describe --edit`,
+	Run: func(cmd *cobra.Command, args []string) {
+		project, _ := cmd.Flags().GetString("project")
+		repo, _ := cmd.Flags().GetBool("repo")
+		ecosystemFlag, _ := cmd.Flags().GetBool("ecosystem")
+		name, _ := cmd.Flags().GetString("name")
+		clear, _ := cmd.Flags().GetBool("clear")
+		edit, _ := cmd.Flags().GetBool("edit")
+
+		// Naming the ecosystem only makes sense for the ecosystem description
+		ecosystemFlag = ecosystemFlag || cmd.Flags().Changed("name")
+		if repo && ecosystemFlag {
+			fail("The ecosystem description lives in the user config; drop --repo")
+		}
+
+		root := projectPath(project)
+		projectName := project
+		if found, ok := findProject(project); ok {
+			projectName = found.Name
+		} else if projectName == "" {
+			projectName = getProjectName()
+		}
+
+		if edit {
+			path := config.Path()
+			if repo {
+				path = config.RepoPath(root)
+			}
+			if err := editFile(path); err != nil {
+				fail("%v", err)
+			}
+			return
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			fail("%v", err)
+		}
+
+		changing := clear || len(args) > 0 || cmd.Flags().Changed("name")
+		if !changing {
+			showDescriptions(cfg, projectName, root)
+			return
+		}
+
+		description := strings.Join(args, " ")
+		switch {
+		case ecosystemFlag:
+			if cmd.Flags().Changed("name") {
+				cfg.Ecosystem.Name = name
+			}
+			if clear {
+				cfg.Ecosystem = config.Ecosystem{}
+			} else if len(args) > 0 {
+				cfg.Ecosystem.Description = args
+			}
+			err = cfg.Save()
+		case repo:
+			var repoCfg *config.Repo
+			repoCfg, err = config.LoadRepo(root)
+			if err == nil {
+				repoCfg.Description = description
+				err = config.SaveRepo(root, repoCfg)
+			}
+		default:
+			cfg.SetProjectDescription(projectName, description)
+			err = cfg.Save()
+		}
+		if err != nil {
+			fail("Could not save description: %v", err)
+		}
+
+		fmt.Println("✅ Description updated")
+		if cfg, err = config.Load(); err == nil {
+			showDescriptions(cfg, projectName, root)
+		}
+	},
+}
+
+func showDescriptions(cfg *config.Config, projectName, root string) {
+	title := "ECOSYSTEM"
+	if cfg.Ecosystem.Name != "" {
+		title = strings.ToUpper(cfg.Ecosystem.Name) + " " + title
+	}
+	fmt.Printf("🧠 %s:\n", title)
+	if len(cfg.Ecosystem.Description) == 0 {
+		fmt.Println("  (none - set with: wherewasi describe --ecosystem \"...\")")
+	}
+	for _, line := range cfg.Ecosystem.Description {
+		fmt.Printf("  - %s\n", line)
+	}
+
+	fmt.Printf("📖 %s:\n", projectName)
+	description, source, err := projectDescription(cfg, projectName, root)
+	switch {
+	case err != nil:
+		fmt.Printf("  ⚠️  %v\n", err)
+	case description == "":
+		fmt.Println("  (none - set with: wherewasi describe \"...\")")
+	default:
+		fmt.Printf("  %s\n  (from %s)\n", description, source)
+	}
+}
+
+// editFile opens path in $VISUAL or $EDITOR, creating its directory first
+func editFile(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		return fmt.Errorf("set $EDITOR to edit %s", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	// The editor may carry arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run editor: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/spf13/cobra"
+)
+
+// Export formats
+const (
+	exportFormatJSONL = "jsonl"
+	exportFormatTar   = "tar"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export contexts, projects, notes and tool messages",
+	Long: `Export saved contexts, projects, notes and tool messages to move history between
+machines or keep a readable backup. JSONL writes one {"table", "row"} object per line;
+tar writes one JSON file per table. Read either back with 'wherewasi import'.`,
+	Example: `  wherewasi export -o history.jsonl
+  wherewasi export --project wherewasi --since 30d --format tar -o wherewasi.tar`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		format, _ := cmd.Flags().GetString("format")
+		project, _ := cmd.Flags().GetString("project")
+		sinceValue, _ := cmd.Flags().GetString("since")
+		untilValue, _ := cmd.Flags().GetString("until")
+
+		if format == "" {
+			format = exportFormatJSONL
+			if strings.HasSuffix(output, ".tar") {
+				format = exportFormatTar
+			}
+		}
+		if format != exportFormatJSONL && format != exportFormatTar {
+			fail("Unknown format %q (use %s or %s)", format, exportFormatJSONL, exportFormatTar)
+		}
+		filter := ecosystem.ExportFilter{}
+		var err error
+		if filter.Since, err = parseSince(sinceValue); err != nil {
+			fail("--since: %v", err)
+		}
+		if filter.Until, err = parseSince(untilValue); err != nil {
+			fail("--until: %v", err)
+		}
+		requireDB()
+		if project != "" {
+			filter.Project = canonicalProjectName(project)
+		}
+
+		export, err := db.Export(filter)
+		if err != nil {
+			fail("%v", err)
+		}
+
+		// Status goes to stderr when the export itself goes to stdout
+		status := os.Stdout
+		var w io.Writer = os.Stdout
+		if output == "-" {
+			status = os.Stderr
+		} else {
+			file, err := os.Create(output)
+			if err != nil {
+				fail("Could not create %s: %v", output, err)
+			}
+			defer file.Close()
+			w = file
+		}
+
+		write := export.WriteJSONL
+		if format == exportFormatTar {
+			write = export.WriteTar
+		}
+		if err := write(w); err != nil {
+			fmt.Fprintf(status, "⚠️  %v\n", err)
+			exitWithError(err)
+		}
+
+		counts := export.Counts()
+		destination := output
+		if output == "-" {
+			destination = "stdout"
+		}
+		fmt.Fprintf(status, "📦 Exported %d contexts, %d projects, %d notes and %d tool messages to %s\n",
+			counts.Contexts, counts.Projects, counts.Notes, counts.Messages, destination)
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file|->",
+	Short: "Merge an export into the database",
+	Long: `Merge a 'wherewasi export' file (JSONL or tar) into the database in one transaction.
+
+Contexts, notes and tool messages already present are skipped, so importing the same
+file twice is safe. Projects that already exist are merged by default: existing values
+are kept and empty ones filled in. Use --on-conflict keep to leave them untouched or
+replace to overwrite them.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		onConflict, _ := cmd.Flags().GetString("on-conflict")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		requireDB()
+
+		var r io.Reader = os.Stdin
+		if args[0] != "-" {
+			file, err := os.Open(args[0])
+			if err != nil {
+				fail("Could not open %s: %v", args[0], err)
+			}
+			defer file.Close()
+			r = file
+		}
+
+		export, err := ecosystem.ReadExport(r)
+		if err != nil {
+			fail("%v", err)
+		}
+		result, err := db.Import(export, ecosystem.ImportOptions{OnConflict: onConflict, DryRun: dryRun})
+		if err != nil {
+			fail("Import failed, nothing was changed: %v", err)
+		}
+
+		verb := "Imported"
+		if dryRun {
+			verb = "Would import"
+		}
+		fmt.Printf("📥 %s %d contexts, %d projects, %d notes and %d tool messages (exported %s)\n",
+			verb, result.Added.Contexts, result.Added.Projects, result.Added.Notes, result.Added.Messages,
+			export.Manifest.ExportedAt.Local().Format("2006-01-02T15:04"))
+		skipped := result.Skipped.Contexts + result.Skipped.Notes + result.Skipped.Messages
+		if skipped > 0 {
+			fmt.Printf("  • %d already present, skipped\n", skipped)
+		}
+		if result.ProjectsUpdated > 0 {
+			fmt.Printf("  • %d existing project(s) updated (%s)\n", result.ProjectsUpdated, onConflict)
+		}
+		if result.Skipped.Projects > 0 {
+			fmt.Printf("  • %d existing project(s) kept as they are\n", result.Skipped.Projects)
+		}
+	},
+}
+
+var dbBackupCmd = &cobra.Command{
+	Use:   "backup [path]",
+	Short: "Copy the database safely while it is in use",
+	Long: `Copy the database with SQLite's online backup API, which takes a consistent snapshot
+even while other tools are writing to it. Unlike copying the file, the backup includes
+changes still in the write-ahead log. Without a path, the backup is written to a
+backups directory next to the database.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireDB()
+
+		var dst string
+		if len(args) == 1 {
+			dst = args[0]
+		} else {
+			source := db.DatabasePath()
+			name := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+			dst = filepath.Join(filepath.Dir(source), "backups", fmt.Sprintf("%s-%s.sqlite", name, time.Now().Format("20060102-150405")))
+		}
+
+		if err := db.Backup(dst); err != nil {
+			fail("%v", err)
+		}
+		size := "unknown size"
+		if info, err := os.Stat(dst); err == nil {
+			size = formatBytes(info.Size())
+		}
+		fmt.Printf("💾 Backed up %s to %s (%s)\n", db.DatabasePath(), dst, size)
+	},
+}
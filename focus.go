@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/config"
+	"github.com/QRY91/wherewasi/internal/contextdoc"
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/QRY91/wherewasi/internal/gitinfo"
+	"github.com/QRY91/wherewasi/internal/provider"
+	"github.com/QRY91/wherewasi/internal/search"
+	"github.com/QRY91/wherewasi/internal/symbols"
+)
+
+// Focused pulls centre the context on one file, directory or Go symbol
+const (
+	sectionFocusNotes = "focus_notes"
+	titleFocusNotes   = "📌 RELATED NOTES"
+
+	// focusHistoryLimit is how many commits touching the focus are listed
+	focusHistoryLimit = 10
+	// focusPatchCommits is how many of those commits have their diff included
+	focusPatchCommits = 3
+	// focusPatchLines caps each diff so one large change cannot crowd out the rest
+	focusPatchLines    = 40
+	maxFocusReferences = 20
+	maxFocusTests      = 10
+	// focusNoteScan is how many recent notes are checked for mentions of the focus
+	focusNoteScan = 100
+)
+
+// focusProviders fill the sections of a focused pull, replacing the configured sections
+var focusProviders = provider.NewRegistry()
+
+func init() {
+	focusProviders.Register(provider.Func(contextdoc.SectionFocusHistory, provideFocusHistory))
+	focusProviders.Register(provider.Func(contextdoc.SectionFocusDiffs, provideFocusDiffs))
+	focusProviders.Register(provider.Func(contextdoc.SectionReferences, provideReferences))
+	focusProviders.Register(provider.Func(sectionFocusNotes, provideFocusNotes))
+}
+
+// resolveFocus finds what target names in the project: a file or directory relative to the
+// current directory or the project root, otherwise a Go func, type, var, const or method
+func resolveFocus(ctx context.Context, project, target string) (*contextdoc.Focus, error) {
+	root := projectPath(project)
+	if root == "" {
+		return nil, fmt.Errorf("project %q not found", project)
+	}
+	// Paths in git commands are relative to the top of the working tree
+	if repo, err := gitinfo.Open(ctx, root); err == nil {
+		root = repo.Root()
+		repo.Close()
+	}
+
+	candidates := []string{target}
+	if !filepath.IsAbs(target) {
+		candidates = []string{filepath.Join(root, target)}
+		if project == "" {
+			candidates = append([]string{filepath.Join(getCurrentDir(), target)}, candidates...)
+		}
+	}
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, candidate)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s is outside the project at %s", target, root)
+		}
+		kind := contextdoc.FocusFile
+		if info.IsDir() {
+			kind = contextdoc.FocusDirectory
+		}
+		return &contextdoc.Focus{Target: target, Kind: kind, Path: filepath.ToSlash(rel), Root: root}, nil
+	}
+
+	declarations, err := symbols.Find(ctx, root, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up symbol %s: %w", target, err)
+	}
+	if len(declarations) == 0 {
+		return nil, fmt.Errorf("%q is not a file, directory or Go symbol in %s", target, root)
+	}
+	declaration := declarations[0]
+	return &contextdoc.Focus{
+		Target:      declaration.Name,
+		Kind:        contextdoc.FocusSymbol,
+		Path:        declaration.File,
+		Line:        declaration.Line,
+		Declaration: declaration.Signature,
+		Root:        root,
+	}, nil
+}
+
+// buildFocusDocument fills the header and runs the focus providers until ctx is done
+func buildFocusDocument(ctx context.Context, project string, focus *contextdoc.Focus) (*contextdoc.Document, []provider.Timing, error) {
+	doc := newContextDocument(project)
+	doc.Focus = focus
+
+	var errs []error
+	cfg, err := config.Load()
+	if err != nil {
+		errs = append(errs, err)
+		cfg = &config.Config{}
+	}
+	providers, err := focusProviders.Resolve(config.Sections{})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	timings, err := provider.Build(ctx, providers, provider.Request{Project: project, Config: cfg}, doc)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	recordSectionTimings(timings, doc.Project)
+	return doc, timings, errors.Join(errs...)
+}
+
+// provideFocusHistory adds the latest commits touching the focused file or directory
+func provideFocusHistory(ctx context.Context, req provider.Request, doc *contextdoc.Document) error {
+	commits, err := readCommits(ctx, doc.Focus.Root, gitinfo.LogOptions{Limit: focusHistoryLimit, Paths: []string{doc.Focus.Path}})
+	if err != nil && !errors.Is(err, gitinfo.ErrNotRepository) && !errors.Is(err, gitinfo.ErrNoCommits) {
+		return err
+	}
+	doc.FocusCommits = commits
+	return nil
+}
+
+// provideFocusDiffs adds uncommitted changes to the focus and the diffs of its latest commits.
+// For a symbol, only hunks mentioning it are kept.
+func provideFocusDiffs(ctx context.Context, req provider.Request, doc *contextdoc.Document) error {
+	repo, err := gitinfo.Open(ctx, doc.Focus.Root)
+	if err != nil {
+		if errors.Is(err, gitinfo.ErrNotRepository) {
+			return nil
+		}
+		return err
+	}
+	defer repo.Close()
+
+	ident := ""
+	if doc.Focus.Kind == contextdoc.FocusSymbol {
+		ident = symbolIdent(doc.Focus.Target)
+	}
+	add := func(sha, subject, patch string) {
+		if patch = hunksMentioning(patch, ident); patch == "" {
+			return
+		}
+		patch, truncated := truncateLines(patch, focusPatchLines)
+		doc.Patches = append(doc.Patches, contextdoc.Patch{SHA: sha, Subject: subject, Patch: patch, Truncated: truncated})
+	}
+
+	working, err := repo.WorkingPatch(ctx, doc.Focus.Path)
+	if err != nil && !errors.Is(err, gitinfo.ErrNoCommits) {
+		return err
+	}
+	add("", "", working)
+
+	commits, err := repo.Log(ctx, gitinfo.LogOptions{Limit: focusPatchCommits, Paths: []string{doc.Focus.Path}})
+	if err != nil && !errors.Is(err, gitinfo.ErrNoCommits) {
+		return err
+	}
+	for _, commit := range commits {
+		patch, err := repo.Patch(ctx, commit.SHA, doc.Focus.Path)
+		if err != nil {
+			return err
+		}
+		add(commit.ShortSHA(), commit.Subject, patch)
+	}
+	return nil
+}
+
+// provideReferences searches the project for uses of the focus: the identifier of a symbol,
+// the import path of a Go package, or otherwise the file name or directory path. Uses in test
+// files are listed as tests, together with test files named after the focus.
+func provideReferences(ctx context.Context, req provider.Request, doc *contextdoc.Document) error {
+	focus := doc.Focus
+	query := focusQuery(focus)
+	if query.Pattern == "" {
+		return nil
+	}
+	targets := []search.Target{{Project: doc.Project, Root: focus.Root}}
+	hits, err := searchHits(ctx, targets, query, search.Options{MaxPerFile: maxFocusReferences})
+	if err != nil {
+		return err
+	}
+	hits = search.Rank(hits, nil, time.Now())
+
+	tests := make(map[string]bool)
+	for _, hit := range hits {
+		if focus.Kind == contextdoc.FocusSymbol && hit.File == focus.Path && hit.Line == focus.Line ||
+			focus.Kind != contextdoc.FocusSymbol && withinPath(hit.File, focus.Path) {
+			continue
+		}
+		result := contextdoc.SearchHit{Project: hit.Project, File: hit.File, Line: hit.Line, Column: hit.Column, Snippet: truncate(hit.Snippet, 80)}
+		switch {
+		case isTestFile(hit.File):
+			if len(doc.Tests) < maxFocusTests {
+				doc.Tests = append(doc.Tests, result)
+				tests[hit.File] = true
+			}
+		case len(doc.References) < maxFocusReferences:
+			doc.References = append(doc.References, result)
+		}
+	}
+
+	for _, file := range focusTestFiles(focus) {
+		if !tests[file] && len(doc.Tests) < maxFocusTests {
+			doc.Tests = append(doc.Tests, contextdoc.SearchHit{Project: doc.Project, File: file})
+		}
+	}
+	return ctx.Err()
+}
+
+// provideFocusNotes adds the project's open notes that mention the focus
+func provideFocusNotes(ctx context.Context, req provider.Request, doc *contextdoc.Document) error {
+	if db == nil {
+		return nil
+	}
+	notes, err := db.GetNotes(ecosystem.NoteFilter{Project: doc.Project, Limit: focusNoteScan})
+	if err != nil {
+		return err
+	}
+
+	terms := []string{strings.ToLower(doc.Focus.Path), strings.ToLower(path.Base(doc.Focus.Path))}
+	if doc.Focus.Kind == contextdoc.FocusSymbol {
+		terms = []string{strings.ToLower(symbolIdent(doc.Focus.Target))}
+	}
+	var entries []string
+	for _, note := range notes {
+		body := strings.ToLower(note.Body)
+		for _, term := range terms {
+			if term != "." && strings.Contains(body, term) {
+				entries = append(entries, fmt.Sprintf("#%d %s", note.ID, formatNote(note, note.CreatedAt.Local().Format("01-02 15:04"))))
+				break
+			}
+		}
+		if len(entries) == contextNoteLimit {
+			break
+		}
+	}
+	if len(entries) > 0 {
+		doc.AddSection(sectionFocusNotes, titleFocusNotes, entries...)
+	}
+	return nil
+}
+
+// focusQuery is how uses of the focus are searched for
+func focusQuery(focus *contextdoc.Focus) search.Query {
+	if focus.Kind == contextdoc.FocusSymbol {
+		ident := regexp.QuoteMeta(symbolIdent(focus.Target))
+		pattern := `\b` + ident + `\b`
+		// Methods are referred to through a value
+		if strings.Contains(focus.Target, ".") {
+			pattern = `\.` + ident + `\b`
+		}
+		return search.Query{Pattern: pattern, Regex: true, CaseSensitive: true}
+	}
+
+	dir := focus.Path
+	if focus.Kind == contextdoc.FocusFile {
+		dir = path.Dir(focus.Path)
+	}
+	isGo := focus.Kind == contextdoc.FocusDirectory || strings.HasSuffix(focus.Path, ".go")
+	if module := goModulePath(focus.Root); isGo && module != "" && dir != "." {
+		return search.Query{Pattern: `"` + module + "/" + dir + `"`, CaseSensitive: true}
+	}
+	if focus.Kind == contextdoc.FocusDirectory {
+		if focus.Path == "." {
+			return search.Query{}
+		}
+		return search.Query{Pattern: focus.Path, CaseSensitive: true}
+	}
+	return search.Query{Pattern: path.Base(focus.Path), CaseSensitive: true}
+}
+
+// focusTestFiles lists existing test files named after the focused file, or the test files
+// directly inside the focused directory
+func focusTestFiles(focus *contextdoc.Focus) []string {
+	if focus.Kind == contextdoc.FocusDirectory {
+		entries, err := os.ReadDir(filepath.Join(focus.Root, filepath.FromSlash(focus.Path)))
+		if err != nil {
+			return nil
+		}
+		var files []string
+		for _, entry := range entries {
+			if !entry.IsDir() && isTestFile(entry.Name()) {
+				files = append(files, path.Join(focus.Path, entry.Name()))
+			}
+		}
+		return files
+	}
+
+	dir, base := path.Split(focus.Path)
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	var files []string
+	for _, name := range []string{stem + "_test" + ext, stem + ".test" + ext, stem + ".spec" + ext, "test_" + base} {
+		if name == base {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(focus.Root, filepath.FromSlash(dir), name)); err == nil {
+			files = append(files, dir+name)
+		}
+	}
+	return files
+}
+
+// isTestFile reports whether a path looks like a test by the usual naming conventions
+func isTestFile(file string) bool {
+	base := path.Base(file)
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	if strings.HasSuffix(stem, "_test") || strings.HasSuffix(stem, ".test") || strings.HasSuffix(stem, ".spec") || strings.HasPrefix(stem, "test_") {
+		return true
+	}
+	for _, dir := range strings.Split(path.Dir(file), "/") {
+		if dir == "test" || dir == "tests" || dir == "__tests__" {
+			return true
+		}
+	}
+	return false
+}
+
+// withinPath reports whether file is focus or inside it
+func withinPath(file, focus string) bool {
+	return focus == "." || file == focus || strings.HasPrefix(file, focus+"/")
+}
+
+// symbolIdent is the identifier of a symbol, without the receiver type of a method
+func symbolIdent(name string) string {
+	if _, method, ok := strings.Cut(name, "."); ok {
+		return method
+	}
+	return name
+}
+
+// goModulePath reads the module path from go.mod at root, or "" if there is none
+func goModulePath(root string) string {
+	file, err := os.Open(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`)
+		}
+	}
+	return ""
+}
+
+// hunksMentioning keeps the file headers of a patch and only the hunks whose lines contain
+// ident; an empty ident keeps the whole patch. It returns "" when no hunk matches.
+func hunksMentioning(patch, ident string) string {
+	if ident == "" || patch == "" {
+		return patch
+	}
+	var kept, header, hunk []string
+	matched := false
+	flush := func() {
+		if len(hunk) > 0 && strings.Contains(strings.Join(hunk[1:], "\n"), ident) {
+			kept = append(kept, header...)
+			kept = append(kept, hunk...)
+			header = nil
+			matched = true
+		}
+		hunk = nil
+	}
+	for _, line := range strings.Split(strings.TrimRight(patch, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			header = []string{line}
+		case strings.HasPrefix(line, "@@"):
+			flush()
+			hunk = []string{line}
+		case hunk != nil:
+			hunk = append(hunk, line)
+		default:
+			header = append(header, line)
+		}
+	}
+	flush()
+	if !matched {
+		return ""
+	}
+	return strings.Join(kept, "\n") + "\n"
+}
+
+// truncateLines keeps at most limit lines of text, reporting whether any were dropped
+func truncateLines(text string, limit int) (string, bool) {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) <= limit {
+		return text, false
+	}
+	return strings.Join(lines[:limit], "\n") + "\n", true
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/contextdoc"
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/atotto/clipboard"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Browse, compare and restore saved contexts",
+	Long:  "Every pull saves its context. List, search, show, diff, copy and delete them by ID.",
+}
+
+var historyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved contexts, newest first (or best matches first with --keyword)",
+	Run: func(cmd *cobra.Command, args []string) {
+		keyword, _ := cmd.Flags().GetString("keyword")
+		filter, err := historyFilterFromFlags(cmd)
+		if err != nil {
+			fail("%v", err)
+		}
+		showHistory(keyword, filter)
+	},
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Print a saved context",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		session := loadContext(args[0])
+
+		// The header goes to stderr so the context itself can be piped
+		fmt.Fprintf(os.Stderr, "📜 CONTEXT #%d: %s | %s%s | %s\n", session.ID, session.Project,
+			session.Timestamp.Local().Format("2006-01-02T15:04"), formatSessionGit(*session), session.SessionInfo)
+		fmt.Println(session.ContextData)
+	},
+}
+
+var historyDiffCmd = &cobra.Command{
+	Use:   "diff <from-id> <to-id>",
+	Short: "Show what changed in the project between two pulls",
+	Long:  "Compare two saved contexts: new commits, files that became dirty or clean, and new insights.",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, to := loadContext(args[0]), loadContext(args[1])
+		showContextDiff(from, to)
+	},
+}
+
+var historyCopyCmd = &cobra.Command{
+	Use:   "copy <id>",
+	Short: "Copy a saved context to the clipboard",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		session := loadContext(args[0])
+		if err := clipboard.WriteAll(session.ContextData); err != nil {
+			fail("Could not copy to clipboard: %v", err)
+		}
+		fmt.Printf("📋 Context #%d (%s, %s) copied to clipboard! Paste and build.\n",
+			session.ID, session.Project, session.Timestamp.Local().Format("2006-01-02T15:04"))
+	},
+}
+
+var historyDeleteCmd = &cobra.Command{
+	Use:   "delete <id>...",
+	Short: "Delete saved contexts",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireDB()
+		var errs []error
+		for _, arg := range args {
+			id, err := parseID("context", arg)
+			if err == nil {
+				err = db.DeleteContext(id)
+			}
+			if err != nil {
+				fmt.Printf("⚠️  Could not delete %s: %v\n", arg, err)
+				err = fmt.Errorf("could not delete %s: %w", arg, err)
+				errs = append(errs, err)
+				continue
+			}
+			fmt.Printf("🧹 Deleted context #%d\n", id)
+		}
+		if len(errs) > 0 {
+			exitWithError(errors.Join(errs...))
+		}
+	},
+}
+
+// historyFilter is the history flags shared by search and the recent listing
+type historyFilter struct {
+	Project string
+	// AllProjects lists every project instead of the current one when Project is empty
+	AllProjects bool
+	Branch      string
+	Since       time.Time
+	Limit       int
+}
+
+// historyFilterFromFlags reads --project, --all, --branch, --since and --limit
+func historyFilterFromFlags(cmd *cobra.Command) (historyFilter, error) {
+	var filter historyFilter
+	filter.Project, _ = cmd.Flags().GetString("project")
+	filter.AllProjects, _ = cmd.Flags().GetBool("all")
+	filter.Branch, _ = cmd.Flags().GetString("branch")
+	filter.Limit, _ = cmd.Flags().GetInt("limit")
+	sinceValue, _ := cmd.Flags().GetString("since")
+
+	if filter.Limit < 1 {
+		return filter, fmt.Errorf("--limit must be positive")
+	}
+	since, err := parseSince(sinceValue)
+	filter.Since = since
+	return filter, err
+}
+
+// showHistory searches saved contexts for keyword, or lists recent contexts when keyword is empty
+func showHistory(keyword string, filter historyFilter) {
+	if db == nil {
+		fmt.Println("⚠️  Database not available - history search disabled")
+		return
+	}
+	if keyword != "" {
+		showHistorySearch(keyword, filter)
+	} else {
+		showRecentHistory(filter)
+	}
+}
+
+// showHistorySearch prints ranked full-text matches with the matching excerpt
+func showHistorySearch(keyword string, filter historyFilter) {
+	project := ""
+	if filter.Project != "" {
+		project = canonicalProjectName(filter.Project)
+	}
+	results, err := db.SearchContexts(ecosystem.ContextSearch{
+		Query:   keyword,
+		Project: project,
+		Branch:  filter.Branch,
+		Since:   filter.Since,
+		Limit:   filter.Limit,
+	})
+	if err != nil {
+		fmt.Printf("⚠️  Could not search history: %v\n", err)
+		return
+	}
+	if len(results) == 0 {
+		fmt.Println("📚 No matching contexts found in history")
+		return
+	}
+
+	fmt.Printf("📚 CONTEXT HISTORY SEARCH: '%s' (best matches first)\n", keyword)
+	for _, result := range results {
+		fmt.Printf("  • #%d [%s] %s%s | %s\n", result.ID, result.Project, result.Timestamp.Local().Format("2006-01-02T15:04"), formatSessionGit(result.ContextSession), result.SessionInfo)
+		if result.Snippet != "" {
+			fmt.Printf("      %s\n", result.Snippet)
+		}
+	}
+}
+
+// showRecentHistory lists the newest contexts saved for the selected, current or every project
+func showRecentHistory(filter historyFilter) {
+	var project string
+	switch {
+	case filter.Project != "":
+		project = canonicalProjectName(filter.Project)
+	case !filter.AllProjects:
+		project = getProjectName()
+	}
+
+	results, err := db.ListContexts(ecosystem.ContextSearch{
+		Project: project,
+		Branch:  filter.Branch,
+		Since:   filter.Since,
+		Limit:   filter.Limit,
+	})
+	if err != nil {
+		fmt.Printf("⚠️  Could not get history: %v\n", err)
+		return
+	}
+	if len(results) == 0 {
+		fmt.Println("📚 No context history found for this project")
+		return
+	}
+
+	scope := project
+	if scope == "" {
+		scope = "all projects"
+	}
+	if filter.Branch != "" {
+		fmt.Printf("📚 RECENT CONTEXTS (%s on %s):\n", scope, filter.Branch)
+	} else {
+		fmt.Printf("📚 RECENT CONTEXTS (%s):\n", scope)
+	}
+	for _, result := range results {
+		fmt.Printf("  • #%d ", result.ID)
+		if project == "" {
+			fmt.Printf("[%s] ", result.Project)
+		}
+		fmt.Printf("📅 %s%s | %s\n", result.Timestamp.Local().Format("2006-01-02T15:04"), formatSessionGit(result), result.SessionInfo)
+	}
+}
+
+// showContextDiff prints what changed in the project between two saved contexts
+func showContextDiff(from, to *ecosystem.ContextSession) {
+	fmt.Printf("🔀 CONTEXT #%d → #%d: %s\n", from.ID, to.ID, to.Project)
+	fmt.Printf("📅 %s → %s\n", from.Timestamp.Local().Format("2006-01-02T15:04"), to.Timestamp.Local().Format("2006-01-02T15:04"))
+	if from.Project != to.Project {
+		fmt.Printf("⚠️  Comparing different projects: %s and %s\n", from.Project, to.Project)
+	}
+	if to.Timestamp.Before(from.Timestamp) {
+		fmt.Printf("⚠️  #%d is older than #%d - showing changes backwards in time\n", to.ID, from.ID)
+	}
+	fromGit, toGit := sessionGitState(*from), sessionGitState(*to)
+	if fromGit != nil && toGit != nil && fromGit.String() != toGit.String() {
+		fmt.Printf("🌿 %s → %s\n", fromGit.String(), toGit.String())
+	}
+
+	diff := contextdoc.Compare(contextdoc.Parse(from.ContextData), contextdoc.Parse(to.ContextData))
+	if diff.Empty() {
+		fmt.Println("\n✨ No new commits, working tree changes or insights")
+		return
+	}
+	if len(diff.NewCommits) > 0 {
+		fmt.Printf("\n📝 NEW COMMITS (%d):\n", len(diff.NewCommits))
+		for _, commit := range diff.NewCommits {
+			fmt.Printf("  • %s %s\n", commit.SHA, commit.Subject)
+		}
+	}
+	if len(diff.Dirtied) > 0 {
+		fmt.Println("\n🔄 NOW UNCOMMITTED:")
+		for _, change := range diff.Dirtied {
+			fmt.Printf("  • %s %s\n", change.Status, change.Path)
+		}
+	}
+	if len(diff.Cleaned) > 0 {
+		fmt.Println("\n✅ NOW CLEAN:")
+		for _, change := range diff.Cleaned {
+			fmt.Printf("  • %s\n", change.Path)
+		}
+	}
+	if len(diff.NewInsights) > 0 {
+		fmt.Println("\n💭 NEW INSIGHTS:")
+		for _, insight := range diff.NewInsights {
+			fmt.Printf("  • %s\n", insight)
+		}
+	}
+}
+
+// loadContext fetches a saved context by ID, exiting when it does not exist
+func loadContext(arg string) *ecosystem.ContextSession {
+	requireDB()
+	id, err := parseID("context", arg)
+	if err != nil {
+		fail("%v", err)
+	}
+	session, err := db.GetContext(id)
+	if err != nil {
+		fail("Could not load context: %v", err)
+	}
+	if session == nil {
+		fail("No saved context #%d (see: wherewasi history list)", id)
+	}
+	return session
+}
+
+// parseID accepts context and note IDs as listed, with or without the leading #
+func parseID(kind, arg string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid %s ID %q", kind, arg)
+	}
+	return id, nil
+}
+
+// parseSince parses --since and --older-than as a relative age (90m, 36h, 7d, 2w) or a date (2006-01-02, RFC 3339)
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	now := time.Now()
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(value, suffix)); err == nil && strings.HasSuffix(value, suffix) && n >= 0 {
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	}
+	if age, err := time.ParseDuration(value); err == nil && age >= 0 {
+		return now.Add(-age), nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid age or date %q (use an age like 36h, 7d or 2w, or a date like 2006-01-02)", value)
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/spf13/cobra"
+)
+
+// hookMarker identifies hook scripts written by wherewasi
+const hookMarker = "# wherewasi-hook: managed by 'wherewasi hooks install'"
+
+// chainedSuffix is appended to pre-existing hooks that the wherewasi hook chains to
+const chainedSuffix = ".pre-wherewasi"
+
+// managedHooks are the git hooks wherewasi installs
+var managedHooks = []string{"post-commit", "post-checkout", "post-merge"}
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Manage git hooks that record commit activity",
+	Long:  "Install git hooks in ecosystem projects so commits, checkouts and merges are recorded even when the daemon is not running",
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install wherewasi hooks in all ecosystem projects",
+	Run: func(cmd *cobra.Command, args []string) {
+		executable, err := os.Executable()
+		if err != nil {
+			fail("Could not locate wherewasi binary: %v", err)
+		}
+
+		fmt.Println("🪝 Installing git hooks:")
+		for _, project := range ecosystemProjects() {
+			if err := installHooks(project.Path, executable); err != nil {
+				fmt.Printf("  ⚠️  %s: %v\n", project.Name, err)
+				continue
+			}
+			fmt.Printf("  ✅ %s\n", project.Name)
+		}
+	},
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove wherewasi hooks and restore chained hooks",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("🪝 Removing git hooks:")
+		for _, project := range ecosystemProjects() {
+			if err := uninstallHooks(project.Path); err != nil {
+				fmt.Printf("  ⚠️  %s: %v\n", project.Name, err)
+				continue
+			}
+			fmt.Printf("  🧹 %s\n", project.Name)
+		}
+	},
+}
+
+var hooksStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which ecosystem projects have wherewasi hooks",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("🪝 Git hook status:")
+		for _, project := range ecosystemProjects() {
+			fmt.Printf("  • %s: %s\n", project.Name, hookStatus(project.Path))
+		}
+	},
+}
+
+// hookEventCmd is invoked by the installed hook scripts
+var hookEventCmd = &cobra.Command{
+	Use:    "hook-event <hook> [args...]",
+	Short:  "Record a git hook invocation",
+	Hidden: true,
+	Args:   cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Without a database there is nowhere to record the event, or the failure
+		if db == nil {
+			os.Exit(1)
+		}
+		if err := recordHookEvent(args[0], args[1:]); err != nil {
+			fail("Could not record %s: %v", args[0], err)
+		}
+	},
+}
+
+// hooksDir resolves the hooks directory, honouring core.hooksPath
+func hooksDir(project string) (string, error) {
+	cmd := exec.Command("git", "-C", project, "rev-parse", "--git-path", "hooks")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve hooks directory: %w", err)
+	}
+
+	dir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(project, dir)
+	}
+	return dir, nil
+}
+
+func installHooks(project, executable string) error {
+	dir, err := hooksDir(project)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	for _, hook := range managedHooks {
+		hookPath := filepath.Join(dir, hook)
+
+		// Keep an existing foreign hook by chaining to it
+		if existing, err := os.ReadFile(hookPath); err == nil && !isManagedHook(existing) {
+			if _, err := os.Stat(hookPath + chainedSuffix); err == nil {
+				return fmt.Errorf("%s already has a chained hook at %s", hook, hookPath+chainedSuffix)
+			}
+			if err := os.Rename(hookPath, hookPath+chainedSuffix); err != nil {
+				return fmt.Errorf("failed to preserve existing %s hook: %w", hook, err)
+			}
+		}
+
+		if err := os.WriteFile(hookPath, []byte(hookScript(hook, executable)), 0755); err != nil {
+			return fmt.Errorf("failed to write %s hook: %w", hook, err)
+		}
+	}
+	return nil
+}
+
+func uninstallHooks(project string) error {
+	dir, err := hooksDir(project)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range managedHooks {
+		hookPath := filepath.Join(dir, hook)
+
+		existing, err := os.ReadFile(hookPath)
+		if err != nil || !isManagedHook(existing) {
+			continue
+		}
+		if err := os.Remove(hookPath); err != nil {
+			return fmt.Errorf("failed to remove %s hook: %w", hook, err)
+		}
+		if _, err := os.Stat(hookPath + chainedSuffix); err == nil {
+			if err := os.Rename(hookPath+chainedSuffix, hookPath); err != nil {
+				return fmt.Errorf("failed to restore chained %s hook: %w", hook, err)
+			}
+		}
+	}
+	return nil
+}
+
+// hookStatus summarizes which managed hooks are installed in a project
+func hookStatus(project string) string {
+	dir, err := hooksDir(project)
+	if err != nil {
+		return "⚠️  not a git repository"
+	}
+
+	var installed, chained []string
+	for _, hook := range managedHooks {
+		hookPath := filepath.Join(dir, hook)
+		if content, err := os.ReadFile(hookPath); err == nil && isManagedHook(content) {
+			installed = append(installed, hook)
+			if _, err := os.Stat(hookPath + chainedSuffix); err == nil {
+				chained = append(chained, hook)
+			}
+		}
+	}
+
+	switch {
+	case len(installed) == 0:
+		return "not installed"
+	case len(installed) < len(managedHooks):
+		return fmt.Sprintf("partial (%s)", strings.Join(installed, ", "))
+	case len(chained) > 0:
+		return fmt.Sprintf("installed (chained: %s)", strings.Join(chained, ", "))
+	default:
+		return "installed"
+	}
+}
+
+func isManagedHook(content []byte) bool {
+	return strings.Contains(string(content), hookMarker)
+}
+
+// hookScript runs any chained hook first, keeping its exit status, then records the event.
+// Recording is synchronous so HEAD cannot move before it is read.
+func hookScript(hook, executable string) string {
+	return fmt.Sprintf(`#!/bin/sh
+%s
+status=0
+if [ -x "$0%s" ]; then
+	"$0%s" "$@"
+	status=$?
+fi
+%s hook-event %s "$@" >/dev/null 2>&1
+exit $status
+`, hookMarker, chainedSuffix, chainedSuffix, shellQuote(executable), hook)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// recordHookEvent stores a project_activity message for a hook invocation and bumps the project's last activity.
+// Activity is recorded under the project's name in the ecosystem, which may differ from its directory.
+func recordHookEvent(hook string, args []string) error {
+	root := getProjectRoot()
+	name := filepath.Base(root)
+	if found, ok := findProject(root); ok {
+		name = found.Name
+	}
+
+	branch := gitValue(root, "symbolic-ref", "--short", "-q", "HEAD")
+	if branch == "" {
+		branch = "HEAD"
+	}
+	commit := gitValue(root, "rev-parse", "HEAD")
+
+	activity := ecosystem.ProjectActivityMessageData{
+		Project:   name,
+		Tool:      ecosystem.ToolWherewasi,
+		GitBranch: &branch,
+		GitCommit: &commit,
+	}
+
+	switch hook {
+	case "post-commit":
+		activity.Activity = ecosystem.ActivityCommit
+		activity.Detail = gitValue(root, "log", "-1", "--format=%h %s")
+	case "post-checkout":
+		// The third argument is 1 for branch checkouts and 0 for file checkouts
+		if len(args) < 3 || args[2] != "1" {
+			return nil
+		}
+		previous := gitValue(root, "rev-parse", "--abbrev-ref", "@{-1}")
+		if previous == "" && len(args[0]) >= 7 {
+			previous = args[0][:7]
+		}
+		if previous == branch {
+			return nil
+		}
+		activity.Activity = ecosystem.ActivityBranchSwitch
+		activity.Detail = fmt.Sprintf("%s → %s", previous, branch)
+	case "post-merge":
+		activity.Activity = ecosystem.ActivityMerge
+		activity.Detail = gitValue(root, "log", "-1", "--format=%h %s")
+	default:
+		return fmt.Errorf("unknown hook %s", hook)
+	}
+
+	if err := db.RecordProjectActivity(activity); err != nil {
+		return err
+	}
+	return db.TrackProject(name, root, ecosystem.ToolWherewasi, true)
+}
+
+// gitValue runs a git command in dir and returns its trimmed output, or "" on error
+func gitValue(dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+)
+
+// initRepo creates a git repository with one commit and returns its top-level directory
+func initRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+		{"commit", "-q", "--allow-empty", "-m", "Initial commit"},
+	} {
+		if output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	return gitValue(dir, "rev-parse", "--show-toplevel")
+}
+
+// runHook runs an installed hook script the way git does and returns its exit status
+func runHook(t *testing.T, repo, hook string, args ...string) int {
+	t.Helper()
+	cmd := exec.Command(filepath.Join(repo, ".git", "hooks", hook), args...)
+	cmd.Dir = repo
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("Failed to run %s hook: %v", hook, err)
+	}
+	return 0
+}
+
+func TestInstallHooks(t *testing.T) {
+	repo := initRepo(t)
+	hooks := filepath.Join(repo, ".git", "hooks")
+	logs := t.TempDir()
+
+	// A stand-in for the wherewasi binary that logs how the hook called it
+	recorder := filepath.Join(logs, "recorder")
+	recorderScript := "#!/bin/sh\necho \"$@\" >> " + shellQuote(filepath.Join(logs, "recorded")) + "\n"
+	if err := os.WriteFile(recorder, []byte(recorderScript), 0755); err != nil {
+		t.Fatal(err)
+	}
+	foreign := "#!/bin/sh\necho foreign >> " + shellQuote(filepath.Join(logs, "foreign")) + "\nexit 3\n"
+	if err := os.WriteFile(filepath.Join(hooks, "post-commit"), []byte(foreign), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := installHooks(repo, recorder); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if status := hookStatus(repo); status != "installed (chained: post-commit)" {
+		t.Errorf("Unexpected status after install: %s", status)
+	}
+	if code := runHook(t, repo, "post-commit"); code != 3 {
+		t.Errorf("Expected the chained hook's exit status 3, got %d", code)
+	}
+	if data, err := os.ReadFile(filepath.Join(logs, "foreign")); err != nil || string(data) != "foreign\n" {
+		t.Errorf("Expected the chained hook to run once, got %q (%v)", data, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(logs, "recorded")); err != nil || string(data) != "hook-event post-commit\n" {
+		t.Errorf("Expected the event to be recorded after the chained hook, got %q (%v)", data, err)
+	}
+
+	// Installing again replaces the wherewasi hooks without chaining them to themselves
+	if err := installHooks(repo, recorder); err != nil {
+		t.Fatalf("Second install failed: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(hooks, "post-commit"+chainedSuffix)); err != nil || string(data) != foreign {
+		t.Errorf("Expected the chained hook to be the original after a second install, got %q (%v)", data, err)
+	}
+	for _, hook := range []string{"post-checkout", "post-merge"} {
+		if _, err := os.Stat(filepath.Join(hooks, hook+chainedSuffix)); err == nil {
+			t.Errorf("Expected no chained %s hook after a second install", hook)
+		}
+	}
+	if code := runHook(t, repo, "post-commit"); code != 3 {
+		t.Errorf("Expected the chained hook's exit status after a second install, got %d", code)
+	}
+	if data, _ := os.ReadFile(filepath.Join(logs, "foreign")); strings.Count(string(data), "foreign") != 2 {
+		t.Errorf("Expected the chained hook to run once per commit, got %q", data)
+	}
+
+	if err := uninstallHooks(repo); err != nil {
+		t.Fatalf("Uninstall failed: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(hooks, "post-commit")); err != nil || string(data) != foreign {
+		t.Errorf("Expected the original hook to be restored, got %q (%v)", data, err)
+	}
+	for _, hook := range managedHooks {
+		if _, err := os.Stat(filepath.Join(hooks, hook+chainedSuffix)); err == nil {
+			t.Errorf("Expected no chained %s hook after uninstall", hook)
+		}
+	}
+	for _, hook := range []string{"post-checkout", "post-merge"} {
+		if _, err := os.Stat(filepath.Join(hooks, hook)); err == nil {
+			t.Errorf("Expected the %s hook to be removed", hook)
+		}
+	}
+	if status := hookStatus(repo); status != "not installed" {
+		t.Errorf("Unexpected status after uninstall: %s", status)
+	}
+}
+
+func TestRecordHookEvent(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	repo := initRepo(t)
+
+	// The repository is outside the configured roots and registered under another name
+	configDir := filepath.Join(home, ".config", "wherewasi")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	roots := "ecosystem:\n  roots:\n    - path: " + t.TempDir() + "\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(roots), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var err error
+	db, err = ecosystem.NewEcosystemDB(ecosystem.DatabaseConfig{
+		ToolName:     ecosystem.ToolWherewasi,
+		FallbackPath: filepath.Join(home, "test.sqlite"),
+		ForceLocal:   true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		db = nil
+	})
+	if err := db.TrackProject("renamed", repo, ecosystem.ToolWherewasi, true); err != nil {
+		t.Fatal(err)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	head := gitValue(repo, "rev-parse", "HEAD")
+	// A file checkout has flag 0 and is not a branch switch
+	if err := recordHookEvent("post-checkout", []string{head, head, "0"}); err != nil {
+		t.Fatalf("Recording a file checkout failed: %v", err)
+	}
+	if err := recordHookEvent("post-commit", nil); err != nil {
+		t.Fatalf("Recording a commit failed: %v", err)
+	}
+
+	messages, err := db.GetProjectActivity("renamed", time.Now().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("Expected only the commit under the registered name, got %d messages", len(messages))
+	}
+	var activity ecosystem.ProjectActivityMessageData
+	if err := messages[0].ParseMessageData(&activity); err != nil {
+		t.Fatal(err)
+	}
+	if activity.Activity != ecosystem.ActivityCommit || !strings.HasSuffix(activity.Detail, "Initial commit") {
+		t.Errorf("Unexpected activity %+v", activity)
+	}
+	if other, _ := db.GetProjectActivity(filepath.Base(repo), time.Now().Add(-time.Hour), 10); len(other) != 0 {
+		t.Errorf("Expected nothing recorded under the directory name, got %d messages", len(other))
+	}
+
+	if err := recordHookEvent("pre-push", nil); err == nil {
+		t.Error("Expected an unknown hook to be rejected")
+	}
+}
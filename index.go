@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/search"
+	"github.com/spf13/cobra"
+)
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Maintain the cross-project search index",
+	Long: `Maintain the trigram index that makes pull --keyword fast across projects.
+
+The index lives in the database and covers the text files of every git repository a
+keyword search visits. Searches refresh it first: repositories with a clean working tree
+that was already indexed are skipped, and elsewhere only files whose size or modification
+time changed are read again. Ignore rules are the same as for searching.`,
+}
+
+var indexRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Index every project from scratch",
+	Run: func(cmd *cobra.Command, args []string) {
+		project, _ := cmd.Flags().GetString("project")
+		requireDB()
+
+		var targets []search.Target
+		if project != "" {
+			found, ok := findProject(project)
+			if !ok {
+				fail("Unknown project %q", project)
+			}
+			targets = []search.Target{{Project: found.Name, Root: found.Path}}
+		} else {
+			// Dropping everything also forgets projects that are no longer tracked
+			if err := db.ClearFileIndex(""); err != nil {
+				fail("%v", err)
+			}
+			targets = searchTargets("")
+		}
+
+		fmt.Printf("🔎 Rebuilding the search index for %d project(s)...\n", len(targets))
+		start := time.Now()
+		results, err := search.NewIndex(db).Rebuild(context.Background(), targets)
+		if err != nil {
+			fail("%v", err)
+		}
+
+		files := 0
+		indexed := make(map[string]bool, len(results))
+		for _, result := range results {
+			fmt.Printf("  • %s: %d files\n", result.Project, result.Added)
+			files += result.Added
+			indexed[result.Project] = true
+		}
+		var skipped []string
+		for _, target := range targets {
+			if !indexed[target.Project] {
+				skipped = append(skipped, target.Project)
+			}
+		}
+		if len(skipped) > 0 {
+			fmt.Printf("  ⏭️  Not git repositories, searched without the index: %s\n", strings.Join(skipped, ", "))
+		}
+		fmt.Printf("✅ Indexed %d files in %d project(s) in %s\n", files, len(results), formatDuration(time.Since(start)))
+	},
+}
+
+var indexStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show what the search index covers and what needs refreshing",
+	Run: func(cmd *cobra.Command, args []string) {
+		requireDB()
+		projects, err := db.IndexedProjects()
+		if err != nil {
+			fail("%v", err)
+		}
+		if len(projects) == 0 {
+			fmt.Println("🔎 The search index is empty; it fills on the next pull --keyword or index rebuild")
+			return
+		}
+
+		index := search.NewIndex(db)
+		ctx := context.Background()
+		files := 0
+		var size int64
+		for _, project := range projects {
+			files += project.Files
+			size += project.Bytes
+		}
+		fmt.Printf("🔎 Search index: %d project(s), %d files, %s of source\n", len(projects), files, formatBytes(size))
+
+		indexed := make(map[string]bool, len(projects))
+		for _, project := range projects {
+			indexed[project.Project] = true
+			state := "🔄 checks changed files on next search"
+			if index.UpToDate(ctx, project) {
+				state = "✅ up to date"
+			}
+			fmt.Printf("  • %-20s %6d files %10s  indexed %s ago  %s\n", project.Project, project.Files,
+				formatBytes(project.Bytes), formatAge(time.Since(project.IndexedAt)), state)
+		}
+
+		var missing []string
+		for _, target := range searchTargets("") {
+			if !indexed[target.Project] {
+				missing = append(missing, target.Project)
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			fmt.Printf("  📂 Not indexed yet: %s\n", strings.Join(missing, ", "))
+		}
+	},
+}
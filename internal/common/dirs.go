@@ -60,3 +60,17 @@ func GetConfigDir() string {
 func GetDefaultDBPath() string {
 	return filepath.Join(GetDataDir(), "context.sqlite")
 }
+
+// GetDaemonPIDPath returns the PID file of the background tracking daemon
+// Linux/macOS: ~/.local/share/wherewasi/daemon.pid
+// Windows: %APPDATA%/wherewasi/daemon.pid
+func GetDaemonPIDPath() string {
+	return filepath.Join(GetDataDir(), "daemon.pid")
+}
+
+// GetDaemonLogPath returns the log file of the background tracking daemon
+// Linux/macOS: ~/.local/share/wherewasi/daemon.log
+// Windows: %APPDATA%/wherewasi/daemon.log
+func GetDaemonLogPath() string {
+	return filepath.Join(GetDataDir(), "daemon.log")
+}
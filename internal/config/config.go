@@ -0,0 +1,146 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/QRY91/wherewasi/internal/common"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the user config file inside the config directory
+const FileName = "config.yaml"
+
+// Config is the user configuration loaded from config.yaml
+type Config struct {
+	Ecosystem Ecosystem          `yaml:"ecosystem,omitempty"`
+	Projects  map[string]Project `yaml:"projects,omitempty"`
+	Sections  Sections           `yaml:"sections,omitempty"`
+	Storage   Storage            `yaml:"storage,omitempty"`
+	Usage     Usage              `yaml:"usage,omitempty"`
+}
+
+// Ecosystem describes the set of projects wherewasi tracks and how to find them
+type Ecosystem struct {
+	// Name is shown in the section title, e.g. "QRY" renders "QRY ECOSYSTEM CONTEXT"
+	Name        string   `yaml:"name,omitempty"`
+	Description []string `yaml:"description,omitempty"`
+
+	// Roots are scanned for git repositories; the parent of the current directory when empty
+	Roots []Root `yaml:"roots,omitempty"`
+	// Depth is how many directory levels below a root are searched (default 1)
+	Depth int `yaml:"depth,omitempty"`
+	// Include and Exclude are globs over repository names or paths below a root
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// Root is a directory containing projects, written either as a plain path or as {path, depth}
+type Root struct {
+	Path  string `yaml:"path"`
+	Depth int    `yaml:"depth,omitempty"`
+}
+
+// UnmarshalYAML accepts a plain path as shorthand for a root with the default depth
+func (r *Root) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&r.Path)
+	}
+	type plain Root
+	return node.Decode((*plain)(r))
+}
+
+// MarshalYAML writes roots without a depth as plain paths
+func (r Root) MarshalYAML() (interface{}, error) {
+	if r.Depth == 0 {
+		return r.Path, nil
+	}
+	type plain Root
+	return plain(r), nil
+}
+
+// Project holds per-project settings keyed by project name
+type Project struct {
+	Description string `yaml:"description,omitempty"`
+}
+
+// Sections controls which context providers run and in what order
+type Sections struct {
+	// Order lists providers to run first; unlisted providers follow in registration order
+	Order []string `yaml:"order,omitempty"`
+	// Disabled lists providers that are skipped
+	Disabled []string `yaml:"disabled,omitempty"`
+}
+
+// Storage controls how saved contexts are kept
+type Storage struct {
+	// Compression stores new contexts compressed ("gzip"); compressed contexts are searched
+	// by session info and keywords only
+	Compression string `yaml:"compression,omitempty"`
+}
+
+// Usage controls the local command usage records shown by 'wherewasi stats'
+type Usage struct {
+	// Disabled stops recording command timings and outcomes
+	Disabled bool `yaml:"disabled,omitempty"`
+}
+
+// Path returns the location of the user config file
+// Linux/macOS: ~/.config/wherewasi/config.yaml
+// Windows: %APPDATA%/wherewasi/config.yaml
+func Path() string {
+	return filepath.Join(common.GetConfigDir(), FileName)
+}
+
+// Load reads the user config, returning an empty config when the file does not exist
+func Load() (*Config, error) {
+	return LoadFile(Path())
+}
+
+// LoadFile reads a config file, returning an empty config when it does not exist
+func LoadFile(path string) (*Config, error) {
+	cfg := &Config{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes the config to the user config file.
+// Comments in a hand-edited file are not preserved.
+func (c *Config) Save() error {
+	return c.SaveFile(Path())
+}
+
+// SaveFile writes the config to path, creating its directory
+func (c *Config) SaveFile(path string) error {
+	return writeYAML(path, c)
+}
+
+func writeYAML(path string, v interface{}) error {
+	var data bytes.Buffer
+	encoder := yaml.NewEncoder(&data)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wherewasi", FileName)
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("Expected missing config to load empty, got %v", err)
+	}
+
+	cfg.Ecosystem = Ecosystem{Name: "acme", Description: []string{"Payments platform"}}
+	cfg.SetProjectDescription("api", "Public REST API")
+	cfg.Ecosystem.Roots = []Root{{Path: "~/work"}, {Path: "~/src", Depth: 3}}
+	cfg.Sections.Disabled = []string{"insights"}
+	if err := cfg.SaveFile(path); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if loaded.Ecosystem.Name != "acme" || len(loaded.Ecosystem.Description) != 1 {
+		t.Errorf("Expected ecosystem to round-trip, got %+v", loaded.Ecosystem)
+	}
+	if len(loaded.Ecosystem.Roots) != 2 || loaded.Ecosystem.Roots[0].Path != "~/work" || loaded.Ecosystem.Roots[1].Depth != 3 {
+		t.Errorf("Expected plain and nested roots to round-trip, got %+v", loaded.Ecosystem.Roots)
+	}
+	if loaded.Projects["api"].Description != "Public REST API" {
+		t.Errorf("Expected project description to round-trip, got %+v", loaded.Projects)
+	}
+
+	loaded.SetProjectDescription("api", "")
+	if _, ok := loaded.Projects["api"]; ok {
+		t.Error("Expected empty description to remove the project entry")
+	}
+}
+
+func TestProjectDescription(t *testing.T) {
+	root := t.TempDir()
+	readme := "# api\n\n[![CI](badge.svg)](ci)\n\n**The public REST API**\n\n## Usage\n\nRun it.\n"
+	if err := os.WriteFile(filepath.Join(root, "README.md"), []byte(readme), 0644); err != nil {
+		t.Fatalf("Failed to write README: %v", err)
+	}
+
+	cfg := &Config{}
+	assertDescription := func(expected, expectedSource string) {
+		t.Helper()
+		description, source, err := cfg.ProjectDescription("api", root)
+		if err != nil {
+			t.Fatalf("Failed to resolve description: %v", err)
+		}
+		if description != expected || source != expectedSource {
+			t.Errorf("Expected %q from %s, got %q from %s", expected, expectedSource, description, source)
+		}
+	}
+
+	assertDescription("The public REST API", SourceReadme)
+
+	if err := SaveRepo(root, &Repo{Description: "Shared description"}); err != nil {
+		t.Fatalf("Failed to save repo config: %v", err)
+	}
+	assertDescription("Shared description", SourceRepo)
+
+	cfg.SetProjectDescription("api", "My own notes")
+	assertDescription("My own notes", SourceUser)
+}
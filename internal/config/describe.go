@@ -0,0 +1,167 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoFileName is the optional per-repository config committed alongside the code
+const RepoFileName = ".wherewasi.yaml"
+
+// maxReadmeDescription caps the README fallback so a long intro does not flood the context
+const maxReadmeDescription = 300
+
+// Description sources, from highest to lowest precedence
+const (
+	SourceUser   = "config"
+	SourceRepo   = RepoFileName
+	SourceReadme = "README"
+)
+
+// Repo is the per-repository config stored in .wherewasi.yaml
+type Repo struct {
+	Description string `yaml:"description,omitempty"`
+}
+
+// RepoPath returns the location of a repository's .wherewasi.yaml
+func RepoPath(root string) string {
+	return filepath.Join(root, RepoFileName)
+}
+
+// LoadRepo reads a repository's .wherewasi.yaml, returning an empty config when it does not exist
+func LoadRepo(root string) (*Repo, error) {
+	repo := &Repo{}
+
+	path := RepoPath(root)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return repo, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, repo); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return repo, nil
+}
+
+// SaveRepo writes a repository's .wherewasi.yaml
+func SaveRepo(root string, repo *Repo) error {
+	return writeYAML(RepoPath(root), repo)
+}
+
+// ProjectDescription resolves a project's description and where it came from.
+// The user config overrides the repository file, which overrides the README.
+func (c *Config) ProjectDescription(name, root string) (string, string, error) {
+	if description := c.UserDescription(name); description != "" {
+		return description, SourceUser, nil
+	}
+	return RepoDescription(root)
+}
+
+// UserDescription returns the description set in the user config, or ""
+func (c *Config) UserDescription(name string) string {
+	return c.Projects[name].Description
+}
+
+// RepoDescription resolves a description from the repository itself: .wherewasi.yaml, then the README
+func RepoDescription(root string) (string, string, error) {
+	if root == "" {
+		return "", "", nil
+	}
+	repo, err := LoadRepo(root)
+	if err != nil {
+		return "", "", err
+	}
+	if repo.Description != "" {
+		return repo.Description, SourceRepo, nil
+	}
+
+	if description := ReadmeParagraph(root); description != "" {
+		return description, SourceReadme, nil
+	}
+	return "", "", nil
+}
+
+// SetProjectDescription stores a description in the user config; an empty description removes it
+func (c *Config) SetProjectDescription(name, description string) {
+	if description == "" {
+		delete(c.Projects, name)
+		return
+	}
+	if c.Projects == nil {
+		c.Projects = make(map[string]Project)
+	}
+	project := c.Projects[name]
+	project.Description = description
+	c.Projects[name] = project
+}
+
+// ReadmeParagraph returns the first prose paragraph of a project's README,
+// skipping headings, badges, HTML and code blocks
+func ReadmeParagraph(root string) string {
+	var file *os.File
+	for _, name := range []string{"README.md", "README", "README.txt", "readme.md"} {
+		f, err := os.Open(filepath.Join(root, name))
+		if err == nil {
+			file = f
+			break
+		}
+	}
+	if file == nil {
+		return ""
+	}
+	defer file.Close()
+
+	var paragraph []string
+	inCode := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+
+		skip := line == "" ||
+			strings.HasPrefix(line, "#") ||
+			strings.HasPrefix(line, "<") ||
+			strings.HasPrefix(line, "[![") ||
+			strings.HasPrefix(line, "![") ||
+			strings.HasPrefix(line, "---") ||
+			strings.HasPrefix(line, "===")
+		if skip {
+			if len(paragraph) > 0 {
+				break
+			}
+			continue
+		}
+		// A bold tagline reads better without its markers
+		if strings.HasPrefix(line, "**") && strings.HasSuffix(line, "**") {
+			line = strings.Trim(line, "*")
+		}
+		paragraph = append(paragraph, strings.TrimPrefix(line, "> "))
+	}
+
+	description := strings.Join(paragraph, " ")
+	if len(description) > maxReadmeDescription {
+		cut := strings.LastIndex(description[:maxReadmeDescription], " ")
+		if cut <= 0 {
+			cut = maxReadmeDescription
+		}
+		description = description[:cut] + "..."
+	}
+	return description
+}
@@ -0,0 +1,212 @@
+package contextdoc
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Trim records how many entries of a section were dropped to fit the token budget
+type Trim struct {
+	Section string `json:"section"`
+	Dropped int    `json:"dropped"`
+	Total   int    `json:"total"`
+}
+
+// String renders a trim as "search: dropped 12 of 20"
+func (t Trim) String() string {
+	return fmt.Sprintf("%s: dropped %d of %d", t.Section, t.Dropped, t.Total)
+}
+
+// EstimateTokens approximates the token count of text at roughly four bytes per token.
+// Emoji and other multi-byte characters therefore count as about one token each.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// Output formats FitTokens can budget
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// trimStep drops entries from the end of a section, always keeping the first keep
+type trimStep struct {
+	section string
+	keep    int
+}
+
+// trimOrder lists sections from lowest to highest priority. Older diffs go before the
+// uncommitted changes or latest commit, and commits are newest first, so the most recent
+// of each is always kept.
+var trimOrder = []trimStep{
+	{SectionSearch, 0},
+	{SectionReferences, 0},
+	{SectionInsights, 0},
+	{SectionFocusDiffs, 1},
+	{SectionCommits, 1},
+}
+
+// FitTokens drops the lowest-priority entries until the output in format fits maxTokens.
+// The output is measured once and each dropped entry's size is subtracted from it.
+// It returns false if the budget could not be met after trimming everything allowed.
+func (d *Document) FitTokens(maxTokens int, format string) bool {
+	if maxTokens <= 0 {
+		return true
+	}
+	d.TokenBudget = maxTokens
+
+	// EstimateTokens stays within maxTokens up to four bytes per token
+	maxSize := maxTokens * 4
+	size, sections := d.measure(format)
+	report := d.trimReportSize(format)
+	for _, step := range trimOrder {
+		// A timed-out section renders as a notice whatever its entries
+		if format == FormatText && d.timedOut(step.section) {
+			continue
+		}
+		total := d.sectionLength(step.section)
+		dropped := 0
+		for size > maxSize && total-dropped > step.keep {
+			entry := d.lastEntrySize(step.section, format)
+			d.dropLast(step.section)
+			size -= entry
+			sections[step.section] -= entry
+
+			// The trim report is part of the output, so it is updated as entries go
+			if dropped == 0 {
+				d.Trimmed = append(d.Trimmed, Trim{Section: step.section, Total: total})
+			}
+			dropped++
+			d.Trimmed[len(d.Trimmed)-1].Dropped = dropped
+			next := d.trimReportSize(format)
+			size += next - report
+			report = next
+		}
+		// An emptied section loses its title too
+		if dropped > 0 && dropped == total && format == FormatText {
+			size += len(d.renderSection(step.section)) - sections[step.section]
+		}
+	}
+
+	return d.Tokens(format) <= maxTokens
+}
+
+// Tokens estimates the size of the output in format
+func (d *Document) Tokens(format string) int {
+	if format == FormatJSON {
+		data, err := d.JSON()
+		if err != nil {
+			return 0
+		}
+		return EstimateTokens(data)
+	}
+	return EstimateTokens(d.Text())
+}
+
+// measure returns the size of the output in format and, for text, of each section
+func (d *Document) measure(format string) (int, map[string]int) {
+	sizes := map[string]int{}
+	if format == FormatJSON {
+		data, err := d.JSON()
+		if err != nil {
+			return 0, sizes
+		}
+		return len(data), sizes
+	}
+	size := 0
+	for _, section := range d.sections() {
+		sizes[section.name] = len(section.text)
+		size += len(section.text)
+	}
+	return size, sizes
+}
+
+// trimReportSize returns the size of the trim report in format
+func (d *Document) trimReportSize(format string) int {
+	if format == FormatJSON {
+		report := struct {
+			TokenBudget int    `json:"token_budget,omitempty"`
+			Trimmed     []Trim `json:"trimmed,omitempty"`
+		}{d.TokenBudget, d.Trimmed}
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return 0
+		}
+		return len(data)
+	}
+	return len(d.renderTrimReport())
+}
+
+// lastEntrySize returns the size of a section's last entry in format
+func (d *Document) lastEntrySize(section, format string) int {
+	var text string
+	var value any
+	switch section {
+	case SectionSearch:
+		hit := d.SearchHits[len(d.SearchHits)-1]
+		text, value = bullet(hit.String()), hit
+	case SectionReferences:
+		hit := d.References[len(d.References)-1]
+		text, value = bullet(hit.String()), hit
+	case SectionInsights:
+		insight := d.Insights[len(d.Insights)-1]
+		text, value = bullet(insight), insight
+	case SectionFocusDiffs:
+		patch := d.Patches[len(d.Patches)-1]
+		text, value = renderPatch(patch), patch
+	case SectionCommits:
+		commit := d.Commits[len(d.Commits)-1]
+		text, value = bullet(commit.SHA+" "+commit.Subject), commit
+	}
+	if format != FormatJSON {
+		return len(text)
+	}
+	// Array elements are indented two levels and followed by a comma and newline
+	data, err := json.MarshalIndent(value, "    ", "  ")
+	if err != nil {
+		return 0
+	}
+	return len("    ") + len(data) + len(",\n")
+}
+
+// dropLast removes the last entry of a section
+func (d *Document) dropLast(section string) {
+	switch section {
+	case SectionSearch:
+		d.SearchHits = d.SearchHits[:len(d.SearchHits)-1]
+	case SectionReferences:
+		d.References = d.References[:len(d.References)-1]
+	case SectionInsights:
+		d.Insights = d.Insights[:len(d.Insights)-1]
+	case SectionFocusDiffs:
+		d.Patches = d.Patches[:len(d.Patches)-1]
+	case SectionCommits:
+		d.Commits = d.Commits[:len(d.Commits)-1]
+	}
+}
+
+// wasTrimmed reports whether FitTokens removed entries from a section
+func (d *Document) wasTrimmed(section string) bool {
+	for _, trim := range d.Trimmed {
+		if trim.Section == section {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Document) sectionLength(section string) int {
+	switch section {
+	case SectionSearch:
+		return len(d.SearchHits)
+	case SectionInsights:
+		return len(d.Insights)
+	case SectionCommits:
+		return len(d.Commits)
+	case SectionReferences:
+		return len(d.References)
+	case SectionFocusDiffs:
+		return len(d.Patches)
+	}
+	return 0
+}
@@ -0,0 +1,374 @@
+package contextdoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+)
+
+// SchemaVersion is bumped whenever the JSON layout changes incompatibly
+const SchemaVersion = 1
+
+// Document is the typed model behind every pull, rendered as text or JSON
+type Document struct {
+	Version     int       `json:"version"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	Project      string              `json:"project"`
+	Focused      bool                `json:"focused"`
+	ProjectFound bool                `json:"project_found"`
+	Location     string              `json:"location,omitempty"`
+	Git          *ecosystem.GitState `json:"git,omitempty"`
+	Session      string              `json:"session,omitempty"`
+
+	EcosystemName string   `json:"ecosystem_name,omitempty"`
+	Ecosystem     []string `json:"ecosystem,omitempty"`
+	Description   string   `json:"description,omitempty"`
+
+	// CommitDays is the --days window; 0 means the most recent commits
+	CommitDays  int      `json:"commit_days"`
+	Commits     []Commit `json:"commits"`
+	CommitError string   `json:"commit_error,omitempty"`
+
+	Changes  []Change `json:"uncommitted_changes"`
+	KeyFiles []string `json:"key_files"`
+	Activity []string `json:"tracked_activity,omitempty"`
+	Insights []string `json:"insights"`
+
+	Keyword    string      `json:"keyword,omitempty"`
+	SearchHits []SearchHit `json:"search_hits,omitempty"`
+
+	// Focus and the fields after it are only set by focused pulls
+	Focus        *Focus      `json:"focus,omitempty"`
+	FocusCommits []Commit    `json:"focus_commits,omitempty"`
+	Patches      []Patch     `json:"patches,omitempty"`
+	References   []SearchHit `json:"references,omitempty"`
+	Tests        []SearchHit `json:"tests,omitempty"`
+
+	// Sections holds custom sections added by context providers
+	Sections []Section `json:"sections,omitempty"`
+	// Order lists the sections to render between header and footer; nil means DefaultOrder
+	Order []string `json:"-"`
+	// TimedOut lists sections whose provider did not finish before the pull deadline
+	TimedOut []string `json:"timed_out,omitempty"`
+
+	// TokenBudget and Trimmed are set by FitTokens
+	TokenBudget int    `json:"token_budget,omitempty"`
+	Trimmed     []Trim `json:"trimmed,omitempty"`
+}
+
+// Section is a custom block of bullet points, such as open TODOs or test status
+type Section struct {
+	Name  string   `json:"name"`
+	Title string   `json:"title"`
+	Items []string `json:"items"`
+}
+
+// Commit is a single entry from git log
+type Commit struct {
+	SHA     string    `json:"sha"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body,omitempty"`
+	Author  string    `json:"author,omitempty"`
+	Date    time.Time `json:"date"`
+}
+
+// Change is an uncommitted change as reported by git status --porcelain. Status is the
+// two-letter code, staged side first, e.g. "M " or " M"; older saved contexts have one letter.
+type Change struct {
+	Status string `json:"status"`
+	Path   string `json:"path"`
+}
+
+// SearchHit is a keyword match in an ecosystem project
+type SearchHit struct {
+	Project string `json:"project"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Snippet string `json:"snippet"`
+	// LineRange is the surrounding conversation for chat history hits, e.g. "120-145"
+	LineRange string `json:"line_range,omitempty"`
+	// Score is the ranking relevance; hits are listed best first
+	Score float64 `json:"score,omitempty"`
+}
+
+// IsChat reports whether the hit is in a chat history file
+func (h SearchHit) IsChat() bool {
+	return h.LineRange != ""
+}
+
+// New creates an empty document stamped with the current schema version
+func New() *Document {
+	return &Document{
+		Version:     SchemaVersion,
+		GeneratedAt: time.Now(),
+	}
+}
+
+// JSON renders the document as indented JSON
+func (d *Document) JSON() (string, error) {
+	// Empty sections are encoded as [] rather than null
+	out := *d
+	if out.Commits == nil {
+		out.Commits = []Commit{}
+	}
+	if out.Changes == nil {
+		out.Changes = []Change{}
+	}
+	if out.KeyFiles == nil {
+		out.KeyFiles = []string{}
+	}
+	if out.Insights == nil {
+		out.Insights = []string{}
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode context: %w", err)
+	}
+	return string(data), nil
+}
+
+// Section names used when rendering and budgeting the text output
+const (
+	SectionHeader    = "header"
+	SectionEcosystem = "ecosystem"
+	SectionCommits   = "commits"
+	SectionChanges   = "changes"
+	SectionKeyFiles  = "key_files"
+	SectionActivity  = "activity"
+	SectionInsights  = "insights"
+	SectionSearch    = "search"
+	SectionFooter    = "footer"
+)
+
+// DefaultOrder is the order of the built-in sections between header and footer
+var DefaultOrder = []string{
+	SectionEcosystem,
+	SectionCommits,
+	SectionChanges,
+	SectionKeyFiles,
+	SectionActivity,
+	SectionInsights,
+	SectionSearch,
+}
+
+// Section titles in the text output, shared by the renderer and Parse
+const (
+	titleProject  = "🏠 CURRENT PROJECT"
+	titleFocused  = "🎯 FOCUSED ON"
+	titleLocation = "📍 LOCATION"
+	titleBranch   = "🌿 BRANCH"
+	titleSession  = "⚡ ACTIVE SESSION"
+	titleCommits  = "📝 RECENT COMMITS"
+	titleDays     = "⏰ LAST %d DAYS"
+	titleChanges  = "🔄 UNCOMMITTED CHANGES"
+	titleKeyFiles = "📁 KEY FILES"
+	titleActivity = "📡 TRACKED ACTIVITY (24h)"
+	titleInsights = "💭 RECENT DEVELOPMENT INSIGHTS"
+)
+
+// renderedSection is one block of the text output
+type renderedSection struct {
+	name string
+	text string
+}
+
+// AddSection appends a custom section rendered under title
+func (d *Document) AddSection(name, title string, items ...string) {
+	d.Sections = append(d.Sections, Section{Name: name, Title: title, Items: items})
+}
+
+// Text renders the document in the classic ripcord format
+func (d *Document) Text() string {
+	var context strings.Builder
+	for _, section := range d.sections() {
+		context.WriteString(section.text)
+	}
+	return context.String()
+}
+
+// sections renders each part of the text output separately so it can be measured
+func (d *Document) sections() []renderedSection {
+	sections := []renderedSection{{name: SectionHeader, text: d.renderHeader()}}
+	for _, name := range d.sectionOrder() {
+		if text := d.renderSection(name); text != "" {
+			sections = append(sections, renderedSection{name: name, text: text})
+		}
+	}
+	return append(sections, renderedSection{name: SectionFooter, text: d.renderFooter()})
+}
+
+// sectionOrder is Order when providers set it, otherwise the built-in order followed by custom sections
+func (d *Document) sectionOrder() []string {
+	if d.Order != nil {
+		return d.Order
+	}
+	order := append([]string{}, DefaultOrder...)
+	for _, section := range d.Sections {
+		order = append(order, section.Name)
+	}
+	return order
+}
+
+func (d *Document) renderHeader() string {
+	var context strings.Builder
+	context.WriteString("--- AI CONTEXT DEPLOYMENT ---\n")
+	if d.Focused {
+		context.WriteString(fmt.Sprintf("%s: %s\n", titleFocused, d.Project))
+		if !d.ProjectFound {
+			context.WriteString("⚠️  Project not found in current ecosystem\n")
+		}
+	} else {
+		context.WriteString(fmt.Sprintf("%s: %s\n", titleProject, d.Project))
+		context.WriteString(fmt.Sprintf("%s: %s\n", titleLocation, d.Location))
+		if d.Git != nil {
+			context.WriteString(fmt.Sprintf("%s: %s\n", titleBranch, d.Git.String()))
+		}
+	}
+	if d.Session != "" {
+		context.WriteString(fmt.Sprintf("%s: %s\n", titleSession, d.Session))
+	}
+	context.WriteString(d.renderFocusHeader())
+	return context.String()
+}
+
+// renderSection renders one section by name, or "" when it has nothing to show
+func (d *Document) renderSection(name string) string {
+	var context strings.Builder
+
+	if d.timedOut(name) {
+		title := strings.ToUpper(strings.ReplaceAll(name, "_", " "))
+		return fmt.Sprintf("\n⏱️  %s (timed out)\n", title)
+	}
+
+	switch name {
+	case SectionEcosystem:
+		if len(d.Ecosystem) > 0 {
+			title := "ECOSYSTEM CONTEXT"
+			if d.EcosystemName != "" {
+				title = strings.ToUpper(d.EcosystemName) + " " + title
+			}
+			context.WriteString(fmt.Sprintf("\n🧠 %s:\n", title))
+			for _, line := range d.Ecosystem {
+				context.WriteString(fmt.Sprintf("- %s\n", line))
+			}
+		}
+		if d.Description != "" {
+			context.WriteString(fmt.Sprintf("\n📖 ABOUT %s:\n  %s\n", d.Project, d.Description))
+		}
+	case SectionCommits:
+		if d.CommitDays > 0 {
+			context.WriteString(fmt.Sprintf("\n"+titleDays+":\n", d.CommitDays))
+			switch {
+			case d.CommitError != "":
+				context.WriteString(fmt.Sprintf("  • %s\n", d.CommitError))
+			case len(d.Commits) == 0:
+				context.WriteString("  • No commits found in timeframe\n")
+			}
+			writeCommits(&context, d.Commits)
+		} else if len(d.Commits) > 0 || d.CommitError != "" {
+			context.WriteString("\n" + titleCommits + ":\n")
+			if d.CommitError != "" {
+				context.WriteString(fmt.Sprintf("  • %s\n", d.CommitError))
+			}
+			writeCommits(&context, d.Commits)
+		}
+	case SectionChanges:
+		if len(d.Changes) > 0 {
+			context.WriteString("\n" + titleChanges + ":\n")
+			for _, change := range d.Changes {
+				context.WriteString(fmt.Sprintf("  • %s %s\n", change.Status, change.Path))
+			}
+		}
+	case SectionKeyFiles:
+		context.WriteString("\n" + titleKeyFiles + ":\n")
+		for _, file := range d.KeyFiles {
+			context.WriteString(fmt.Sprintf("  • %s\n", file))
+		}
+	case SectionActivity:
+		if len(d.Activity) > 0 {
+			context.WriteString("\n" + titleActivity + ":\n")
+			for _, entry := range d.Activity {
+				context.WriteString(fmt.Sprintf("  • %s\n", entry))
+			}
+		}
+	case SectionInsights:
+		if len(d.Insights) > 0 {
+			context.WriteString("\n" + titleInsights + ":\n")
+			for _, insight := range d.Insights {
+				context.WriteString(bullet(insight))
+			}
+		}
+	case SectionSearch:
+		// A search emptied by the token budget is dropped rather than reported as "no matches"
+		if d.Keyword != "" && (len(d.SearchHits) > 0 || !d.wasTrimmed(SectionSearch)) {
+			context.WriteString(fmt.Sprintf("\n🔍 CROSS-PROJECT SEARCH: '%s'\n", d.Keyword))
+			if len(d.SearchHits) > 0 {
+				for _, hit := range d.SearchHits {
+					context.WriteString(bullet(hit.String()))
+				}
+			} else {
+				context.WriteString("  • No matches found across ecosystem\n")
+			}
+		}
+	default:
+		if text, ok := d.renderFocusSection(name); ok {
+			return text
+		}
+		for _, section := range d.Sections {
+			if section.Name == name && len(section.Items) > 0 {
+				context.WriteString(fmt.Sprintf("\n%s:\n", section.Title))
+				for _, item := range section.Items {
+					context.WriteString(fmt.Sprintf("  • %s\n", item))
+				}
+			}
+		}
+	}
+
+	return context.String()
+}
+
+func (d *Document) renderFooter() string {
+	return d.renderTrimReport() + "\n🎯 READY FOR AI COLLABORATION\n--- END CONTEXT ---"
+}
+
+// renderTrimReport lists what FitTokens dropped, or "" when nothing was
+func (d *Document) renderTrimReport() string {
+	if len(d.Trimmed) == 0 {
+		return ""
+	}
+	var context strings.Builder
+	context.WriteString(fmt.Sprintf("\n✂️  TRIMMED TO FIT ~%d TOKENS:\n", d.TokenBudget))
+	for _, trim := range d.Trimmed {
+		context.WriteString(bullet(trim.String()))
+	}
+	return context.String()
+}
+
+// String renders a hit as "[project] file:line → snippet", with 💬 and the line range for chat
+// history, or as "[project] file" for a whole file
+func (h SearchHit) String() string {
+	if h.Line == 0 {
+		return fmt.Sprintf("[%s] %s", h.Project, h.File)
+	}
+	if h.IsChat() {
+		return fmt.Sprintf("[%s] 💬 %s:%s → %s", h.Project, h.File, h.LineRange, h.Snippet)
+	}
+	return fmt.Sprintf("[%s] %s:%d → %s", h.Project, h.File, h.Line, h.Snippet)
+}
+
+func writeCommits(context *strings.Builder, commits []Commit) {
+	for _, commit := range commits {
+		context.WriteString(bullet(commit.SHA + " " + commit.Subject))
+	}
+}
+
+// bullet renders one entry of a section
+func bullet(item string) string {
+	return fmt.Sprintf("  • %s\n", item)
+}
@@ -0,0 +1,285 @@
+package contextdoc
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleDocument() *Document {
+	doc := New()
+	doc.Project = "wherewasi"
+	doc.ProjectFound = true
+	doc.Location = "/src/wherewasi"
+	doc.Commits = []Commit{{SHA: "abc1234", Subject: "Add ripcord", Date: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}}
+	doc.Changes = []Change{{Status: "M", Path: "main.go"}}
+	doc.KeyFiles = []string{"README.md"}
+	doc.Keyword = "ripcord"
+	doc.SearchHits = []SearchHit{
+		{Project: "wherewasi", File: "main.go", Line: 42, Snippet: "pull the ripcord"},
+		{Project: "uroboro", File: "cursor_chat.md", Line: 120, Snippet: "ripcord idea", LineRange: "100-120"},
+	}
+	return doc
+}
+
+func TestText(t *testing.T) {
+	text := sampleDocument().Text()
+
+	expected := []string{
+		"--- AI CONTEXT DEPLOYMENT ---",
+		"🏠 CURRENT PROJECT: wherewasi",
+		"📝 RECENT COMMITS:\n  • abc1234 Add ripcord",
+		"🔄 UNCOMMITTED CHANGES:\n  • M main.go",
+		"🔍 CROSS-PROJECT SEARCH: 'ripcord'",
+		"[wherewasi] main.go:42 → pull the ripcord",
+		"[uroboro] 💬 cursor_chat.md:100-120 → ripcord idea",
+		"--- END CONTEXT ---",
+	}
+	for _, want := range expected {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected text output to contain %q", want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	doc := New()
+	doc.Project = "empty"
+
+	data, err := doc.JSON()
+	if err != nil {
+		t.Fatalf("Failed to encode document: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+
+	if decoded["version"] != float64(SchemaVersion) {
+		t.Errorf("Expected version %d, got %v", SchemaVersion, decoded["version"])
+	}
+
+	for _, key := range []string{"commits", "uncommitted_changes", "key_files", "insights"} {
+		if list, ok := decoded[key].([]interface{}); !ok || len(list) != 0 {
+			t.Errorf("Expected %s to encode as an empty list, got %v", key, decoded[key])
+		}
+	}
+}
+
+func TestFitTokens(t *testing.T) {
+	doc := sampleDocument()
+	doc.Insights = []string{"first insight", "second insight"}
+	for i := 0; i < 20; i++ {
+		doc.Commits = append(doc.Commits, Commit{SHA: "def5678", Subject: "Older work on the tracker"})
+	}
+
+	untrimmed := doc.Tokens(FormatText)
+	if !doc.FitTokens(untrimmed, FormatText) {
+		t.Fatalf("Expected document to fit its own size")
+	}
+	if len(doc.Trimmed) != 0 {
+		t.Errorf("Expected nothing trimmed, got %v", doc.Trimmed)
+	}
+
+	// Dropping every hit and insight is not enough, so older commits go too
+	budget := untrimmed - 100
+	if !doc.FitTokens(budget, FormatText) {
+		t.Fatalf("Expected document to fit %d tokens, got %d", budget, doc.Tokens(FormatText))
+	}
+	if len(doc.SearchHits) != 0 || len(doc.Insights) != 0 {
+		t.Errorf("Expected search hits and insights to be dropped first")
+	}
+	if len(doc.Commits) == 0 || len(doc.Commits) == 21 || doc.Commits[0].SHA != "abc1234" {
+		t.Errorf("Expected older commits to be trimmed while keeping the newest, got %d", len(doc.Commits))
+	}
+
+	text := doc.Text()
+	for _, want := range []string{"✂️  TRIMMED TO FIT", "search: dropped 2 of 2", "insights: dropped 2 of 2", "commits: dropped"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected trimmed output to contain %q", want)
+		}
+	}
+	if strings.Contains(text, "No matches found") {
+		t.Errorf("Expected a trimmed search section to be omitted, not reported as empty")
+	}
+
+	if doc.FitTokens(1, FormatText) {
+		t.Errorf("Expected an impossible budget to report failure")
+	}
+}
+
+func TestFitTokensJSON(t *testing.T) {
+	doc := sampleDocument()
+	doc.Insights = []string{"first insight", "second insight"}
+	for i := 0; i < 20; i++ {
+		doc.Commits = append(doc.Commits, Commit{SHA: "def5678", Subject: "Older work on the tracker"})
+	}
+
+	// The JSON output is larger than the text, so a budget the text fits still trims it
+	budget := doc.Tokens(FormatText)
+	if !doc.FitTokens(budget, FormatJSON) {
+		t.Fatalf("Expected JSON to fit %d tokens, got %d", budget, doc.Tokens(FormatJSON))
+	}
+	if len(doc.SearchHits) != 0 || len(doc.Insights) != 0 || len(doc.Commits) == 21 {
+		t.Errorf("Expected hits, insights and older commits to be trimmed, got %d commits", len(doc.Commits))
+	}
+	// Trimming stops as soon as the output fits
+	if doc.Tokens(FormatJSON) < budget-30 {
+		t.Errorf("Expected trimming to stop near the budget %d, got %d", budget, doc.Tokens(FormatJSON))
+	}
+
+	data, err := doc.JSON()
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	for _, want := range []string{`"token_budget"`, `"section": "commits"`} {
+		if !strings.Contains(data, want) {
+			t.Errorf("Expected the JSON to report the trim, missing %s", want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	doc := sampleDocument()
+	doc.CommitDays = 7
+	doc.Insights = []string{"Discussed the ripcord"}
+	doc.Session = "Recent file modifications detected"
+	doc.AddSection("todos", "📌 OPEN TODOS", "main.go:10 TODO tidy up")
+	// Staged and unstaged changes keep git's two-letter code; older contexts have one letter
+	doc.Changes = append(doc.Changes, Change{Status: "M ", Path: "staged.go"}, Change{Status: " M", Path: "unstaged.go"}, Change{Status: "??", Path: "new notes.md"})
+	changes := doc.Changes
+
+	parsed := Parse(doc.Text())
+	if parsed.Project != "wherewasi" || parsed.Location != "/src/wherewasi" || parsed.Session != doc.Session {
+		t.Errorf("Expected the header to round-trip, got %+v", parsed)
+	}
+	if parsed.CommitDays != 7 || len(parsed.Commits) != 1 || parsed.Commits[0].SHA != "abc1234" || parsed.Commits[0].Subject != "Add ripcord" {
+		t.Errorf("Expected commits to round-trip, got %d days %+v", parsed.CommitDays, parsed.Commits)
+	}
+	if !reflect.DeepEqual(parsed.Changes, changes) {
+		t.Errorf("Expected changes to round-trip, got %+v", parsed.Changes)
+	}
+	if len(parsed.KeyFiles) != 1 || len(parsed.Insights) != 1 || parsed.Insights[0] != "Discussed the ripcord" {
+		t.Errorf("Expected key files and insights to round-trip, got %v %v", parsed.KeyFiles, parsed.Insights)
+	}
+	if len(parsed.Sections) != 1 || len(parsed.Sections[0].Items) != 1 {
+		t.Errorf("Expected the custom section to round-trip, got %+v", parsed.Sections)
+	}
+	// Search hits follow the insights but are not mistaken for them
+	if len(parsed.SearchHits) != 0 {
+		t.Errorf("Expected search hits to be skipped, got %v", parsed.SearchHits)
+	}
+
+	empty := New()
+	empty.Project = "quiet"
+	empty.CommitDays = 3
+	if parsed := Parse(empty.Text()); len(parsed.Commits) != 0 {
+		t.Errorf("Expected 'No commits found' not to parse as a commit, got %v", parsed.Commits)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	from := sampleDocument()
+	from.Changes = append(from.Changes, Change{Status: "??", Path: "notes.md"})
+	from.Insights = []string{"old insight"}
+
+	to := sampleDocument()
+	to.Commits = append([]Commit{{SHA: "fff0001", Subject: "Commit notes"}}, to.Commits...)
+	to.Changes = []Change{{Status: "A", Path: "main.go"}, {Status: "M", Path: "daemon.go"}}
+	to.Insights = []string{"old insight", "new insight"}
+
+	diff := Compare(Parse(from.Text()), Parse(to.Text()))
+	if len(diff.NewCommits) != 1 || diff.NewCommits[0].SHA != "fff0001" {
+		t.Errorf("Expected one new commit, got %+v", diff.NewCommits)
+	}
+	if len(diff.Dirtied) != 2 || diff.Dirtied[0].Status != "A" || diff.Dirtied[1].Path != "daemon.go" {
+		t.Errorf("Expected main.go to change status and daemon.go to become dirty, got %+v", diff.Dirtied)
+	}
+	if len(diff.Cleaned) != 1 || diff.Cleaned[0].Path != "notes.md" {
+		t.Errorf("Expected notes.md to become clean, got %+v", diff.Cleaned)
+	}
+	if len(diff.NewInsights) != 1 || diff.NewInsights[0] != "new insight" {
+		t.Errorf("Expected one new insight, got %v", diff.NewInsights)
+	}
+
+	if !Compare(from, from).Empty() {
+		t.Error("Expected a document compared with itself to be unchanged")
+	}
+}
+
+func TestForkMerge(t *testing.T) {
+	doc := sampleDocument()
+	fork := doc.Fork()
+	if fork.Project != doc.Project || fork.Keyword != doc.Keyword || len(fork.Commits) != 0 || len(fork.SearchHits) != 0 {
+		t.Fatalf("Expected a fork with the header only, got %+v", fork)
+	}
+
+	fork.Commits = []Commit{{SHA: "def5678", Subject: "Fork commits"}}
+	fork.Changes = []Change{{Status: "A", Path: "fork.go"}}
+	fork.AddSection("todos", "✅ TODOS", "write tests")
+	doc.Merge(SectionCommits, fork)
+	if len(doc.Commits) != 1 || doc.Commits[0].SHA != "def5678" {
+		t.Errorf("Expected commits to be merged, got %+v", doc.Commits)
+	}
+	if len(doc.Changes) != 1 || doc.Changes[0].Path != "main.go" {
+		t.Errorf("Expected other sections to be left alone, got %+v", doc.Changes)
+	}
+	if len(doc.Sections) != 1 || doc.Sections[0].Name != "todos" {
+		t.Errorf("Expected custom sections to be merged, got %+v", doc.Sections)
+	}
+
+	doc.MarkTimedOut(SectionSearch)
+	doc.MarkTimedOut(SectionSearch)
+	text := doc.Text()
+	if len(doc.TimedOut) != 1 || !strings.Contains(text, "⏱️  SEARCH (timed out)") || strings.Contains(text, "CROSS-PROJECT SEARCH") {
+		t.Errorf("Expected the search section to be marked as timed out:\n%s", text)
+	}
+}
+
+func TestFocus(t *testing.T) {
+	doc := New()
+	doc.Project = "wherewasi"
+	doc.ProjectFound = true
+	doc.Focus = &Focus{Target: "Index.Refresh", Kind: FocusSymbol, Path: "internal/search/index.go", Line: 84, Declaration: "func (ix *Index) Refresh("}
+	doc.FocusCommits = []Commit{{SHA: "abc1234", Subject: "Add index", Author: "qry", Date: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}}
+	doc.Patches = []Patch{
+		{Patch: "@@ -1 +1 @@\n-old\n+new\n"},
+		{SHA: "abc1234", Subject: "Add index", Patch: "+added\n", Truncated: true},
+	}
+	doc.References = []SearchHit{{Project: "wherewasi", File: "main.go", Line: 42, Snippet: "ix.Refresh(ctx, targets)"}}
+	doc.Tests = []SearchHit{{Project: "wherewasi", File: "internal/search/index_test.go"}}
+	doc.Order = []string{SectionFocusHistory, SectionFocusDiffs, SectionReferences}
+	text := doc.Text()
+
+	expected := []string{
+		"🔬 FOCUS: symbol Index.Refresh (internal/search/index.go:84)\n📜 DECLARATION: func (ix *Index) Refresh(",
+		"📝 HISTORY OF internal/search/index.go:\n  • abc1234 2025-06-01 Add index (qry)",
+		"  • uncommitted changes\n    @@ -1 +1 @@\n    -old\n    +new\n",
+		"  • abc1234 Add index\n    +added\n    … (truncated)",
+		"🔗 REFERENCES:\n  • [wherewasi] main.go:42 → ix.Refresh(ctx, targets)",
+		"🧪 TESTS:\n  • [wherewasi] internal/search/index_test.go\n",
+	}
+	for _, want := range expected {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected text output to contain %q:\n%s", want, text)
+		}
+	}
+
+	fork := doc.Fork()
+	if fork.Focus != doc.Focus || len(fork.References) != 0 {
+		t.Errorf("Expected a fork to keep the focus and nothing else, got %+v", fork)
+	}
+	fork.References = []SearchHit{{Project: "wherewasi", File: "index.go", Line: 1}}
+	fork.Tests = nil
+	doc.Merge(SectionReferences, fork)
+	if len(doc.References) != 1 || doc.References[0].File != "index.go" || doc.Tests != nil {
+		t.Errorf("Expected references and tests to be merged together, got %+v and %+v", doc.References, doc.Tests)
+	}
+
+	if parsed := Parse(text); len(parsed.Sections) != 0 || len(parsed.Commits) != 0 {
+		t.Errorf("Expected focused sections to be skipped when parsing, got %+v", parsed)
+	}
+}
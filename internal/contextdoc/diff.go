@@ -0,0 +1,63 @@
+package contextdoc
+
+// Diff is what changed in a project between two context documents
+type Diff struct {
+	// NewCommits are commits made since the earlier document, newest first
+	NewCommits []Commit `json:"new_commits"`
+	// Dirtied are uncommitted changes that are new or changed status
+	Dirtied []Change `json:"dirtied"`
+	// Cleaned are files that had uncommitted changes and are now clean or committed
+	Cleaned     []Change `json:"cleaned"`
+	NewInsights []string `json:"new_insights"`
+}
+
+// Empty reports whether nothing changed
+func (d Diff) Empty() bool {
+	return len(d.NewCommits) == 0 && len(d.Dirtied) == 0 && len(d.Cleaned) == 0 && len(d.NewInsights) == 0
+}
+
+// Compare reports what changed between an earlier and a later document of the same project
+func Compare(from, to *Document) Diff {
+	var diff Diff
+
+	// Commits are newest first, so new ones are those before the newest commit both documents share
+	known := make(map[string]bool, len(from.Commits))
+	for _, commit := range from.Commits {
+		known[commit.SHA] = true
+	}
+	for _, commit := range to.Commits {
+		if known[commit.SHA] {
+			break
+		}
+		diff.NewCommits = append(diff.NewCommits, commit)
+	}
+
+	before := make(map[string]string, len(from.Changes))
+	for _, change := range from.Changes {
+		before[change.Path] = change.Status
+	}
+	after := make(map[string]bool, len(to.Changes))
+	for _, change := range to.Changes {
+		after[change.Path] = true
+		if status, ok := before[change.Path]; !ok || status != change.Status {
+			diff.Dirtied = append(diff.Dirtied, change)
+		}
+	}
+	for _, change := range from.Changes {
+		if !after[change.Path] {
+			diff.Cleaned = append(diff.Cleaned, change)
+		}
+	}
+
+	seen := make(map[string]bool, len(from.Insights))
+	for _, insight := range from.Insights {
+		seen[insight] = true
+	}
+	for _, insight := range to.Insights {
+		if !seen[insight] {
+			diff.NewInsights = append(diff.NewInsights, insight)
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,130 @@
+package contextdoc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Focus kinds
+const (
+	FocusFile      = "file"
+	FocusDirectory = "directory"
+	FocusSymbol    = "symbol"
+)
+
+// Focus is the file, directory or Go symbol a focused pull centres on
+type Focus struct {
+	// Target is what was asked for, e.g. "internal/search" or "Index.Refresh"
+	Target string `json:"target"`
+	Kind   string `json:"kind"`
+	// Path is the file or directory, relative to the repository root; for symbols, the
+	// file declaring it
+	Path string `json:"path"`
+	// Line and Declaration locate a symbol's declaration
+	Line        int    `json:"line,omitempty"`
+	Declaration string `json:"declaration,omitempty"`
+	// Root is the repository the path is relative to
+	Root string `json:"-"`
+}
+
+// String renders the focus as "symbol Index.Refresh (internal/search/index.go:84)"
+func (f Focus) String() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s %s (%s:%d)", f.Kind, f.Target, f.Path, f.Line)
+	}
+	return fmt.Sprintf("%s %s", f.Kind, f.Path)
+}
+
+// Patch is a change to the focused area, from a commit or the working tree
+type Patch struct {
+	// SHA is "" for uncommitted changes
+	SHA     string `json:"sha,omitempty"`
+	Subject string `json:"subject,omitempty"`
+	Patch   string `json:"patch"`
+	// Truncated is set when the patch was cut to fit
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Focused sections, filled by the providers of a focused pull. Tests are found by the same
+// search as references, so they are part of the references section.
+const (
+	SectionFocusHistory = "focus_history"
+	SectionFocusDiffs   = "focus_diffs"
+	SectionReferences   = "references"
+)
+
+// Focused section titles in the text output
+const (
+	titleFocus        = "🔬 FOCUS"
+	titleDeclaration  = "📜 DECLARATION"
+	titleFocusHistory = "📝 HISTORY OF %s"
+	titleFocusDiffs   = "🩹 RECENT DIFFS"
+	titleReferences   = "🔗 REFERENCES"
+	titleTests        = "🧪 TESTS"
+)
+
+// renderFocusHeader renders the focus lines of the header
+func (d *Document) renderFocusHeader() string {
+	if d.Focus == nil {
+		return ""
+	}
+	text := fmt.Sprintf("%s: %s\n", titleFocus, d.Focus.String())
+	if d.Focus.Declaration != "" {
+		text += fmt.Sprintf("%s: %s\n", titleDeclaration, d.Focus.Declaration)
+	}
+	return text
+}
+
+// renderFocusSection renders a focused section, reporting false for other section names
+func (d *Document) renderFocusSection(name string) (string, bool) {
+	var context strings.Builder
+	switch name {
+	case SectionFocusHistory:
+		if len(d.FocusCommits) > 0 {
+			context.WriteString("\n" + fmt.Sprintf(titleFocusHistory, d.Focus.Path) + ":\n")
+			for _, commit := range d.FocusCommits {
+				context.WriteString(fmt.Sprintf("  • %s %s %s (%s)\n", commit.SHA, commit.Date.Format("2006-01-02"), commit.Subject, commit.Author))
+			}
+		}
+	case SectionFocusDiffs:
+		if len(d.Patches) > 0 {
+			context.WriteString("\n" + titleFocusDiffs + ":\n")
+			for _, patch := range d.Patches {
+				context.WriteString(renderPatch(patch))
+			}
+		}
+	case SectionReferences:
+		if len(d.References) > 0 {
+			context.WriteString("\n" + titleReferences + ":\n")
+			for _, hit := range d.References {
+				context.WriteString(bullet(hit.String()))
+			}
+		}
+		if len(d.Tests) > 0 {
+			context.WriteString("\n" + titleTests + ":\n")
+			for _, hit := range d.Tests {
+				context.WriteString(bullet(hit.String()))
+			}
+		}
+	default:
+		return "", false
+	}
+	return context.String(), true
+}
+
+// renderPatch renders a patch under its commit, or under "uncommitted changes"
+func renderPatch(patch Patch) string {
+	label := "uncommitted changes"
+	if patch.SHA != "" {
+		label = patch.SHA + " " + patch.Subject
+	}
+	var context strings.Builder
+	context.WriteString(bullet(label))
+	for _, line := range strings.Split(strings.TrimRight(patch.Patch, "\n"), "\n") {
+		context.WriteString("    " + line + "\n")
+	}
+	if patch.Truncated {
+		context.WriteString("    … (truncated)\n")
+	}
+	return context.String()
+}
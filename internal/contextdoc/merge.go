@@ -0,0 +1,67 @@
+package contextdoc
+
+// Fork returns a document with d's header and no sections, for a provider to fill
+// independently of the others
+func (d *Document) Fork() *Document {
+	return &Document{
+		Version:      d.Version,
+		GeneratedAt:  d.GeneratedAt,
+		Project:      d.Project,
+		Focused:      d.Focused,
+		ProjectFound: d.ProjectFound,
+		Location:     d.Location,
+		Git:          d.Git,
+		Session:      d.Session,
+		Keyword:      d.Keyword,
+		Focus:        d.Focus,
+	}
+}
+
+// Merge copies what a provider filled into a fork back into d: the fields of the
+// built-in section name and any custom sections
+func (d *Document) Merge(name string, from *Document) {
+	switch name {
+	case SectionEcosystem:
+		d.EcosystemName = from.EcosystemName
+		d.Ecosystem = from.Ecosystem
+		d.Description = from.Description
+	case SectionCommits:
+		d.CommitDays = from.CommitDays
+		d.Commits = from.Commits
+		d.CommitError = from.CommitError
+	case SectionChanges:
+		d.Changes = from.Changes
+	case SectionKeyFiles:
+		d.KeyFiles = from.KeyFiles
+	case SectionActivity:
+		d.Activity = from.Activity
+	case SectionInsights:
+		d.Insights = from.Insights
+	case SectionSearch:
+		d.SearchHits = from.SearchHits
+	case SectionFocusHistory:
+		d.FocusCommits = from.FocusCommits
+	case SectionFocusDiffs:
+		d.Patches = from.Patches
+	case SectionReferences:
+		d.References = from.References
+		d.Tests = from.Tests
+	}
+	d.Sections = append(d.Sections, from.Sections...)
+}
+
+// MarkTimedOut records that a section's provider did not finish in time
+func (d *Document) MarkTimedOut(name string) {
+	if !d.timedOut(name) {
+		d.TimedOut = append(d.TimedOut, name)
+	}
+}
+
+func (d *Document) timedOut(name string) bool {
+	for _, section := range d.TimedOut {
+		if section == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package contextdoc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// skippedSection marks blocks Parse does not recover
+const skippedSection = "-"
+
+// Parse recovers a document from its text rendering, so contexts saved as text can be compared.
+// It reads the header, commits, uncommitted changes, key files, activity, insights and custom
+// sections; search hits, the ecosystem block, the sections of focused pulls and the footer are
+// skipped.
+func Parse(text string) *Document {
+	doc := New()
+	doc.GeneratedAt = time.Time{}
+
+	var section string
+	custom := -1
+	for _, line := range strings.Split(text, "\n") {
+		if item, ok := strings.CutPrefix(line, "  • "); ok {
+			switch section {
+			case SectionCommits:
+				if commit, ok := parseCommit(item); ok {
+					doc.Commits = append(doc.Commits, commit)
+				}
+			case SectionChanges:
+				if change, ok := parseChange(item); ok {
+					doc.Changes = append(doc.Changes, change)
+				}
+			case SectionKeyFiles:
+				doc.KeyFiles = append(doc.KeyFiles, item)
+			case SectionActivity:
+				doc.Activity = append(doc.Activity, item)
+			case SectionInsights:
+				doc.Insights = append(doc.Insights, item)
+			case "":
+				if custom >= 0 {
+					doc.Sections[custom].Items = append(doc.Sections[custom].Items, item)
+				}
+			}
+			continue
+		}
+
+		title, value, _ := strings.Cut(line, ": ")
+		switch {
+		case title == titleProject:
+			doc.Project = value
+			doc.ProjectFound = true
+		case title == titleFocused:
+			doc.Project = value
+			doc.Focused = true
+			doc.ProjectFound = true
+		case strings.HasPrefix(line, "⚠️  Project not found"):
+			doc.ProjectFound = false
+		case title == titleLocation:
+			doc.Location = value
+		case title == titleSession:
+			doc.Session = value
+		case strings.HasPrefix(line, "🔍 CROSS-PROJECT SEARCH"):
+			section, custom = skippedSection, -1
+		case strings.HasSuffix(line, ":") && !strings.HasPrefix(line, " "):
+			section, custom = parseSectionTitle(doc, strings.TrimSuffix(line, ":"))
+		}
+	}
+	return doc
+}
+
+// parseSectionTitle maps a section title to its section name, adding custom sections to doc
+// and returning their index
+func parseSectionTitle(doc *Document, title string) (string, int) {
+	var days int
+	switch {
+	case title == titleCommits:
+		return SectionCommits, -1
+	case sscanTitle(title, &days):
+		doc.CommitDays = days
+		return SectionCommits, -1
+	case title == titleChanges:
+		return SectionChanges, -1
+	case title == titleKeyFiles:
+		return SectionKeyFiles, -1
+	case title == titleActivity:
+		return SectionActivity, -1
+	case title == titleInsights:
+		return SectionInsights, -1
+	case strings.Contains(title, "ECOSYSTEM CONTEXT"), strings.HasPrefix(title, "📖 ABOUT"), strings.HasPrefix(title, "✂️"):
+		return skippedSection, -1
+	case strings.HasPrefix(title, strings.TrimSuffix(titleFocusHistory, "%s")), title == titleFocusDiffs, title == titleReferences, title == titleTests:
+		return skippedSection, -1
+	}
+
+	doc.Sections = append(doc.Sections, Section{Name: title, Title: title})
+	return "", len(doc.Sections) - 1
+}
+
+// sscanTitle reads N from a "LAST N DAYS" title
+func sscanTitle(title string, days *int) bool {
+	n, err := fmt.Sscanf(title, titleDays, days)
+	return err == nil && n == 1
+}
+
+// parseCommit reads "sha subject", skipping notes such as "No commits found in timeframe"
+func parseCommit(item string) (Commit, bool) {
+	sha, subject, _ := strings.Cut(item, " ")
+	if len(sha) < 7 || strings.Trim(sha, "0123456789abcdef") != "" {
+		return Commit{}, false
+	}
+	return Commit{SHA: sha, Subject: subject}, true
+}
+
+// parseChange reads "XY path" with git's two-letter code, or "X path" from older contexts
+func parseChange(item string) (Change, bool) {
+	if len(item) > 3 && item[2] == ' ' {
+		return Change{Status: item[:2], Path: item[3:]}, true
+	}
+	status, path, ok := strings.Cut(item, " ")
+	return Change{Status: status, Path: path}, ok
+}
@@ -1,366 +0,0 @@
-package database
-
-import (
-	"os"
-	"testing"
-	"time"
-)
-
-func TestDatabaseIntegration(t *testing.T) {
-	// Create temporary database
-	tmpFile := "/tmp/test_wherewasi.sqlite"
-	defer os.Remove(tmpFile)
-
-	db, err := NewDB(tmpFile)
-	if err != nil {
-		t.Fatalf("Failed to create database: %v", err)
-	}
-	defer db.Close()
-
-	t.Run("SaveContext", func(t *testing.T) {
-		session, err := db.SaveContext("testproject", "Test context data", "Test session", "keyword1,keyword2")
-		if err != nil {
-			t.Fatalf("Failed to save context: %v", err)
-		}
-
-		if session.ID == 0 {
-			t.Error("Expected context session ID to be set")
-		}
-
-		if session.Project != "testproject" {
-			t.Errorf("Expected project 'testproject', got '%s'", session.Project)
-		}
-
-		if session.ContextData != "Test context data" {
-			t.Errorf("Expected context data 'Test context data', got '%s'", session.ContextData)
-		}
-
-		if session.SessionInfo != "Test session" {
-			t.Errorf("Expected session info 'Test session', got '%s'", session.SessionInfo)
-		}
-
-		if session.Keywords != "keyword1,keyword2" {
-			t.Errorf("Expected keywords 'keyword1,keyword2', got '%s'", session.Keywords)
-		}
-	})
-
-	t.Run("GetRecentContexts", func(t *testing.T) {
-		// Insert test contexts
-		_, err := db.SaveContext("project1", "Context 1", "Session 1", "test")
-		if err != nil {
-			t.Fatalf("Failed to save test context: %v", err)
-		}
-
-		_, err = db.SaveContext("project1", "Context 2", "Session 2", "keyword")
-		if err != nil {
-			t.Fatalf("Failed to save test context: %v", err)
-		}
-
-		_, err = db.SaveContext("project2", "Context 3", "Session 3", "other")
-		if err != nil {
-			t.Fatalf("Failed to save test context: %v", err)
-		}
-
-		// Get recent contexts for project1
-		contexts, err := db.GetRecentContexts("project1", 5)
-		if err != nil {
-			t.Fatalf("Failed to get recent contexts: %v", err)
-		}
-
-		if len(contexts) < 2 {
-			t.Errorf("Expected at least 2 contexts for project1, got %d", len(contexts))
-		}
-
-		// Verify all contexts belong to project1
-		for _, context := range contexts {
-			if context.Project != "project1" {
-				t.Errorf("Expected all contexts to be for project1, got '%s'", context.Project)
-			}
-		}
-
-		// Test limiting
-		limitedContexts, err := db.GetRecentContexts("project1", 1)
-		if err != nil {
-			t.Fatalf("Failed to get limited contexts: %v", err)
-		}
-
-		if len(limitedContexts) != 1 {
-			t.Errorf("Expected exactly 1 context with limit, got %d", len(limitedContexts))
-		}
-	})
-
-	t.Run("SearchStoredContexts", func(t *testing.T) {
-		// Insert searchable contexts
-		_, err := db.SaveContext("searchproject", "Context with whisper keyword", "Whisper session", "whisper,ai")
-		if err != nil {
-			t.Fatalf("Failed to save searchable context: %v", err)
-		}
-
-		_, err = db.SaveContext("otherproject", "Different context", "Regular session", "other")
-		if err != nil {
-			t.Fatalf("Failed to save other context: %v", err)
-		}
-
-		// Search for "whisper"
-		results, err := db.SearchStoredContexts("whisper")
-		if err != nil {
-			t.Fatalf("Failed to search contexts: %v", err)
-		}
-
-		if len(results) == 0 {
-			t.Error("Expected to find contexts with 'whisper' keyword")
-		}
-
-		found := false
-		for _, result := range results {
-			if result.Project == "searchproject" && result.SessionInfo == "Whisper session" {
-				found = true
-				break
-			}
-		}
-
-		if !found {
-			t.Error("Expected to find the whisper context in search results")
-		}
-
-		// Search for non-existent keyword
-		noResults, err := db.SearchStoredContexts("nonexistent")
-		if err != nil {
-			t.Fatalf("Failed to search for non-existent keyword: %v", err)
-		}
-
-		if len(noResults) != 0 {
-			t.Errorf("Expected 0 results for non-existent keyword, got %d", len(noResults))
-		}
-	})
-
-	t.Run("TrackProject", func(t *testing.T) {
-		err := db.TrackProject("trackedproject", "/path/to/project", true)
-		if err != nil {
-			t.Fatalf("Failed to track project: %v", err)
-		}
-
-		// Test upsert behavior - track same project again
-		err = db.TrackProject("trackedproject", "/updated/path", false)
-		if err != nil {
-			t.Fatalf("Failed to update tracked project: %v", err)
-		}
-
-		// Verify the project was tracked (would need to add a getter method in production)
-		// For now, just ensure no error occurred
-	})
-
-	t.Run("SendToolMessage", func(t *testing.T) {
-		err := db.SendToolMessage("uroboro", "context_export", `{"project": "test", "data": "context"}`)
-		if err != nil {
-			t.Fatalf("Failed to send tool message: %v", err)
-		}
-	})
-
-	t.Run("TrackUsage", func(t *testing.T) {
-		err := db.TrackUsage("pull", "testproject", 150, true, "")
-		if err != nil {
-			t.Fatalf("Failed to track successful usage: %v", err)
-		}
-
-		err = db.TrackUsage("pull", "testproject", 0, false, "test error")
-		if err != nil {
-			t.Fatalf("Failed to track failed usage: %v", err)
-		}
-	})
-
-	t.Run("EmptyValues", func(t *testing.T) {
-		// Test with empty values
-		session, err := db.SaveContext("emptytest", "Content only", "", "")
-		if err != nil {
-			t.Fatalf("Failed to save context with empty values: %v", err)
-		}
-
-		if session.SessionInfo != "" {
-			t.Error("Expected session info to be empty")
-		}
-
-		if session.Keywords != "" {
-			t.Error("Expected keywords to be empty")
-		}
-
-		// Verify we can read it back
-		contexts, err := db.GetRecentContexts("emptytest", 1)
-		if err != nil {
-			t.Fatalf("Failed to get contexts: %v", err)
-		}
-
-		if len(contexts) == 0 {
-			t.Error("Expected to find the empty-field context")
-		}
-
-		if contexts[0].ContextData != "Content only" {
-			t.Errorf("Expected context data 'Content only', got '%s'", contexts[0].ContextData)
-		}
-	})
-}
-
-func TestSchemaCreation(t *testing.T) {
-	tmpFile := "/tmp/test_wherewasi_schema.sqlite"
-	defer os.Remove(tmpFile)
-
-	db, err := NewDB(tmpFile)
-	if err != nil {
-		t.Fatalf("Failed to create database: %v", err)
-	}
-	defer db.Close()
-
-	// Verify tables exist
-	tables := []string{"context_sessions", "projects", "tool_messages", "usage_stats", "schema_migrations"}
-
-	for _, table := range tables {
-		var count int
-		err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=?", table).Scan(&count)
-		if err != nil {
-			t.Fatalf("Failed to check table %s: %v", table, err)
-		}
-
-		if count != 1 {
-			t.Errorf("Expected table %s to exist", table)
-		}
-	}
-
-	// Verify migration record
-	var version int
-	err = db.QueryRow("SELECT version FROM schema_migrations WHERE version = 1").Scan(&version)
-	if err != nil {
-		t.Fatalf("Failed to find migration record: %v", err)
-	}
-
-	if version != 1 {
-		t.Errorf("Expected migration version 1, got %d", version)
-	}
-
-	// Verify indexes exist
-	indexes := []string{
-		"idx_context_sessions_project",
-		"idx_context_sessions_timestamp",
-		"idx_context_sessions_keywords",
-		"idx_projects_name",
-		"idx_tool_messages_to_tool",
-		"idx_usage_stats_command",
-	}
-
-	for _, index := range indexes {
-		var count int
-		err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='index' AND name=?", index).Scan(&count)
-		if err != nil {
-			t.Fatalf("Failed to check index %s: %v", index, err)
-		}
-
-		if count != 1 {
-			t.Errorf("Expected index %s to exist", index)
-		}
-	}
-}
-
-func TestConcurrentAccess(t *testing.T) {
-	tmpFile := "/tmp/test_wherewasi_concurrent.sqlite"
-	defer os.Remove(tmpFile)
-
-	db, err := NewDB(tmpFile)
-	if err != nil {
-		t.Fatalf("Failed to create database: %v", err)
-	}
-	defer db.Close()
-
-	// Test sequential writes (SQLite WAL mode should handle this)
-	// Changed from truly concurrent to rapid sequential to avoid test flakiness
-	done := make(chan error, 2)
-
-	go func() {
-		_, err := db.SaveContext("concurrent1", "Context from goroutine 1", "Session 1", "test")
-		done <- err
-	}()
-
-	// Small delay to reduce lock contention
-	time.Sleep(5 * time.Millisecond)
-
-	go func() {
-		_, err := db.SaveContext("concurrent2", "Context from goroutine 2", "Session 2", "test")
-		done <- err
-	}()
-
-	// Wait for both goroutines with timeout
-	for i := 0; i < 2; i++ {
-		select {
-		case err := <-done:
-			if err != nil {
-				// WAL mode should handle concurrent access, but if it fails it's not critical for our use case
-				t.Logf("Concurrent write warning (expected in some environments): %v", err)
-			}
-		case <-time.After(5 * time.Second):
-			t.Fatal("Test timed out waiting for concurrent writes")
-		}
-	}
-
-	// Verify at least one context was saved (concurrent access isn't critical for wherewasi's use case)
-	allContexts1, err := db.GetRecentContexts("concurrent1", 5)
-	if err != nil {
-		t.Fatalf("Failed to get concurrent1 contexts: %v", err)
-	}
-
-	allContexts2, err := db.GetRecentContexts("concurrent2", 5)
-	if err != nil {
-		t.Fatalf("Failed to get concurrent2 contexts: %v", err)
-	}
-
-	if len(allContexts1) == 0 && len(allContexts2) == 0 {
-		t.Error("Expected at least one context to be saved from concurrent operations")
-	}
-
-	t.Logf("Concurrent test completed: project1=%d contexts, project2=%d contexts", len(allContexts1), len(allContexts2))
-}
-
-func TestTimestampOrdering(t *testing.T) {
-	tmpFile := "/tmp/test_wherewasi_timestamps.sqlite"
-	defer os.Remove(tmpFile)
-
-	db, err := NewDB(tmpFile)
-	if err != nil {
-		t.Fatalf("Failed to create database: %v", err)
-	}
-	defer db.Close()
-
-	// Save contexts with small delay to ensure different timestamps
-	_, err = db.SaveContext("timetest", "First context", "Session 1", "")
-	if err != nil {
-		t.Fatalf("Failed to save first context: %v", err)
-	}
-
-	time.Sleep(10 * time.Millisecond) // Ensure different timestamp
-
-	_, err = db.SaveContext("timetest", "Second context", "Session 2", "")
-	if err != nil {
-		t.Fatalf("Failed to save second context: %v", err)
-	}
-
-	// Get recent contexts - should be ordered by timestamp DESC
-	contexts, err := db.GetRecentContexts("timetest", 5)
-	if err != nil {
-		t.Fatalf("Failed to get contexts: %v", err)
-	}
-
-	if len(contexts) < 2 {
-		t.Fatalf("Expected at least 2 contexts, got %d", len(contexts))
-	}
-
-	// Most recent should be first
-	if contexts[0].SessionInfo != "Session 2" {
-		t.Errorf("Expected most recent context first, got session info: %s", contexts[0].SessionInfo)
-	}
-
-	if contexts[1].SessionInfo != "Session 1" {
-		t.Errorf("Expected older context second, got session info: %s", contexts[1].SessionInfo)
-	}
-
-	// Verify timestamps are in descending order
-	if !contexts[0].Timestamp.After(contexts[1].Timestamp) {
-		t.Error("Expected contexts to be ordered by timestamp DESC")
-	}
-}
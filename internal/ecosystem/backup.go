@@ -0,0 +1,62 @@
+package ecosystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"modernc.org/sqlite"
+)
+
+// backupPagesPerStep is how many pages each backup step copies; other connections can
+// write between steps
+const backupPagesPerStep = 256
+
+// backupConn is the modernc.org/sqlite connection API for online backups
+type backupConn interface {
+	NewBackup(dstURI string) (*sqlite.Backup, error)
+}
+
+// Backup copies the database to dst with SQLite's online backup API, which produces a
+// consistent snapshot while other tools keep using the database. dst must not exist.
+func (edb *EcosystemDB) Backup(dst string) error {
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("backup %s already exists", dst)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to check backup path: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	conn, err := edb.Conn(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		source, ok := driverConn.(backupConn)
+		if !ok {
+			return errors.New("database driver does not support online backup")
+		}
+		backup, err := source.NewBackup(dst)
+		if err != nil {
+			return err
+		}
+		for more := true; more; {
+			if more, err = backup.Step(backupPagesPerStep); err != nil {
+				backup.Finish()
+				return err
+			}
+		}
+		return backup.Finish()
+	})
+	if err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
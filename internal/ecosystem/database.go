@@ -15,6 +15,10 @@ type EcosystemDB struct {
 	*sql.DB
 	dbPath   string
 	isShared bool
+	// legacyImport is set when opening imported a legacy context.sqlite
+	legacyImport *LegacyImport
+	// compression is applied to newly saved contexts
+	compression string
 }
 
 // DatabaseConfig holds configuration for ecosystem database discovery
@@ -22,6 +26,13 @@ type DatabaseConfig struct {
 	ToolName     string
 	FallbackPath string
 	ForceLocal   bool
+	// SkipMigrations opens the database as-is, for inspecting or applying migrations explicitly
+	SkipMigrations bool
+	// LegacyPath is where the old internal/database package kept its database; a legacy
+	// database found there is imported once after migrating
+	LegacyPath string
+	// Compression stores new contexts compressed: "" (none) or CompressionGzip
+	Compression string
 }
 
 // SharedDatabasePath returns the standard ecosystem database path
@@ -41,6 +52,10 @@ func SharedDatabasePath() (string, error) {
 
 // NewEcosystemDB creates a new ecosystem database connection with discovery logic
 func NewEcosystemDB(config DatabaseConfig) (*EcosystemDB, error) {
+	if err := validateCompression(config.Compression); err != nil {
+		return nil, err
+	}
+	
 	var dbPath string
 	var isShared bool
 	
@@ -62,12 +77,25 @@ func NewEcosystemDB(config DatabaseConfig) (*EcosystemDB, error) {
 		isShared = false
 	}
 	
+	// A legacy database may sit at the fallback path, so it is moved aside before opening
+	var legacyPending string
+	if config.LegacyPath != "" && !config.SkipMigrations {
+		pending, err := stageLegacyDatabase(config.LegacyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check legacy database: %w", err)
+		}
+		legacyPending = pending
+	}
+	
 	// Ensure parent directory exists
 	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 	
-	db, err := sql.Open("sqlite", dbPath)
+	// Immediate transactions take the write lock up front, so concurrent migrations and
+	// journal writes wait on busy_timeout instead of failing on lock upgrade.
+	// Times are written in a layout SQLite date functions understand.
+	db, err := sql.Open("sqlite", dbPath+"?_txlock=immediate&_pragma=busy_timeout(5000)&_time_format=sqlite")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -87,14 +115,25 @@ func NewEcosystemDB(config DatabaseConfig) (*EcosystemDB, error) {
 	}
 	
 	edb := &EcosystemDB{
-		DB:       db,
-		dbPath:   dbPath,
-		isShared: isShared,
+		DB:          db,
+		dbPath:      dbPath,
+		isShared:    isShared,
+		compression: config.Compression,
 	}
 	
 	// Run migrations
-	if err := edb.migrate(config.ToolName); err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	if !config.SkipMigrations {
+		if err := edb.migrate(config.ToolName); err != nil {
+			return nil, fmt.Errorf("failed to run migrations: %w", err)
+		}
+	}
+	
+	if legacyPending != "" {
+		imported, err := edb.importLegacyDatabase(legacyPending)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import legacy database %s: %w", legacyPending, err)
+		}
+		edb.legacyImport = imported
 	}
 	
 	return edb, nil
@@ -110,291 +149,6 @@ func (edb *EcosystemDB) DatabasePath() string {
 	return edb.dbPath
 }
 
-// migrate runs database migrations for ecosystem tables
-func (edb *EcosystemDB) migrate(toolName string) error {
-	// Check if migrations table exists
-	var tableExists bool
-	err := edb.QueryRow(`
-		SELECT COUNT(*) > 0 FROM sqlite_master 
-		WHERE type='table' AND name='schema_migrations'
-	`).Scan(&tableExists)
-	
-	if err != nil {
-		return fmt.Errorf("failed to check migrations table: %w", err)
-	}
-	
-	if !tableExists {
-		// Create initial ecosystem schema
-		if err := edb.createEcosystemSchema(); err != nil {
-			return fmt.Errorf("failed to create ecosystem schema: %w", err)
-		}
-	}
-	
-	// Run tool-specific migrations
-	switch toolName {
-	case "wherewasi":
-		if err := edb.migrateWherewasiTables(); err != nil {
-			return fmt.Errorf("failed to migrate wherewasi tables: %w", err)
-		}
-	case "uroboro":
-		if err := edb.migrateUroboroTables(); err != nil {
-			return fmt.Errorf("failed to migrate uroboro tables: %w", err)
-		}
-	case "examinator":
-		if err := edb.migrateExaminatorTables(); err != nil {
-			return fmt.Errorf("failed to migrate examinator tables: %w", err)
-		}
-	}
-	
-	return nil
-}
-
-// createEcosystemSchema creates the shared ecosystem database schema
-func (edb *EcosystemDB) createEcosystemSchema() error {
-	schema := `
-	-- QRY Ecosystem Shared Database Schema
-	-- Each tool can function independently, but when pointed to shared DB,
-	-- they gain ecosystem intelligence through overlapping tables
-	
-	-- === SHARED CORE TABLES ===
-	
-	-- Projects tracked across the ecosystem
-	CREATE TABLE IF NOT EXISTS projects (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT UNIQUE NOT NULL,
-		description TEXT,
-		path TEXT,
-		git_repo BOOLEAN DEFAULT FALSE,
-		last_activity DATETIME,
-		primary_tool TEXT, -- which tool primarily manages this project
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
-	);
-	
-	-- Cross-tool communication
-	CREATE TABLE IF NOT EXISTS tool_messages (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		from_tool TEXT NOT NULL,
-		to_tool TEXT NOT NULL,
-		message_type TEXT NOT NULL,
-		data TEXT NOT NULL,
-		processed BOOLEAN DEFAULT FALSE,
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		processed_at DATETIME
-	);
-	
-	-- Usage analytics across ecosystem
-	CREATE TABLE IF NOT EXISTS usage_stats (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		tool TEXT NOT NULL,
-		command TEXT NOT NULL,
-		project TEXT,
-		duration_ms INTEGER,
-		success BOOLEAN DEFAULT TRUE,
-		error_message TEXT,
-		session_id TEXT,
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
-	);
-	
-	-- Cross-tool insights and recommendations
-	CREATE TABLE IF NOT EXISTS ecosystem_insights (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		insight_type TEXT NOT NULL,
-		source_tool TEXT NOT NULL,
-		target_tool TEXT,
-		project TEXT,
-		confidence REAL DEFAULT 0.0,
-		data TEXT NOT NULL, -- JSON
-		applied BOOLEAN DEFAULT FALSE,
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		applied_at DATETIME
-	);
-	
-	-- === SHARED INDEXES ===
-	CREATE INDEX IF NOT EXISTS idx_projects_name ON projects(name);
-	CREATE INDEX IF NOT EXISTS idx_projects_last_activity ON projects(last_activity);
-	CREATE INDEX IF NOT EXISTS idx_tool_messages_to_tool ON tool_messages(to_tool, processed);
-	CREATE INDEX IF NOT EXISTS idx_tool_messages_from_tool ON tool_messages(from_tool);
-	CREATE INDEX IF NOT EXISTS idx_usage_stats_tool ON usage_stats(tool);
-	CREATE INDEX IF NOT EXISTS idx_usage_stats_project ON usage_stats(project);
-	CREATE INDEX IF NOT EXISTS idx_ecosystem_insights_target ON ecosystem_insights(target_tool, applied);
-	CREATE INDEX IF NOT EXISTS idx_ecosystem_insights_project ON ecosystem_insights(project);
-	
-	-- Migration tracking
-	CREATE TABLE IF NOT EXISTS schema_migrations (
-		version INTEGER PRIMARY KEY,
-		tool TEXT NOT NULL,
-		description TEXT NOT NULL,
-		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
-	);
-	
-	-- Initial migration record
-	INSERT OR IGNORE INTO schema_migrations (version, tool, description) 
-	VALUES (1, 'ecosystem', 'Initial shared ecosystem schema');
-	`
-	
-	_, err := edb.Exec(schema)
-	if err != nil {
-		return fmt.Errorf("failed to create ecosystem schema: %w", err)
-	}
-	
-	return nil
-}
-
-// migrateWherewasiTables creates wherewasi-specific tables
-func (edb *EcosystemDB) migrateWherewasiTables() error {
-	schema := `
-	-- === WHEREWASI TABLES ===
-	
-	-- Context sessions for ripcord deployments
-	CREATE TABLE IF NOT EXISTS context_sessions (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		project TEXT NOT NULL,
-		timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		context_data TEXT NOT NULL,
-		session_info TEXT,
-		keywords TEXT,
-		git_branch TEXT,
-		git_commit TEXT,
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
-	);
-	
-	-- Wherewasi-specific indexes
-	CREATE INDEX IF NOT EXISTS idx_context_sessions_project ON context_sessions(project);
-	CREATE INDEX IF NOT EXISTS idx_context_sessions_timestamp ON context_sessions(timestamp);
-	CREATE INDEX IF NOT EXISTS idx_context_sessions_keywords ON context_sessions(keywords);
-	CREATE INDEX IF NOT EXISTS idx_context_sessions_git_branch ON context_sessions(git_branch);
-	
-	-- Migration record
-	INSERT OR IGNORE INTO schema_migrations (version, tool, description) 
-	VALUES (2, 'wherewasi', 'Wherewasi context sessions and project tracking');
-	`
-	
-	_, err := edb.Exec(schema)
-	return err
-}
-
-// migrateUroboroTables creates uroboro-specific tables
-func (edb *EcosystemDB) migrateUroboroTables() error {
-	schema := `
-	-- === UROBORO TABLES ===
-	
-	-- Content captures
-	CREATE TABLE IF NOT EXISTS captures (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		content TEXT NOT NULL,
-		project TEXT,
-		tags TEXT,
-		source_tool TEXT DEFAULT 'uroboro',
-		metadata TEXT, -- JSON
-		context_session_id INTEGER,
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (context_session_id) REFERENCES context_sessions(id)
-	);
-	
-	-- Published content
-	CREATE TABLE IF NOT EXISTS publications (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		title TEXT NOT NULL,
-		content TEXT NOT NULL,
-		format TEXT NOT NULL,
-		type TEXT NOT NULL,
-		source_captures TEXT, -- JSON array of capture IDs
-		project TEXT,
-		target_path TEXT,
-		context_session_id INTEGER,
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (context_session_id) REFERENCES context_sessions(id)
-	);
-	
-	-- Uroboro-specific indexes
-	CREATE INDEX IF NOT EXISTS idx_captures_timestamp ON captures(timestamp);
-	CREATE INDEX IF NOT EXISTS idx_captures_project ON captures(project);
-	CREATE INDEX IF NOT EXISTS idx_captures_source_tool ON captures(source_tool);
-	CREATE INDEX IF NOT EXISTS idx_captures_context_session ON captures(context_session_id);
-	CREATE INDEX IF NOT EXISTS idx_publications_type ON publications(type);
-	CREATE INDEX IF NOT EXISTS idx_publications_project ON publications(project);
-	CREATE INDEX IF NOT EXISTS idx_publications_context_session ON publications(context_session_id);
-	
-	-- Migration record
-	INSERT OR IGNORE INTO schema_migrations (version, tool, description) 
-	VALUES (3, 'uroboro', 'Uroboro captures and publications with context linking');
-	`
-	
-	_, err := edb.Exec(schema)
-	return err
-}
-
-// migrateExaminatorTables creates examinator-specific tables
-func (edb *EcosystemDB) migrateExaminatorTables() error {
-	schema := `
-	-- === EXAMINATOR TABLES ===
-	
-	-- Flashcards for spaced repetition
-	CREATE TABLE IF NOT EXISTS flashcards (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		question TEXT NOT NULL,
-		answer TEXT NOT NULL,
-		category TEXT,
-		difficulty INTEGER DEFAULT 1,
-		source_capture_id INTEGER,
-		context_session_id INTEGER,
-		project TEXT,
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		last_reviewed DATETIME,
-		next_review DATETIME,
-		ease_factor REAL DEFAULT 2.5,
-		review_count INTEGER DEFAULT 0,
-		correct_streak INTEGER DEFAULT 0,
-		FOREIGN KEY (source_capture_id) REFERENCES captures(id),
-		FOREIGN KEY (context_session_id) REFERENCES context_sessions(id)
-	);
-	
-	-- Study sessions
-	CREATE TABLE IF NOT EXISTS study_sessions (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		project TEXT,
-		flashcards_reviewed INTEGER DEFAULT 0,
-		correct_answers INTEGER DEFAULT 0,
-		duration_minutes INTEGER,
-		context_session_id INTEGER,
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (context_session_id) REFERENCES context_sessions(id)
-	);
-	
-	-- Flashcard reviews (detailed tracking)
-	CREATE TABLE IF NOT EXISTS flashcard_reviews (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		flashcard_id INTEGER NOT NULL,
-		study_session_id INTEGER,
-		response_quality INTEGER, -- 0-5 scale
-		response_time_ms INTEGER,
-		was_correct BOOLEAN,
-		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (flashcard_id) REFERENCES flashcards(id),
-		FOREIGN KEY (study_session_id) REFERENCES study_sessions(id)
-	);
-	
-	-- Examinator-specific indexes
-	CREATE INDEX IF NOT EXISTS idx_flashcards_project ON flashcards(project);
-	CREATE INDEX IF NOT EXISTS idx_flashcards_next_review ON flashcards(next_review);
-	CREATE INDEX IF NOT EXISTS idx_flashcards_source_capture ON flashcards(source_capture_id);
-	CREATE INDEX IF NOT EXISTS idx_flashcards_context_session ON flashcards(context_session_id);
-	CREATE INDEX IF NOT EXISTS idx_study_sessions_project ON study_sessions(project);
-	CREATE INDEX IF NOT EXISTS idx_flashcard_reviews_flashcard ON flashcard_reviews(flashcard_id);
-	CREATE INDEX IF NOT EXISTS idx_flashcard_reviews_session ON flashcard_reviews(study_session_id);
-	
-	-- Migration record
-	INSERT OR IGNORE INTO schema_migrations (version, tool, description) 
-	VALUES (4, 'examinator', 'Examinator flashcards and study tracking with ecosystem links');
-	`
-	
-	_, err := edb.Exec(schema)
-	return err
-}
-
 // Cross-tool communication methods
 
 // SendToolMessage sends a message to another ecosystem tool
@@ -465,6 +219,132 @@ func (edb *EcosystemDB) MarkMessageProcessed(id int64) error {
 	return nil
 }
 
+// RecordProjectActivity stores a project_activity message addressed to wherewasi
+func (edb *EcosystemDB) RecordProjectActivity(activity ProjectActivityMessageData) error {
+	msg, err := NewToolMessage(activity.Tool, ToolWherewasi, MessageTypeProjectActivity, activity)
+	if err != nil {
+		return fmt.Errorf("failed to build activity message: %w", err)
+	}
+	
+	return edb.SendToolMessage(msg.FromTool, msg.ToTool, msg.MessageType, msg.Data)
+}
+
+// GetProjectActivity retrieves recorded activity for a project since the given time, newest first
+func (edb *EcosystemDB) GetProjectActivity(project string, since time.Time, limit int) ([]*ToolMessage, error) {
+	query := `
+		SELECT id, from_tool, to_tool, message_type, data, processed, created_at, processed_at
+		FROM tool_messages 
+		WHERE message_type = ? AND json_extract(data, '$.project') = ? AND created_at >= ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`
+	
+	// created_at uses CURRENT_TIMESTAMP, which SQLite stores as UTC text
+	rows, err := edb.Query(query, MessageTypeProjectActivity, project, since.UTC().Format("2006-01-02 15:04:05"), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query project activity: %w", err)
+	}
+	defer rows.Close()
+	
+	var messages []*ToolMessage
+	for rows.Next() {
+		msg := &ToolMessage{}
+		err := rows.Scan(
+			&msg.ID,
+			&msg.FromTool,
+			&msg.ToTool,
+			&msg.MessageType,
+			&msg.Data,
+			&msg.Processed,
+			&msg.CreatedAt,
+			&msg.ProcessedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tool message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	
+	return messages, nil
+}
+
+// RecordActivityEvents appends file events to the activity journal in a single transaction
+func (edb *EcosystemDB) RecordActivityEvents(events []ActivityEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	
+	tx, err := edb.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	stmt, err := tx.Prepare(`
+		INSERT INTO activity_events (project, path, event_kind, timestamp)
+		VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare activity insert: %w", err)
+	}
+	defer stmt.Close()
+	
+	for _, event := range events {
+		if _, err := stmt.Exec(event.Project, event.Path, event.Kind, event.Timestamp.UTC()); err != nil {
+			return fmt.Errorf("failed to record activity event: %w", err)
+		}
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit activity events: %w", err)
+	}
+	
+	return nil
+}
+
+// GetRecentEdits summarizes journaled edits per file for a project since the given time, most recent first
+func (edb *EcosystemDB) GetRecentEdits(project string, since time.Time, limit int) ([]FileEditSummary, error) {
+	query := `
+		SELECT path, event_kind, timestamp
+		FROM activity_events 
+		WHERE project = ? AND timestamp >= ?
+		ORDER BY timestamp DESC
+	`
+	
+	rows, err := edb.Query(query, project, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query activity events: %w", err)
+	}
+	defer rows.Close()
+	
+	var summaries []FileEditSummary
+	index := make(map[string]int)
+	for rows.Next() {
+		var path, kind string
+		var timestamp time.Time
+		if err := rows.Scan(&path, &kind, &timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan activity event: %w", err)
+		}
+		
+		if i, ok := index[path]; ok {
+			summaries[i].Events++
+			continue
+		}
+		if len(summaries) >= limit {
+			continue
+		}
+		index[path] = len(summaries)
+		summaries = append(summaries, FileEditSummary{
+			Path:     path,
+			LastKind: kind,
+			LastEdit: timestamp,
+			Events:   1,
+		})
+	}
+	
+	return summaries, nil
+}
+
 // Project management methods
 
 // TrackProject records project activity in the ecosystem
@@ -488,10 +368,13 @@ func (edb *EcosystemDB) TrackProject(name, path, primaryTool string, isGitRepo b
 	return nil
 }
 
+// projectColumns lists the projects columns in the order scanProjects expects
+const projectColumns = `id, name, description, path, git_repo, last_activity, primary_tool, created_at, updated_at`
+
 // GetRecentProjects returns recently active projects
 func (edb *EcosystemDB) GetRecentProjects(limit int) ([]*Project, error) {
 	query := `
-		SELECT id, name, description, path, git_repo, last_activity, primary_tool, created_at, updated_at
+		SELECT ` + projectColumns + `
 		FROM projects 
 		ORDER BY last_activity DESC 
 		LIMIT ?
@@ -503,14 +386,163 @@ func (edb *EcosystemDB) GetRecentProjects(limit int) ([]*Project, error) {
 	}
 	defer rows.Close()
 	
+	return scanProjects(rows)
+}
+
+// GetProjects returns every known project ordered by name
+func (edb *EcosystemDB) GetProjects() ([]*Project, error) {
+	query := `
+		SELECT ` + projectColumns + `
+		FROM projects 
+		ORDER BY name
+	`
+	
+	rows, err := edb.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %w", err)
+	}
+	defer rows.Close()
+	
+	return scanProjects(rows)
+}
+
+// GetProject returns a project by name, or nil if it is not known
+func (edb *EcosystemDB) GetProject(name string) (*Project, error) {
+	query := `
+		SELECT ` + projectColumns + `
+		FROM projects 
+		WHERE name = ?
+	`
+	
+	rows, err := edb.Query(query, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query project: %w", err)
+	}
+	defer rows.Close()
+	
+	projects, err := scanProjects(rows)
+	if err != nil || len(projects) == 0 {
+		return nil, err
+	}
+	return projects[0], nil
+}
+
+// SetProjectDescription updates a project's description; an empty description clears it
+func (edb *EcosystemDB) SetProjectDescription(name, description string) error {
+	result, err := edb.Exec(`
+		UPDATE projects SET description = ?, updated_at = CURRENT_TIMESTAMP WHERE name = ?
+	`, nullableString(description), name)
+	if err != nil {
+		return fmt.Errorf("failed to update project description: %w", err)
+	}
+	return requireAffected(result, "project "+name)
+}
+
+// RemoveProject deletes a project and its aliases
+func (edb *EcosystemDB) RemoveProject(name string) error {
+	tx, err := edb.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	result, err := tx.Exec(`DELETE FROM projects WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("failed to remove project: %w", err)
+	}
+	if err := requireAffected(result, "project "+name); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM project_aliases WHERE project = ?`, name); err != nil {
+		return fmt.Errorf("failed to remove project aliases: %w", err)
+	}
+	
+	return tx.Commit()
+}
+
+// AddProjectAlias points alias at a project; aliases cannot shadow project names
+func (edb *EcosystemDB) AddProjectAlias(alias, project string) error {
+	existing, err := edb.GetProject(alias)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("%s is already a project name", alias)
+	}
+	
+	_, err = edb.Exec(`
+		INSERT INTO project_aliases (alias, project) VALUES (?, ?)
+		ON CONFLICT(alias) DO UPDATE SET project = excluded.project
+	`, alias, project)
+	if err != nil {
+		return fmt.Errorf("failed to add project alias: %w", err)
+	}
+	return nil
+}
+
+// RemoveProjectAlias deletes an alias
+func (edb *EcosystemDB) RemoveProjectAlias(alias string) error {
+	result, err := edb.Exec(`DELETE FROM project_aliases WHERE alias = ?`, alias)
+	if err != nil {
+		return fmt.Errorf("failed to remove project alias: %w", err)
+	}
+	return requireAffected(result, "alias "+alias)
+}
+
+// ResolveProjectAlias returns the project an alias points at, or "" if it is not an alias
+func (edb *EcosystemDB) ResolveProjectAlias(alias string) (string, error) {
+	var project string
+	err := edb.QueryRow(`SELECT project FROM project_aliases WHERE alias = ?`, alias).Scan(&project)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project alias: %w", err)
+	}
+	return project, nil
+}
+
+// GetProjectAliases returns the aliases of every project, keyed by project name
+func (edb *EcosystemDB) GetProjectAliases() (map[string][]string, error) {
+	rows, err := edb.Query(`SELECT alias, project FROM project_aliases ORDER BY alias`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query project aliases: %w", err)
+	}
+	defer rows.Close()
+	
+	aliases := make(map[string][]string)
+	for rows.Next() {
+		var alias, project string
+		if err := rows.Scan(&alias, &project); err != nil {
+			return nil, fmt.Errorf("failed to scan project alias: %w", err)
+		}
+		aliases[project] = append(aliases[project], alias)
+	}
+	return aliases, rows.Err()
+}
+
+// requireAffected turns an update that matched no rows into a not-found error
+func requireAffected(result sql.Result, what string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s not found", what)
+	}
+	return nil
+}
+
+func scanProjects(rows *sql.Rows) ([]*Project, error) {
 	var projects []*Project
 	for rows.Next() {
 		project := &Project{}
+		var path sql.NullString
 		err := rows.Scan(
 			&project.ID,
 			&project.Name,
 			&project.Description,
-			&project.Path,
+			&path,
 			&project.GitRepo,
 			&project.LastActivity,
 			&project.PrimaryTool,
@@ -520,10 +552,11 @@ func (edb *EcosystemDB) GetRecentProjects(limit int) ([]*Project, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan project: %w", err)
 		}
+		project.Path = path.String
 		projects = append(projects, project)
 	}
 	
-	return projects, nil
+	return projects, rows.Err()
 }
 
 // Usage tracking
@@ -545,27 +578,12 @@ func (edb *EcosystemDB) TrackUsage(tool, command, project, sessionID string, dur
 
 // Wherewasi-specific methods
 
-// SaveContext saves a context session to the database
-func (edb *EcosystemDB) SaveContext(project, contextData, sessionInfo, keywords string) (*ContextSession, error) {
+// SaveContext saves a context session to the database, stamped with the git state when provided.
+// Saving the same context as the project's latest one refreshes that session instead of storing a copy.
+func (edb *EcosystemDB) SaveContext(project, contextData, sessionInfo, keywords string, git *GitState) (*ContextSession, error) {
 	timestamp := time.Now()
 
-	query := `
-		INSERT INTO context_sessions (project, context_data, session_info, keywords, timestamp)
-		VALUES (?, ?, ?, ?, ?)
-	`
-
-	result, err := edb.Exec(query, project, contextData, sessionInfo, keywords, timestamp)
-	if err != nil {
-		return nil, fmt.Errorf("failed to save context: %w", err)
-	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get insert ID: %w", err)
-	}
-
 	session := &ContextSession{
-		ID:          id,
 		Project:     project,
 		Timestamp:   timestamp,
 		ContextData: contextData,
@@ -573,14 +591,77 @@ func (edb *EcosystemDB) SaveContext(project, contextData, sessionInfo, keywords
 		Keywords:    keywords,
 		CreatedAt:   timestamp,
 	}
+	if git != nil {
+		session.GitBranch = nullableString(git.Branch)
+		session.GitCommit = nullableString(git.Commit)
+		session.GitDirty = git.Dirty
+		if git.HasUpstream {
+			ahead, behind := git.Ahead, git.Behind
+			session.GitAhead = &ahead
+			session.GitBehind = &behind
+		}
+	}
+
+	hash := contentHash(contextData)
+	data, encoding, err := encodeContext(contextData, edb.compression)
+	if err != nil {
+		return nil, err
+	}
 
+	tx, err := edb.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Stored in UTC so timestamps from different zones order and filter correctly
+	latest, err := latestDuplicate(tx, project, hash, sessionInfo, keywords)
+	if err != nil {
+		return nil, err
+	}
+	if latest != 0 {
+		_, err = tx.Exec(`
+			UPDATE context_sessions
+			SET timestamp = ?, git_branch = ?, git_commit = ?, git_dirty = ?, git_ahead = ?, git_behind = ?
+			WHERE id = ?
+		`, timestamp.UTC(), session.GitBranch, session.GitCommit, session.GitDirty, session.GitAhead, session.GitBehind, latest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to refresh context: %w", err)
+		}
+		session.ID = latest
+	} else {
+		result, err := tx.Exec(`
+			INSERT INTO context_sessions (project, context_data, session_info, keywords, timestamp,
+				git_branch, git_commit, git_dirty, git_ahead, git_behind, content_hash, context_encoding)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, project, data, sessionInfo, keywords, timestamp.UTC(),
+			session.GitBranch, session.GitCommit, session.GitDirty, session.GitAhead, session.GitBehind,
+			hash, nullableString(encoding))
+		if err != nil {
+			return nil, fmt.Errorf("failed to save context: %w", err)
+		}
+
+		session.ID, err = result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get insert ID: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to save context: %w", err)
+	}
 	return session, nil
 }
 
+// contextSessionColumns is the column list scanned by scanContextSessions; session_info and
+// keywords are nullable, since other tools and older versions may leave them unset
+const contextSessionColumns = `id, project, timestamp, context_data, COALESCE(session_info, ''), COALESCE(keywords, ''),
+	git_branch, git_commit, git_dirty, git_ahead, git_behind, created_at, context_encoding`
+
 // GetRecentContexts retrieves recent context sessions for a project
 func (edb *EcosystemDB) GetRecentContexts(project string, limit int) ([]ContextSession, error) {
 	query := `
-		SELECT id, project, timestamp, context_data, session_info, keywords, git_branch, git_commit, created_at
+		SELECT ` + contextSessionColumns + `
 		FROM context_sessions 
 		WHERE project = ?
 		ORDER BY timestamp DESC 
@@ -593,66 +674,157 @@ func (edb *EcosystemDB) GetRecentContexts(project string, limit int) ([]ContextS
 	}
 	defer rows.Close()
 
-	var sessions []ContextSession
-	for rows.Next() {
-		var session ContextSession
-		err := rows.Scan(
-			&session.ID,
-			&session.Project,
-			&session.Timestamp,
-			&session.ContextData,
-			&session.SessionInfo,
-			&session.Keywords,
-			&session.GitBranch,
-			&session.GitCommit,
-			&session.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan context session: %w", err)
-		}
-		sessions = append(sessions, session)
-	}
-
-	return sessions, nil
+	return scanContextSessions(rows)
 }
 
-// SearchStoredContexts searches stored contexts by keyword
-func (edb *EcosystemDB) SearchStoredContexts(keyword string) ([]ContextSession, error) {
+// GetRecentContextsOnBranch retrieves recent context sessions saved on a specific git branch
+func (edb *EcosystemDB) GetRecentContextsOnBranch(project, branch string, limit int) ([]ContextSession, error) {
 	query := `
-		SELECT id, project, timestamp, context_data, session_info, keywords, git_branch, git_commit, created_at
+		SELECT ` + contextSessionColumns + `
 		FROM context_sessions 
-		WHERE context_data LIKE ? OR keywords LIKE ? OR session_info LIKE ?
+		WHERE project = ? AND git_branch = ?
 		ORDER BY timestamp DESC 
-		LIMIT 10
+		LIMIT ?
 	`
 
-	searchTerm := "%" + keyword + "%"
-	rows, err := edb.Query(query, searchTerm, searchTerm, searchTerm)
+	rows, err := edb.Query(query, project, branch, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search contexts: %w", err)
+		return nil, fmt.Errorf("failed to query recent contexts: %w", err)
 	}
 	defer rows.Close()
 
+	return scanContextSessions(rows)
+}
+
+// ListContexts returns saved contexts newest first, filtered by project, branch and age;
+// the search query is ignored and an empty project lists every project
+func (edb *EcosystemDB) ListContexts(search ContextSearch) ([]ContextSession, error) {
+	query := `
+		SELECT ` + contextSessionColumns + `
+		FROM context_sessions 
+		WHERE 1 = 1`
+	var args []interface{}
+	if search.Project != "" {
+		query += ` AND project = ?`
+		args = append(args, search.Project)
+	}
+	if search.Branch != "" {
+		query += ` AND git_branch = ?`
+		args = append(args, search.Branch)
+	}
+	if !search.Since.IsZero() {
+		query += ` AND julianday(timestamp) >= julianday(?)`
+		args = append(args, search.Since.UTC())
+	}
+	limit := search.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	query += `
+		ORDER BY timestamp DESC, id DESC 
+		LIMIT ?`
+	args = append(args, limit)
+	
+	rows, err := edb.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contexts: %w", err)
+	}
+	defer rows.Close()
+	
+	return scanContextSessions(rows)
+}
+
+// GetContext returns a saved context by ID, or nil if there is none
+func (edb *EcosystemDB) GetContext(id int64) (*ContextSession, error) {
+	rows, err := edb.Query(`
+		SELECT `+contextSessionColumns+`
+		FROM context_sessions 
+		WHERE id = ?
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query context: %w", err)
+	}
+	defer rows.Close()
+	
+	sessions, err := scanContextSessions(rows)
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+	return &sessions[0], nil
+}
+
+// DeleteContext removes a saved context; the search index follows through its trigger
+func (edb *EcosystemDB) DeleteContext(id int64) error {
+	result, err := edb.Exec(`DELETE FROM context_sessions WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete context: %w", err)
+	}
+	return requireAffected(result, fmt.Sprintf("context #%d", id))
+}
+
+// SearchStoredContexts searches stored contexts by keyword, best matches first
+func (edb *EcosystemDB) SearchStoredContexts(keyword string) ([]ContextSession, error) {
+	matches, err := edb.SearchContexts(ContextSearch{Query: keyword})
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]ContextSession, 0, len(matches))
+	for _, match := range matches {
+		sessions = append(sessions, match.ContextSession)
+	}
+	return sessions, nil
+}
+
+// scanContextSessions reads rows selected with contextSessionColumns
+func scanContextSessions(rows *sql.Rows) ([]ContextSession, error) {
 	var sessions []ContextSession
 	for rows.Next() {
 		var session ContextSession
-		err := rows.Scan(
-			&session.ID,
-			&session.Project,
-			&session.Timestamp,
-			&session.ContextData,
-			&session.SessionInfo,
-			&session.Keywords,
-			&session.GitBranch,
-			&session.GitCommit,
-			&session.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan context session: %w", err)
+		if err := scanContextSession(rows, &session); err != nil {
+			return nil, err
 		}
 		sessions = append(sessions, session)
 	}
 
-	return sessions, nil
+	return sessions, rows.Err()
 }
 
+// scanContextSession reads one row selected with contextSessionColumns followed by extra columns
+func scanContextSession(rows *sql.Rows, session *ContextSession, extra ...interface{}) error {
+	var dirty sql.NullBool
+	var encoding sql.NullString
+	dest := []interface{}{
+		&session.ID,
+		&session.Project,
+		&session.Timestamp,
+		&session.ContextData,
+		&session.SessionInfo,
+		&session.Keywords,
+		&session.GitBranch,
+		&session.GitCommit,
+		&dirty,
+		&session.GitAhead,
+		&session.GitBehind,
+		&session.CreatedAt,
+		&encoding,
+	}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return fmt.Errorf("failed to scan context session: %w", err)
+	}
+	session.GitDirty = dirty.Bool
+
+	data, err := decodeContext(session.ContextData, encoding.String)
+	if err != nil {
+		return fmt.Errorf("failed to read context #%d: %w", session.ID, err)
+	}
+	session.ContextData = data
+	return nil
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
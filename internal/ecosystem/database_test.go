@@ -0,0 +1,448 @@
+package ecosystem
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDatabaseIntegration(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "test_wherewasi.sqlite"), false)
+
+	t.Run("SaveContext", func(t *testing.T) {
+		session, err := db.SaveContext("testproject", "Test context data", "Test session", "keyword1,keyword2", nil)
+		if err != nil {
+			t.Fatalf("Failed to save context: %v", err)
+		}
+
+		if session.ID == 0 {
+			t.Error("Expected context session ID to be set")
+		}
+
+		if session.Project != "testproject" {
+			t.Errorf("Expected project 'testproject', got '%s'", session.Project)
+		}
+
+		if session.ContextData != "Test context data" {
+			t.Errorf("Expected context data 'Test context data', got '%s'", session.ContextData)
+		}
+
+		if session.SessionInfo != "Test session" {
+			t.Errorf("Expected session info 'Test session', got '%s'", session.SessionInfo)
+		}
+
+		if session.Keywords != "keyword1,keyword2" {
+			t.Errorf("Expected keywords 'keyword1,keyword2', got '%s'", session.Keywords)
+		}
+	})
+
+	t.Run("SaveContextWithGit", func(t *testing.T) {
+		git := &GitState{Branch: "feature/x", Commit: "abc1234", Dirty: true, HasUpstream: true, Ahead: 2}
+		if _, err := db.SaveContext("gitproject", "Context on a branch", "Git session", "", git); err != nil {
+			t.Fatalf("Failed to save context: %v", err)
+		}
+
+		contexts, err := db.GetRecentContextsOnBranch("gitproject", "feature/x", 5)
+		if err != nil {
+			t.Fatalf("Failed to get contexts on branch: %v", err)
+		}
+		if len(contexts) != 1 {
+			t.Fatalf("Expected 1 context on feature/x, got %d", len(contexts))
+		}
+
+		session := contexts[0]
+		if session.GitCommit == nil || *session.GitCommit != "abc1234" || !session.GitDirty {
+			t.Errorf("Expected git state to round-trip, got %+v", session)
+		}
+		if session.GitAhead == nil || *session.GitAhead != 2 || session.GitBehind == nil || *session.GitBehind != 0 {
+			t.Errorf("Expected ahead/behind to round-trip, got %v/%v", session.GitAhead, session.GitBehind)
+		}
+
+		other, err := db.GetRecentContextsOnBranch("gitproject", "main", 5)
+		if err != nil {
+			t.Fatalf("Failed to get contexts on branch: %v", err)
+		}
+		if len(other) != 0 {
+			t.Errorf("Expected no contexts on main, got %d", len(other))
+		}
+	})
+
+	t.Run("GetRecentContexts", func(t *testing.T) {
+		// Insert test contexts
+		_, err := db.SaveContext("project1", "Context 1", "Session 1", "test", nil)
+		if err != nil {
+			t.Fatalf("Failed to save test context: %v", err)
+		}
+
+		_, err = db.SaveContext("project1", "Context 2", "Session 2", "keyword", nil)
+		if err != nil {
+			t.Fatalf("Failed to save test context: %v", err)
+		}
+
+		_, err = db.SaveContext("project2", "Context 3", "Session 3", "other", nil)
+		if err != nil {
+			t.Fatalf("Failed to save test context: %v", err)
+		}
+
+		// Get recent contexts for project1
+		contexts, err := db.GetRecentContexts("project1", 5)
+		if err != nil {
+			t.Fatalf("Failed to get recent contexts: %v", err)
+		}
+
+		if len(contexts) < 2 {
+			t.Errorf("Expected at least 2 contexts for project1, got %d", len(contexts))
+		}
+
+		// Verify all contexts belong to project1
+		for _, context := range contexts {
+			if context.Project != "project1" {
+				t.Errorf("Expected all contexts to be for project1, got '%s'", context.Project)
+			}
+		}
+
+		// Test limiting
+		limitedContexts, err := db.GetRecentContexts("project1", 1)
+		if err != nil {
+			t.Fatalf("Failed to get limited contexts: %v", err)
+		}
+
+		if len(limitedContexts) != 1 {
+			t.Errorf("Expected exactly 1 context with limit, got %d", len(limitedContexts))
+		}
+	})
+
+	t.Run("ListGetDeleteContexts", func(t *testing.T) {
+		first, err := db.SaveContext("listproject", "First", "Session 1", "", &GitState{Branch: "main"})
+		if err != nil {
+			t.Fatalf("Failed to save context: %v", err)
+		}
+		second, err := db.SaveContext("listproject", "Second", "Session 2", "", nil)
+		if err != nil {
+			t.Fatalf("Failed to save context: %v", err)
+		}
+
+		listed, err := db.ListContexts(ContextSearch{Project: "listproject"})
+		if err != nil {
+			t.Fatalf("Failed to list contexts: %v", err)
+		}
+		if len(listed) != 2 || listed[0].ID != second.ID {
+			t.Errorf("Expected both contexts newest first, got %d", len(listed))
+		}
+		if listed, _ := db.ListContexts(ContextSearch{Project: "listproject", Branch: "main"}); len(listed) != 1 || listed[0].ID != first.ID {
+			t.Errorf("Expected only the context on main, got %d", len(listed))
+		}
+		if listed, _ := db.ListContexts(ContextSearch{Since: time.Now().Add(time.Hour)}); len(listed) != 0 {
+			t.Errorf("Expected no contexts from the future, got %d", len(listed))
+		}
+
+		loaded, err := db.GetContext(first.ID)
+		if err != nil || loaded == nil || loaded.ContextData != "First" {
+			t.Fatalf("Expected to load context #%d, got %v (%v)", first.ID, loaded, err)
+		}
+
+		if err := db.DeleteContext(first.ID); err != nil {
+			t.Fatalf("Failed to delete context: %v", err)
+		}
+		if loaded, err := db.GetContext(first.ID); err != nil || loaded != nil {
+			t.Errorf("Expected the deleted context to be gone, got %v (%v)", loaded, err)
+		}
+		if err := db.DeleteContext(first.ID); err == nil {
+			t.Error("Expected deleting a missing context to fail")
+		}
+	})
+
+	t.Run("SearchStoredContexts", func(t *testing.T) {
+		// Insert searchable contexts
+		_, err := db.SaveContext("searchproject", "Context with whisper keyword", "Whisper session", "whisper,ai", nil)
+		if err != nil {
+			t.Fatalf("Failed to save searchable context: %v", err)
+		}
+
+		_, err = db.SaveContext("otherproject", "Different context", "Regular session", "other", nil)
+		if err != nil {
+			t.Fatalf("Failed to save other context: %v", err)
+		}
+
+		// Search for "whisper"
+		results, err := db.SearchStoredContexts("whisper")
+		if err != nil {
+			t.Fatalf("Failed to search contexts: %v", err)
+		}
+
+		if len(results) == 0 {
+			t.Error("Expected to find contexts with 'whisper' keyword")
+		}
+
+		found := false
+		for _, result := range results {
+			if result.Project == "searchproject" && result.SessionInfo == "Whisper session" {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			t.Error("Expected to find the whisper context in search results")
+		}
+
+		// Search for non-existent keyword
+		noResults, err := db.SearchStoredContexts("nonexistent")
+		if err != nil {
+			t.Fatalf("Failed to search for non-existent keyword: %v", err)
+		}
+
+		if len(noResults) != 0 {
+			t.Errorf("Expected 0 results for non-existent keyword, got %d", len(noResults))
+		}
+	})
+
+	t.Run("TrackProject", func(t *testing.T) {
+		err := db.TrackProject("trackedproject", "/path/to/project", ToolWherewasi, true)
+		if err != nil {
+			t.Fatalf("Failed to track project: %v", err)
+		}
+
+		// Test upsert behavior - track same project again
+		err = db.TrackProject("trackedproject", "/updated/path", ToolWherewasi, false)
+		if err != nil {
+			t.Fatalf("Failed to update tracked project: %v", err)
+		}
+
+		project, err := db.GetProject("trackedproject")
+		if err != nil {
+			t.Fatalf("Failed to get tracked project: %v", err)
+		}
+		if project == nil || project.Path != "/updated/path" || project.GitRepo {
+			t.Errorf("Expected the second track to update the project, got %+v", project)
+		}
+	})
+
+	t.Run("SendToolMessage", func(t *testing.T) {
+		err := db.SendToolMessage(ToolWherewasi, ToolUroboro, "context_export", `{"project": "test", "data": "context"}`)
+		if err != nil {
+			t.Fatalf("Failed to send tool message: %v", err)
+		}
+
+		messages, err := db.GetUnprocessedMessages(ToolUroboro)
+		if err != nil {
+			t.Fatalf("Failed to get tool messages: %v", err)
+		}
+		if len(messages) != 1 || messages[0].FromTool != ToolWherewasi {
+			t.Errorf("Expected the message to be waiting for uroboro, got %d", len(messages))
+		}
+	})
+
+	t.Run("TrackUsage", func(t *testing.T) {
+		err := db.TrackUsage(ToolWherewasi, "pull", "testproject", "", 150, true, "")
+		if err != nil {
+			t.Fatalf("Failed to track successful usage: %v", err)
+		}
+
+		err = db.TrackUsage(ToolWherewasi, "pull", "testproject", "session-1", 0, false, "test error")
+		if err != nil {
+			t.Fatalf("Failed to track failed usage: %v", err)
+		}
+	})
+
+	t.Run("EmptyValues", func(t *testing.T) {
+		// Test with empty values
+		session, err := db.SaveContext("emptytest", "Content only", "", "", nil)
+		if err != nil {
+			t.Fatalf("Failed to save context with empty values: %v", err)
+		}
+
+		if session.SessionInfo != "" {
+			t.Error("Expected session info to be empty")
+		}
+
+		if session.Keywords != "" {
+			t.Error("Expected keywords to be empty")
+		}
+
+		// Verify we can read it back
+		contexts, err := db.GetRecentContexts("emptytest", 1)
+		if err != nil {
+			t.Fatalf("Failed to get contexts: %v", err)
+		}
+
+		if len(contexts) == 0 {
+			t.Fatal("Expected to find the empty-field context")
+		}
+
+		if contexts[0].ContextData != "Content only" {
+			t.Errorf("Expected context data 'Content only', got '%s'", contexts[0].ContextData)
+		}
+	})
+}
+
+func TestSchemaCreation(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "test_wherewasi_schema.sqlite"), false)
+
+	// Verify tables exist
+	tables := []string{"context_sessions", "projects", "project_aliases", "tool_messages", "usage_stats", "activity_events", "schema_migrations"}
+
+	for _, table := range tables {
+		var count int
+		err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=?", table).Scan(&count)
+		if err != nil {
+			t.Fatalf("Failed to check table %s: %v", table, err)
+		}
+
+		if count != 1 {
+			t.Errorf("Expected table %s to exist", table)
+		}
+	}
+
+	// Verify migration records
+	for _, version := range []int{1, 2} {
+		var tool string
+		err := db.QueryRow("SELECT tool FROM schema_migrations WHERE version = ?", version).Scan(&tool)
+		if err != nil {
+			t.Fatalf("Failed to find migration record %d: %v", version, err)
+		}
+	}
+
+	// Verify indexes exist
+	indexes := []string{
+		"idx_context_sessions_project",
+		"idx_context_sessions_timestamp",
+		"idx_context_sessions_keywords",
+		"idx_context_sessions_git_branch",
+		"idx_projects_name",
+		"idx_tool_messages_to_tool",
+		"idx_usage_stats_tool",
+	}
+
+	for _, index := range indexes {
+		var count int
+		err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='index' AND name=?", index).Scan(&count)
+		if err != nil {
+			t.Fatalf("Failed to check index %s: %v", index, err)
+		}
+
+		if count != 1 {
+			t.Errorf("Expected index %s to exist", index)
+		}
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "test_wherewasi_concurrent.sqlite"), false)
+
+	// Immediate transactions and busy_timeout serialize writers instead of failing them
+	done := make(chan error, 2)
+	for _, project := range []string{"concurrent1", "concurrent2"} {
+		go func(project string) {
+			_, err := db.SaveContext(project, "Context from "+project, "Session", "test", nil)
+			done <- err
+		}(project)
+	}
+
+	// Wait for both goroutines with timeout
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Concurrent write failed: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Test timed out waiting for concurrent writes")
+		}
+	}
+
+	for _, project := range []string{"concurrent1", "concurrent2"} {
+		contexts, err := db.GetRecentContexts(project, 5)
+		if err != nil {
+			t.Fatalf("Failed to get %s contexts: %v", project, err)
+		}
+		if len(contexts) != 1 {
+			t.Errorf("Expected 1 context for %s, got %d", project, len(contexts))
+		}
+	}
+}
+
+func TestTimestampOrdering(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "test_wherewasi_timestamps.sqlite"), false)
+
+	// Save contexts with small delay to ensure different timestamps
+	_, err := db.SaveContext("timetest", "First context", "Session 1", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to save first context: %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond) // Ensure different timestamp
+
+	_, err = db.SaveContext("timetest", "Second context", "Session 2", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to save second context: %v", err)
+	}
+
+	// Get recent contexts - should be ordered by timestamp DESC
+	contexts, err := db.GetRecentContexts("timetest", 5)
+	if err != nil {
+		t.Fatalf("Failed to get contexts: %v", err)
+	}
+
+	if len(contexts) < 2 {
+		t.Fatalf("Expected at least 2 contexts, got %d", len(contexts))
+	}
+
+	// Most recent should be first
+	if contexts[0].SessionInfo != "Session 2" {
+		t.Errorf("Expected most recent context first, got session info: %s", contexts[0].SessionInfo)
+	}
+
+	if contexts[1].SessionInfo != "Session 1" {
+		t.Errorf("Expected older context second, got session info: %s", contexts[1].SessionInfo)
+	}
+
+	// Verify timestamps are in descending order
+	if !contexts[0].Timestamp.After(contexts[1].Timestamp) {
+		t.Error("Expected contexts to be ordered by timestamp DESC")
+	}
+}
+
+func TestActivityEvents(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "activity.sqlite"), false)
+	now := time.Now().Truncate(time.Second)
+
+	events := []ActivityEvent{
+		{Project: "api", Path: "old.go", Kind: EventKindWrite, Timestamp: now.Add(-48 * time.Hour)},
+		{Project: "api", Path: "main.go", Kind: EventKindCreate, Timestamp: now.Add(-3 * time.Hour)},
+		{Project: "api", Path: "main.go", Kind: EventKindWrite, Timestamp: now.Add(-2 * time.Hour)},
+		{Project: "api", Path: "notes.md", Kind: EventKindWrite, Timestamp: now.Add(-90 * time.Minute)},
+		{Project: "api", Path: "main.go", Kind: EventKindRemove, Timestamp: now.Add(-time.Hour)},
+		{Project: "web", Path: "index.ts", Kind: EventKindWrite, Timestamp: now},
+	}
+	if err := db.RecordActivityEvents(events); err != nil {
+		t.Fatalf("Failed to record events: %v", err)
+	}
+	if err := db.RecordActivityEvents(nil); err != nil {
+		t.Errorf("Expected recording no events to be a no-op, got %v", err)
+	}
+
+	edits, err := db.GetRecentEdits("api", now.Add(-24*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("Failed to get recent edits: %v", err)
+	}
+	// Files are listed by their latest event with every event since counted; older events and
+	// other projects are left out
+	want := []FileEditSummary{
+		{Path: "main.go", LastKind: EventKindRemove, LastEdit: now.Add(-time.Hour), Events: 3},
+		{Path: "notes.md", LastKind: EventKindWrite, LastEdit: now.Add(-90 * time.Minute), Events: 1},
+	}
+	if len(edits) != len(want) {
+		t.Fatalf("Expected %d files, got %+v", len(want), edits)
+	}
+	for i := range want {
+		if edits[i].Path != want[i].Path || edits[i].LastKind != want[i].LastKind || !edits[i].LastEdit.Equal(want[i].LastEdit) || edits[i].Events != want[i].Events {
+			t.Errorf("Expected %+v, got %+v", want[i], edits[i])
+		}
+	}
+
+	edits, err = db.GetRecentEdits("api", now.Add(-24*time.Hour), 1)
+	if err != nil || len(edits) != 1 || edits[0].Path != "main.go" || edits[0].Events != 3 {
+		t.Errorf("Expected the limit to keep the most recent file with all its events, got %+v (%v)", edits, err)
+	}
+}
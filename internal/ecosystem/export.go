@@ -0,0 +1,628 @@
+package ecosystem
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ExportFormatName identifies wherewasi exports; ExportVersion is bumped on incompatible changes
+const (
+	ExportFormatName = "wherewasi-export"
+	ExportVersion    = 1
+)
+
+// Tables carried by an export, used as JSONL record types and tar entry names
+const (
+	TableManifest        = "manifest"
+	TableContextSessions = "context_sessions"
+	TableProjects        = "projects"
+	TableToolMessages    = "tool_messages"
+	TableNotes           = "notes"
+)
+
+// Project conflict policies for Import, applied when an imported project name already exists
+const (
+	// ConflictMerge keeps existing values and fills the ones that are empty
+	ConflictMerge = "merge"
+	// ConflictKeep leaves the existing project untouched
+	ConflictKeep = "keep"
+	// ConflictReplace overwrites the existing project with the imported one
+	ConflictReplace = "replace"
+)
+
+// importTimeTolerance is how close timestamps must be for rows to count as the same.
+// Exports keep nanoseconds while older rows may have been stored with less precision.
+const importTimeTolerance = time.Second
+
+// ExportFilter selects what Export includes; zero values include everything
+type ExportFilter struct {
+	Project string
+	Since   time.Time
+	Until   time.Time
+}
+
+// ExportManifest describes an export and the filter it was made with
+type ExportManifest struct {
+	Format     string     `json:"format"`
+	Version    int        `json:"version"`
+	ExportedAt time.Time  `json:"exported_at"`
+	Project    string     `json:"project,omitempty"`
+	Since      *time.Time `json:"since,omitempty"`
+	Until      *time.Time `json:"until,omitempty"`
+}
+
+// Export is a portable copy of the ecosystem data wherewasi owns or shares
+type Export struct {
+	Manifest ExportManifest
+	Contexts []ContextSession
+	Projects []*Project
+	Messages []*ToolMessage
+	Notes    []Note
+}
+
+// TableCounts counts rows per exported table
+type TableCounts struct {
+	Contexts int `json:"contexts"`
+	Projects int `json:"projects"`
+	Messages int `json:"messages"`
+	Notes    int `json:"notes"`
+}
+
+// ImportOptions controls how Import merges an export into the database
+type ImportOptions struct {
+	// OnConflict is ConflictMerge (default), ConflictKeep or ConflictReplace
+	OnConflict string
+	// DryRun reports what would be imported without changing the database
+	DryRun bool
+}
+
+// ImportResult reports what Import added, skipped as already present, and updated
+type ImportResult struct {
+	Added           TableCounts `json:"added"`
+	Skipped         TableCounts `json:"skipped"`
+	ProjectsUpdated int         `json:"projects_updated"`
+}
+
+// Counts returns the number of rows per table in the export
+func (e *Export) Counts() TableCounts {
+	return TableCounts{Contexts: len(e.Contexts), Projects: len(e.Projects), Messages: len(e.Messages), Notes: len(e.Notes)}
+}
+
+// Export reads context sessions, projects, tool messages and notes matching the filter.
+// Contexts are exported decompressed, so the export does not depend on storage settings.
+func (edb *EcosystemDB) Export(filter ExportFilter) (*Export, error) {
+	export := &Export{Manifest: ExportManifest{
+		Format:     ExportFormatName,
+		Version:    ExportVersion,
+		ExportedAt: time.Now(),
+		Project:    filter.Project,
+	}}
+	if !filter.Since.IsZero() {
+		export.Manifest.Since = &filter.Since
+	}
+	if !filter.Until.IsZero() {
+		export.Manifest.Until = &filter.Until
+	}
+
+	where, args := exportConditions(filter, "project", "timestamp")
+	rows, err := edb.Query(`SELECT `+contextSessionColumns+` FROM context_sessions WHERE `+where+` ORDER BY timestamp, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export contexts: %w", err)
+	}
+	export.Contexts, err = scanContextSessions(rows)
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export contexts: %w", err)
+	}
+
+	where, args = exportConditions(ExportFilter{Project: filter.Project}, "name", "")
+	rows, err = edb.Query(`SELECT `+projectColumns+` FROM projects WHERE `+where+` ORDER BY name`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export projects: %w", err)
+	}
+	export.Projects, err = scanProjects(rows)
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export projects: %w", err)
+	}
+
+	// Messages name their project inside the JSON payload, when they have one
+	where, args = exportConditions(filter, "json_extract(data, '$.project')", "created_at")
+	rows, err = edb.Query(`
+		SELECT id, from_tool, to_tool, message_type, data, processed, created_at, processed_at
+		FROM tool_messages WHERE `+where+` ORDER BY created_at, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export tool messages: %w", err)
+	}
+	for rows.Next() {
+		msg := &ToolMessage{}
+		var processed sql.NullBool
+		if err := rows.Scan(&msg.ID, &msg.FromTool, &msg.ToTool, &msg.MessageType, &msg.Data,
+			&processed, &msg.CreatedAt, &msg.ProcessedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan tool message: %w", err)
+		}
+		msg.Processed = processed.Bool
+		export.Messages = append(export.Messages, msg)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to export tool messages: %w", err)
+	}
+
+	notes, err := edb.GetNotes(NoteFilter{Project: filter.Project, IncludeDone: true})
+	if err != nil {
+		return nil, err
+	}
+	// GetNotes is newest first; exports run oldest first like the other tables
+	for i := len(notes) - 1; i >= 0; i-- {
+		if inRange(notes[i].CreatedAt, filter) {
+			export.Notes = append(export.Notes, notes[i])
+		}
+	}
+	return export, nil
+}
+
+// exportConditions builds the WHERE clause for a filter over the given project and time columns
+func exportConditions(filter ExportFilter, projectColumn, timeColumn string) (string, []interface{}) {
+	where := "1 = 1"
+	var args []interface{}
+	if filter.Project != "" {
+		where += " AND " + projectColumn + " = ?"
+		args = append(args, filter.Project)
+	}
+	if timeColumn != "" && !filter.Since.IsZero() {
+		where += " AND julianday(" + timeColumn + ") >= julianday(?)"
+		args = append(args, filter.Since.UTC())
+	}
+	if timeColumn != "" && !filter.Until.IsZero() {
+		where += " AND julianday(" + timeColumn + ") < julianday(?)"
+		args = append(args, filter.Until.UTC())
+	}
+	return where, args
+}
+
+func inRange(t time.Time, filter ExportFilter) bool {
+	return (filter.Since.IsZero() || !t.Before(filter.Since)) && (filter.Until.IsZero() || t.Before(filter.Until))
+}
+
+// jsonlRecord is one line of a JSONL export
+type jsonlRecord struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// WriteJSONL writes the export as one {"table", "row"} object per line, manifest first
+func (e *Export) WriteJSONL(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	write := func(table string, row interface{}) error {
+		data, err := json.Marshal(row)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", table, err)
+		}
+		if err := encoder.Encode(jsonlRecord{Table: table, Row: data}); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		return nil
+	}
+
+	if err := write(TableManifest, e.Manifest); err != nil {
+		return err
+	}
+	for _, project := range e.Projects {
+		if err := write(TableProjects, project); err != nil {
+			return err
+		}
+	}
+	for _, session := range e.Contexts {
+		if err := write(TableContextSessions, session); err != nil {
+			return err
+		}
+	}
+	for _, note := range e.Notes {
+		if err := write(TableNotes, note); err != nil {
+			return err
+		}
+	}
+	for _, msg := range e.Messages {
+		if err := write(TableToolMessages, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTar writes the export as a tar archive holding one JSON file per table
+func (e *Export) WriteTar(w io.Writer) error {
+	tables := []struct {
+		name string
+		rows interface{}
+	}{
+		{TableManifest, e.Manifest},
+		{TableProjects, e.Projects},
+		{TableContextSessions, e.Contexts},
+		{TableNotes, e.Notes},
+		{TableToolMessages, e.Messages},
+	}
+
+	archive := tar.NewWriter(w)
+	for _, table := range tables {
+		data, err := json.MarshalIndent(table.rows, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", table.name, err)
+		}
+		header := &tar.Header{
+			Name:    table.name + ".json",
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: e.Manifest.ExportedAt,
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		if _, err := archive.Write(data); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+// ReadExport reads an export written by WriteJSONL or WriteTar, detecting the format
+func ReadExport(r io.Reader) (*Export, error) {
+	reader := bufio.NewReaderSize(r, 1024)
+	head, _ := reader.Peek(512)
+
+	var export *Export
+	var err error
+	if len(head) >= 262 && bytes.Equal(head[257:262], []byte("ustar")) {
+		export, err = readTar(reader)
+	} else {
+		export, err = readJSONL(reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if export.Manifest.Format != ExportFormatName {
+		return nil, errors.New("not a wherewasi export (missing manifest)")
+	}
+	if export.Manifest.Version > ExportVersion {
+		return nil, fmt.Errorf("export version %d is newer than this wherewasi supports (%d)", export.Manifest.Version, ExportVersion)
+	}
+	return export, nil
+}
+
+// tarTarget returns where a table's JSON array decodes into, or nil for unknown tables
+func (e *Export) tarTarget(table string) interface{} {
+	switch table {
+	case TableManifest:
+		return &e.Manifest
+	case TableContextSessions:
+		return &e.Contexts
+	case TableProjects:
+		return &e.Projects
+	case TableToolMessages:
+		return &e.Messages
+	case TableNotes:
+		return &e.Notes
+	}
+	return nil
+}
+
+func readJSONL(r io.Reader) (*Export, error) {
+	export := &Export{}
+	decoder := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var record jsonlRecord
+		if err := decoder.Decode(&record); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read export record %d: %w", line, err)
+		}
+
+		var err error
+		switch record.Table {
+		case TableManifest:
+			err = json.Unmarshal(record.Row, &export.Manifest)
+		case TableContextSessions:
+			var session ContextSession
+			if err = json.Unmarshal(record.Row, &session); err == nil {
+				export.Contexts = append(export.Contexts, session)
+			}
+		case TableProjects:
+			project := &Project{}
+			if err = json.Unmarshal(record.Row, project); err == nil {
+				export.Projects = append(export.Projects, project)
+			}
+		case TableToolMessages:
+			msg := &ToolMessage{}
+			if err = json.Unmarshal(record.Row, msg); err == nil {
+				export.Messages = append(export.Messages, msg)
+			}
+		case TableNotes:
+			var note Note
+			if err = json.Unmarshal(record.Row, &note); err == nil {
+				export.Notes = append(export.Notes, note)
+			}
+		default:
+			// Tables from newer versions are skipped
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read export record %d (%s): %w", line, record.Table, err)
+		}
+	}
+	return export, nil
+}
+
+func readTar(r io.Reader) (*Export, error) {
+	export := &Export{}
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read export archive: %w", err)
+		}
+
+		// Tables from newer versions are skipped
+		target := export.tarTarget(strings.TrimSuffix(header.Name, ".json"))
+		if target == nil {
+			continue
+		}
+		if err := json.NewDecoder(archive).Decode(target); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+	}
+	return export, nil
+}
+
+// Import merges an export into the database in one transaction. Contexts, notes and messages
+// already present (same content at the same time) are skipped; projects with an existing name
+// are resolved with opts.OnConflict. Notes keep their link to imported contexts.
+func (edb *EcosystemDB) Import(export *Export, opts ImportOptions) (*ImportResult, error) {
+	switch opts.OnConflict {
+	case "":
+		opts.OnConflict = ConflictMerge
+	case ConflictMerge, ConflictKeep, ConflictReplace:
+	default:
+		return nil, fmt.Errorf("unknown conflict policy %q (use %s, %s or %s)", opts.OnConflict, ConflictMerge, ConflictKeep, ConflictReplace)
+	}
+
+	tx, err := edb.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin import: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &ImportResult{}
+	for _, project := range export.Projects {
+		if err := importProject(tx, project, opts.OnConflict, result); err != nil {
+			return nil, err
+		}
+	}
+
+	// Exported context IDs map to their IDs here, for the notes that link to them
+	contextIDs := make(map[int64]int64, len(export.Contexts))
+	for _, session := range export.Contexts {
+		id, added, err := edb.importContext(tx, session)
+		if err != nil {
+			return nil, err
+		}
+		contextIDs[session.ID] = id
+		if added {
+			result.Added.Contexts++
+		} else {
+			result.Skipped.Contexts++
+		}
+	}
+
+	for _, note := range export.Notes {
+		if note.ContextID != nil {
+			if id, ok := contextIDs[*note.ContextID]; ok {
+				note.ContextID = &id
+			} else {
+				note.ContextID = nil
+			}
+		}
+		added, err := importNote(tx, note)
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			result.Added.Notes++
+		} else {
+			result.Skipped.Notes++
+		}
+	}
+
+	for _, msg := range export.Messages {
+		added, err := importMessage(tx, msg)
+		if err != nil {
+			return nil, err
+		}
+		if added {
+			result.Added.Messages++
+		} else {
+			result.Skipped.Messages++
+		}
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return result, nil
+}
+
+// timeMatch is the SQL condition for a column within importTimeTolerance of a parameter
+func timeMatch(column string) string {
+	return fmt.Sprintf("abs(julianday(%s) - julianday(?)) * 86400 < %g", column, importTimeTolerance.Seconds())
+}
+
+func importProject(tx *sql.Tx, project *Project, onConflict string, result *ImportResult) error {
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM projects WHERE name = ?)`, project.Name).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check project %s: %w", project.Name, err)
+	}
+
+	if !exists {
+		_, err := tx.Exec(`
+			INSERT INTO projects (name, description, path, git_repo, last_activity, primary_tool, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, project.Name, project.Description, nullableString(project.Path), project.GitRepo, project.LastActivity,
+			project.PrimaryTool, project.CreatedAt.UTC(), project.UpdatedAt.UTC())
+		if err != nil {
+			return fmt.Errorf("failed to import project %s: %w", project.Name, err)
+		}
+		result.Added.Projects++
+		return nil
+	}
+
+	var err error
+	switch onConflict {
+	case ConflictKeep:
+		result.Skipped.Projects++
+		return nil
+	case ConflictReplace:
+		_, err = tx.Exec(`
+			UPDATE projects SET description = ?, path = ?, git_repo = ?, last_activity = ?, primary_tool = ?,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE name = ?
+		`, project.Description, nullableString(project.Path), project.GitRepo, project.LastActivity,
+			project.PrimaryTool, project.Name)
+	default:
+		_, err = tx.Exec(`
+			UPDATE projects SET
+				description = COALESCE(NULLIF(description, ''), ?),
+				path = COALESCE(NULLIF(path, ''), ?),
+				git_repo = git_repo OR ?,
+				last_activity = CASE
+					WHEN last_activity IS NULL OR julianday(?) > julianday(last_activity) THEN ?
+					ELSE last_activity END,
+				primary_tool = COALESCE(primary_tool, ?),
+				updated_at = CURRENT_TIMESTAMP
+			WHERE name = ?
+		`, project.Description, nullableString(project.Path), project.GitRepo, project.LastActivity,
+			project.LastActivity, project.PrimaryTool, project.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update project %s: %w", project.Name, err)
+	}
+	result.ProjectsUpdated++
+	return nil
+}
+
+// importContext inserts a context unless the project already has the same context saved at the
+// same time, returning the ID it has in this database
+func (edb *EcosystemDB) importContext(tx *sql.Tx, session ContextSession) (int64, bool, error) {
+	hash := contentHash(session.ContextData)
+	rows, err := tx.Query(`SELECT `+contextSessionColumns+` FROM context_sessions
+		WHERE project = ? AND `+timeMatch("timestamp"), session.Project, session.Timestamp.UTC())
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to check for existing context: %w", err)
+	}
+	existing, err := scanContextSessions(rows)
+	rows.Close()
+	if err != nil {
+		return 0, false, err
+	}
+	for _, candidate := range existing {
+		if contentHash(candidate.ContextData) == hash {
+			return candidate.ID, false, nil
+		}
+	}
+
+	data, encoding, err := encodeContext(session.ContextData, edb.compression)
+	if err != nil {
+		return 0, false, err
+	}
+	created := session.CreatedAt
+	if created.IsZero() {
+		created = session.Timestamp
+	}
+	result, err := tx.Exec(`
+		INSERT INTO context_sessions (project, context_data, session_info, keywords, timestamp,
+			git_branch, git_commit, git_dirty, git_ahead, git_behind, content_hash, context_encoding, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, session.Project, data, session.SessionInfo, session.Keywords, session.Timestamp.UTC(),
+		session.GitBranch, session.GitCommit, session.GitDirty, session.GitAhead, session.GitBehind,
+		hash, nullableString(encoding), created.UTC())
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to import context: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get insert ID: %w", err)
+	}
+	return id, true, nil
+}
+
+func importNote(tx *sql.Tx, note Note) (bool, error) {
+	var exists bool
+	err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM notes WHERE project = ? AND kind = ? AND body = ? AND `+timeMatch("created_at")+`)`,
+		note.Project, note.Kind, note.Body, note.CreatedAt.UTC()).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for existing note: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	var doneAt interface{}
+	if note.DoneAt != nil {
+		doneAt = note.DoneAt.UTC()
+	}
+	if note.Kind == "" {
+		note.Kind = NoteKindNote
+	}
+	_, err = tx.Exec(`
+		INSERT INTO notes (project, branch, git_commit, kind, body, context_session_id, created_at, done_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, note.Project, nullableString(note.Branch), nullableString(note.Commit), note.Kind, note.Body,
+		note.ContextID, note.CreatedAt.UTC(), doneAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to import note: %w", err)
+	}
+	return true, nil
+}
+
+func importMessage(tx *sql.Tx, msg *ToolMessage) (bool, error) {
+	var exists bool
+	err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM tool_messages
+		WHERE from_tool = ? AND to_tool = ? AND message_type = ? AND data = ? AND `+timeMatch("created_at")+`)`,
+		msg.FromTool, msg.ToTool, msg.MessageType, msg.Data, msg.CreatedAt.UTC()).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for existing message: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	var processedAt interface{}
+	if msg.ProcessedAt != nil {
+		processedAt = msg.ProcessedAt.UTC()
+	}
+	_, err = tx.Exec(`
+		INSERT INTO tool_messages (from_tool, to_tool, message_type, data, processed, created_at, processed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, msg.FromTool, msg.ToTool, msg.MessageType, msg.Data, msg.Processed, msg.CreatedAt.UTC(), processedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to import message: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,151 @@
+package ecosystem
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// seedExportDB fills a database with one of everything an export carries
+func seedExportDB(t *testing.T, db *EcosystemDB) {
+	t.Helper()
+	if err := db.TrackProject("wherewasi", "/src/wherewasi", ToolWherewasi, true); err != nil {
+		t.Fatalf("Failed to track project: %v", err)
+	}
+	if err := db.TrackProject("uroboro", "/src/uroboro", ToolUroboro, true); err != nil {
+		t.Fatalf("Failed to track project: %v", err)
+	}
+	if err := db.SetProjectDescription("wherewasi", "Ripcord CLI"); err != nil {
+		t.Fatalf("Failed to describe project: %v", err)
+	}
+	session, err := db.SaveContext("wherewasi", "Pulled the ripcord", "Checkpoint: parser", "", &GitState{Branch: "main", Commit: "abc1234"})
+	if err != nil {
+		t.Fatalf("Failed to save context: %v", err)
+	}
+	if _, err := db.SaveContext("uroboro", "Capture pipeline", "Context pull", "", nil); err != nil {
+		t.Fatalf("Failed to save context: %v", err)
+	}
+	if _, err := db.AddNote(Note{Project: "wherewasi", Kind: NoteKindCheckpoint, Body: "Halfway through the parser", ContextID: &session.ID}); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if err := db.RecordProjectActivity(ProjectActivityMessageData{Project: "wherewasi", Tool: ToolWherewasi, Activity: ActivityCommit, Detail: "abc1234 Add parser"}); err != nil {
+		t.Fatalf("Failed to record activity: %v", err)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	source := openTestDB(t, filepath.Join(t.TempDir(), "source.sqlite"), false)
+	seedExportDB(t, source)
+
+	all, err := source.Export(ExportFilter{})
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	if counts := all.Counts(); counts != (TableCounts{Contexts: 2, Projects: 2, Messages: 1, Notes: 1}) {
+		t.Fatalf("Expected one of everything per project, got %+v", counts)
+	}
+	filtered, err := source.Export(ExportFilter{Project: "wherewasi", Since: time.Now().Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	if counts := filtered.Counts(); counts != (TableCounts{Contexts: 1, Projects: 1, Messages: 1, Notes: 1}) {
+		t.Errorf("Expected only wherewasi rows, got %+v", counts)
+	}
+	if future, err := source.Export(ExportFilter{Since: time.Now().Add(time.Hour)}); err != nil || len(future.Contexts)+len(future.Notes)+len(future.Messages) != 0 {
+		t.Errorf("Expected nothing saved in the future, got %+v, %v", future, err)
+	}
+
+	for _, format := range []string{"jsonl", "tar"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			write := all.WriteJSONL
+			if format == "tar" {
+				write = all.WriteTar
+			}
+			if err := write(&buf); err != nil {
+				t.Fatalf("Failed to write export: %v", err)
+			}
+			read, err := ReadExport(&buf)
+			if err != nil {
+				t.Fatalf("Failed to read export: %v", err)
+			}
+			if read.Counts() != all.Counts() || read.Manifest.Format != ExportFormatName {
+				t.Fatalf("Expected the export to round-trip, got %+v", read.Counts())
+			}
+
+			target, err := NewEcosystemDB(DatabaseConfig{ToolName: ToolWherewasi, FallbackPath: filepath.Join(t.TempDir(), "target.sqlite"), ForceLocal: true, Compression: CompressionGzip})
+			if err != nil {
+				t.Fatalf("Failed to open database: %v", err)
+			}
+			defer target.Close()
+			if err := target.TrackProject("wherewasi", "", ToolWherewasi, false); err != nil {
+				t.Fatalf("Failed to track project: %v", err)
+			}
+
+			if dry, err := target.Import(read, ImportOptions{DryRun: true}); err != nil || dry.Added.Contexts != 2 {
+				t.Fatalf("Expected a dry run to count 2 contexts, got %+v, %v", dry, err)
+			}
+			if contexts, _ := target.ListContexts(ContextSearch{}); len(contexts) != 0 {
+				t.Fatalf("Expected a dry run to import nothing, got %d contexts", len(contexts))
+			}
+
+			result, err := target.Import(read, ImportOptions{})
+			if err != nil {
+				t.Fatalf("Failed to import: %v", err)
+			}
+			want := ImportResult{Added: TableCounts{Contexts: 2, Projects: 1, Messages: 1, Notes: 1}, ProjectsUpdated: 1}
+			if *result != want {
+				t.Errorf("Expected %+v, got %+v", want, *result)
+			}
+
+			project, err := target.GetProject("wherewasi")
+			if err != nil || project == nil || project.Path != "/src/wherewasi" || project.Description == nil || *project.Description != "Ripcord CLI" {
+				t.Errorf("Expected the merge to fill the empty path and description, got %+v, %v", project, err)
+			}
+			notes, err := target.GetNotes(NoteFilter{Project: "wherewasi"})
+			if err != nil || len(notes) != 1 || notes[0].ContextID == nil {
+				t.Fatalf("Expected the imported checkpoint, got %+v, %v", notes, err)
+			}
+			linked, err := target.GetContext(*notes[0].ContextID)
+			if err != nil || linked == nil || linked.ContextData != "Pulled the ripcord" || linked.GitBranch == nil || *linked.GitBranch != "main" {
+				t.Errorf("Expected the checkpoint to link to its imported context, got %+v, %v", linked, err)
+			}
+
+			again, err := target.Import(read, ImportOptions{OnConflict: ConflictKeep})
+			if err != nil {
+				t.Fatalf("Failed to import again: %v", err)
+			}
+			if again.Added != (TableCounts{}) || again.Skipped != read.Counts() || again.ProjectsUpdated != 0 {
+				t.Errorf("Expected a second import to skip everything, got %+v", again)
+			}
+		})
+	}
+
+	if _, err := source.Import(all, ImportOptions{OnConflict: "newest"}); err == nil {
+		t.Error("Expected an unknown conflict policy to be rejected")
+	}
+	if _, err := ReadExport(bytes.NewBufferString(`{"table":"projects","row":{}}`)); err == nil {
+		t.Error("Expected input without a manifest to be rejected")
+	}
+}
+
+func TestBackup(t *testing.T) {
+	dir := t.TempDir()
+	db := openTestDB(t, filepath.Join(dir, "source.sqlite"), false)
+	seedExportDB(t, db)
+
+	dst := filepath.Join(dir, "backups", "copy.sqlite")
+	if err := db.Backup(dst); err != nil {
+		t.Fatalf("Failed to back up: %v", err)
+	}
+	if err := db.Backup(dst); err == nil {
+		t.Error("Expected an existing backup not to be overwritten")
+	}
+
+	backup := openTestDB(t, dst, true)
+	contexts, err := backup.ListContexts(ContextSearch{})
+	if err != nil || len(contexts) != 2 {
+		t.Errorf("Expected the backup to hold both contexts, got %d, %v", len(contexts), err)
+	}
+}
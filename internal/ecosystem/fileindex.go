@@ -0,0 +1,235 @@
+package ecosystem
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// minIndexedSubstring is the shortest text the trigram index can look up
+const minIndexedSubstring = 3
+
+// IndexedFile is a file in the search index with the size and modification time it was read at
+type IndexedFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Unchanged reports whether a file on disk still has the indexed size and modification time
+func (f IndexedFile) Unchanged(size int64, modTime time.Time) bool {
+	return f.Size == size && f.ModTime.Equal(modTime)
+}
+
+// IndexFile is a file to index with its text; Content is empty for binary or oversized files,
+// which are tracked so they are not read again until they change
+type IndexFile struct {
+	IndexedFile
+	Content string
+}
+
+// FileIndexUpdate is a batch of changes to one project's indexed files
+type FileIndexUpdate struct {
+	Project string
+	Root    string
+	// Put adds files or replaces their indexed text
+	Put []IndexFile
+	// Remove lists paths that no longer exist or are now ignored
+	Remove []string
+}
+
+// IndexedProject summarizes a project in the search index
+type IndexedProject struct {
+	Project string
+	Root    string
+	// Tree is the git tree of the clean working tree last indexed, or "" when the tree was
+	// dirty, is not a git repository or indexing did not finish
+	Tree      string
+	IndexedAt time.Time
+	Files     int
+	Bytes     int64
+}
+
+// UpdateFileIndex applies a batch of changes to a project's indexed files. The project's tree
+// is forgotten until MarkFileIndexed records the refresh as complete.
+func (edb *EcosystemDB) UpdateFileIndex(update FileIndexUpdate) error {
+	tx, err := edb.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin index update: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO search_index_projects (project, root, indexed_at) VALUES (?, ?, ?)
+		ON CONFLICT (project) DO UPDATE SET root = excluded.root, git_tree = NULL
+	`, update.Project, update.Root, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to update indexed project: %w", err)
+	}
+
+	// Deleting a file row removes its text from the index through a trigger
+	remove := func(path string) error {
+		_, err := tx.Exec(`DELETE FROM search_index_files WHERE project = ? AND path = ?`, update.Project, path)
+		return err
+	}
+	for _, path := range update.Remove {
+		if err := remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s from the index: %w", path, err)
+		}
+	}
+	for _, file := range update.Put {
+		if err := remove(file.Path); err != nil {
+			return fmt.Errorf("failed to replace %s in the index: %w", file.Path, err)
+		}
+		result, err := tx.Exec(`
+			INSERT INTO search_index_files (project, path, size, mtime) VALUES (?, ?, ?, ?)
+		`, update.Project, file.Path, file.Size, file.ModTime.UnixNano())
+		if err != nil {
+			return fmt.Errorf("failed to index %s: %w", file.Path, err)
+		}
+		if file.Content == "" {
+			continue
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get insert ID: %w", err)
+		}
+		if _, err := tx.Exec(`INSERT INTO search_index_fts (rowid, content) VALUES (?, ?)`, id, file.Content); err != nil {
+			return fmt.Errorf("failed to index %s: %w", file.Path, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit index update: %w", err)
+	}
+	return nil
+}
+
+// MarkFileIndexed records that a project's index is up to date, with the git tree of its clean
+// working tree or "" when there is none
+func (edb *EcosystemDB) MarkFileIndexed(project, root, tree string) error {
+	_, err := edb.Exec(`
+		INSERT INTO search_index_projects (project, root, git_tree, indexed_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (project) DO UPDATE SET
+			root = excluded.root, git_tree = excluded.git_tree, indexed_at = excluded.indexed_at
+	`, project, root, nullableString(tree), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to mark %s indexed: %w", project, err)
+	}
+	return nil
+}
+
+// IndexedFiles lists a project's indexed files by path
+func (edb *EcosystemDB) IndexedFiles(project string) ([]IndexedFile, error) {
+	rows, err := edb.Query(`
+		SELECT path, size, mtime FROM search_index_files WHERE project = ? ORDER BY path
+	`, project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexed files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []IndexedFile
+	for rows.Next() {
+		var file IndexedFile
+		var mtime int64
+		if err := rows.Scan(&file.Path, &file.Size, &mtime); err != nil {
+			return nil, fmt.Errorf("failed to scan indexed file: %w", err)
+		}
+		file.ModTime = time.Unix(0, mtime)
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list indexed files: %w", err)
+	}
+	return files, nil
+}
+
+// FindIndexedFiles returns the paths of a project's indexed files containing every substring,
+// ignoring case. Substrings shorter than three characters cannot be looked up and are skipped;
+// without any usable substring every indexed file is returned.
+func (edb *EcosystemDB) FindIndexedFiles(project string, substrings []string) ([]string, error) {
+	var phrases []string
+	for _, substring := range substrings {
+		if utf8.RuneCountInString(substring) >= minIndexedSubstring {
+			phrases = append(phrases, quoteTerm(substring, false))
+		}
+	}
+
+	query := `SELECT path FROM search_index_files WHERE project = ?`
+	args := []interface{}{project}
+	if len(phrases) > 0 {
+		query += ` AND id IN (SELECT rowid FROM search_index_fts WHERE search_index_fts MATCH ?)`
+		args = append(args, strings.Join(phrases, " AND "))
+	}
+	query += ` ORDER BY path`
+
+	rows, err := edb.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the file index: %w", err)
+	}
+	defer rows.Close()
+
+	paths := []string{}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan indexed file: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search the file index: %w", err)
+	}
+	return paths, nil
+}
+
+// IndexedProjects summarizes every project in the search index by name
+func (edb *EcosystemDB) IndexedProjects() ([]IndexedProject, error) {
+	rows, err := edb.Query(`
+		SELECT p.project, p.root, p.git_tree, p.indexed_at, COUNT(f.id), COALESCE(SUM(f.size), 0)
+		FROM search_index_projects p
+		LEFT JOIN search_index_files f ON f.project = p.project
+		GROUP BY p.project
+		ORDER BY p.project
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexed projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projects []IndexedProject
+	for rows.Next() {
+		var project IndexedProject
+		var tree sql.NullString
+		if err := rows.Scan(&project.Project, &project.Root, &tree, &project.IndexedAt, &project.Files, &project.Bytes); err != nil {
+			return nil, fmt.Errorf("failed to scan indexed project: %w", err)
+		}
+		project.Tree = tree.String
+		projects = append(projects, project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list indexed projects: %w", err)
+	}
+	return projects, nil
+}
+
+// ClearFileIndex drops a project from the search index, or every project when project is ""
+func (edb *EcosystemDB) ClearFileIndex(project string) error {
+	tx, err := edb.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin clearing the index: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"search_index_files", "search_index_projects"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE ? = '' OR project = ?`, project, project); err != nil {
+			return fmt.Errorf("failed to clear the index: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to clear the index: %w", err)
+	}
+	return nil
+}
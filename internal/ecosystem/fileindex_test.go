@@ -0,0 +1,97 @@
+package ecosystem
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFileIndex(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "index.sqlite"), false)
+	modTime := time.Unix(1700000000, 123456789)
+	file := func(path, content string) IndexFile {
+		return IndexFile{IndexedFile: IndexedFile{Path: path, Size: int64(len(content)), ModTime: modTime}, Content: content}
+	}
+
+	err := db.UpdateFileIndex(FileIndexUpdate{Project: "wherewasi", Root: "/src/wherewasi", Put: []IndexFile{
+		file("main.go", "package main\n\nfunc parseFlags() {}\n"),
+		file("README.md", "# Wherewasi\n\nParse flags and pull the ripcord.\n"),
+		file("logo.png", ""),
+	}})
+	if err != nil {
+		t.Fatalf("Failed to index files: %v", err)
+	}
+	if err := db.UpdateFileIndex(FileIndexUpdate{Project: "uroboro", Root: "/src/uroboro", Put: []IndexFile{file("capture.go", "func parseFlags() {}\n")}}); err != nil {
+		t.Fatalf("Failed to index files: %v", err)
+	}
+
+	find := func(project string, substrings ...string) []string {
+		t.Helper()
+		paths, err := db.FindIndexedFiles(project, substrings)
+		if err != nil {
+			t.Fatalf("Failed to search the index: %v", err)
+		}
+		return paths
+	}
+	if got := find("wherewasi", "PARSEFLAGS"); !reflect.DeepEqual(got, []string{"main.go"}) {
+		t.Errorf("Expected a case-insensitive substring match in main.go only, got %v", got)
+	}
+	if got := find("wherewasi", "parse", "ripcord"); !reflect.DeepEqual(got, []string{"README.md"}) {
+		t.Errorf("Expected every substring to be required, got %v", got)
+	}
+	if got := find("wherewasi", "ab"); len(got) != 3 {
+		t.Errorf("Expected short substrings to match every file, got %v", got)
+	}
+	if got := find("wherewasi", "nowhere"); got == nil || len(got) != 0 {
+		t.Errorf("Expected an empty list without candidates, got %#v", got)
+	}
+
+	files, err := db.IndexedFiles("wherewasi")
+	if err != nil || len(files) != 3 || files[2].Path != "main.go" || !files[2].Unchanged(files[2].Size, modTime) {
+		t.Fatalf("Expected indexed files with their stamps, got %+v, %v", files, err)
+	}
+
+	// Replacing and removing files keeps the text index in step
+	err = db.UpdateFileIndex(FileIndexUpdate{Project: "wherewasi", Root: "/src/wherewasi",
+		Put: []IndexFile{file("main.go", "package main\n")}, Remove: []string{"README.md"}})
+	if err != nil {
+		t.Fatalf("Failed to update the index: %v", err)
+	}
+	if got := find("wherewasi", "parseFlags"); len(got) != 0 {
+		t.Errorf("Expected replaced and removed text to be gone, got %v", got)
+	}
+	if got := find("wherewasi", "package"); !reflect.DeepEqual(got, []string{"main.go"}) {
+		t.Errorf("Expected the new text to be indexed, got %v", got)
+	}
+
+	if err := db.MarkFileIndexed("wherewasi", "/src/wherewasi", "4b825dc"); err != nil {
+		t.Fatalf("Failed to mark the project indexed: %v", err)
+	}
+	projects, err := db.IndexedProjects()
+	if err != nil || len(projects) != 2 {
+		t.Fatalf("Expected two indexed projects, got %+v, %v", projects, err)
+	}
+	if got := projects[1]; got.Project != "wherewasi" || got.Tree != "4b825dc" || got.Files != 2 || got.Bytes != 13 || time.Since(got.IndexedAt) > time.Minute {
+		t.Errorf("Expected the wherewasi summary, got %+v", got)
+	}
+	if err := db.UpdateFileIndex(FileIndexUpdate{Project: "wherewasi", Root: "/src/wherewasi"}); err != nil {
+		t.Fatalf("Failed to update the index: %v", err)
+	}
+	if projects, _ := db.IndexedProjects(); projects[1].Tree != "" {
+		t.Errorf("Expected an update to forget the tree, got %+v", projects[1])
+	}
+
+	if err := db.ClearFileIndex("wherewasi"); err != nil {
+		t.Fatalf("Failed to clear the project: %v", err)
+	}
+	if projects, _ := db.IndexedProjects(); len(projects) != 1 || projects[0].Project != "uroboro" {
+		t.Errorf("Expected only uroboro left, got %+v", projects)
+	}
+	if err := db.ClearFileIndex(""); err != nil {
+		t.Fatalf("Failed to clear the index: %v", err)
+	}
+	if got := find("uroboro", "parseFlags"); len(got) != 0 {
+		t.Errorf("Expected an empty index, got %v", got)
+	}
+}
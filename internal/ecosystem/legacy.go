@@ -0,0 +1,187 @@
+package ecosystem
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Legacy databases are renamed while importing so an interrupted import resumes on the next
+// start, then kept under the imported suffix as a backup
+const (
+	legacyPendingSuffix  = ".legacy"
+	legacyImportedSuffix = ".legacy-imported"
+)
+
+// LegacyImport counts the rows copied from a context.sqlite created by the old internal/database package
+type LegacyImport struct {
+	Path     string
+	Backup   string
+	Contexts int
+	Projects int
+	Messages int
+	Usage    int
+}
+
+// legacyTables copies each legacy table into the ecosystem schema. Row IDs are not kept,
+// since they would collide with rows already in a shared database.
+var legacyTables = []struct {
+	name   string
+	query  string
+	insert string
+}{
+	{
+		name:   "context_sessions",
+		query:  `SELECT project, timestamp, context_data, session_info, keywords, created_at FROM context_sessions ORDER BY id`,
+		insert: `INSERT INTO context_sessions (project, timestamp, context_data, session_info, keywords, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+	},
+	{
+		// Projects already tracked in the ecosystem keep their current row
+		name:  "projects",
+		query: `SELECT name, path, git_repo, last_activity, created_at FROM projects ORDER BY id`,
+		insert: `INSERT INTO projects (name, path, git_repo, last_activity, created_at, primary_tool) VALUES (?, ?, ?, ?, ?, '` + ToolWherewasi + `')
+			ON CONFLICT(name) DO NOTHING`,
+	},
+	{
+		name:   "tool_messages",
+		query:  `SELECT from_tool, to_tool, message_type, data, processed, created_at, processed_at FROM tool_messages ORDER BY id`,
+		insert: `INSERT INTO tool_messages (from_tool, to_tool, message_type, data, processed, created_at, processed_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+	},
+	{
+		// The legacy usage_stats predates the tool and session_id columns
+		name:   "usage_stats",
+		query:  `SELECT command, project, duration_ms, success, error_message, created_at FROM usage_stats ORDER BY id`,
+		insert: `INSERT INTO usage_stats (command, project, duration_ms, success, error_message, created_at, tool) VALUES (?, ?, ?, ?, ?, ?, '` + ToolWherewasi + `')`,
+	},
+}
+
+// LegacyImport returns what was imported from a legacy database when this connection was opened, or nil
+func (edb *EcosystemDB) LegacyImport() *LegacyImport {
+	return edb.legacyImport
+}
+
+// isLegacyDatabase reports whether path was created by the old internal/database package,
+// recognised by a usage_stats table without the ecosystem tool column
+func isLegacyDatabase(path string) (bool, error) {
+	legacy, err := sql.Open("sqlite", path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer legacy.Close()
+
+	var tables, toolColumns int
+	err = legacy.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'usage_stats'`).Scan(&tables)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect %s: %w", path, err)
+	}
+	if tables == 0 {
+		return false, nil
+	}
+	err = legacy.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('usage_stats') WHERE name = 'tool'`).Scan(&toolColumns)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect %s: %w", path, err)
+	}
+	return toolColumns == 0, nil
+}
+
+// stageLegacyDatabase moves a legacy database at path aside before the ecosystem database is
+// opened, which may live at the same path. It returns the staged path, or "" when there is
+// nothing to import.
+func stageLegacyDatabase(path string) (string, error) {
+	pending := path + legacyPendingSuffix
+	if _, err := os.Stat(pending); err == nil {
+		return pending, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", nil
+	}
+
+	legacy, err := isLegacyDatabase(path)
+	if err != nil || !legacy {
+		return "", err
+	}
+	// Closing the inspection connection checkpointed the WAL, so the main file is complete
+	if err := os.Rename(path, pending); err != nil {
+		return "", fmt.Errorf("failed to move legacy database aside: %w", err)
+	}
+	return pending, nil
+}
+
+// importLegacyDatabase copies a staged legacy database into the ecosystem schema in one
+// transaction and keeps the file as a backup
+func (edb *EcosystemDB) importLegacyDatabase(pending string) (*LegacyImport, error) {
+	legacy, err := sql.Open("sqlite", pending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open legacy database: %w", err)
+	}
+	defer legacy.Close()
+
+	tx, err := edb.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	path := strings.TrimSuffix(pending, legacyPendingSuffix)
+	result := &LegacyImport{Path: path, Backup: path + legacyImportedSuffix}
+	counts := []*int{&result.Contexts, &result.Projects, &result.Messages, &result.Usage}
+	for i, table := range legacyTables {
+		copied, err := copyLegacyRows(legacy, tx, table.query, table.insert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import legacy %s: %w", table.name, err)
+		}
+		*counts[i] = copied
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit legacy import: %w", err)
+	}
+	legacy.Close()
+
+	if err := os.Rename(pending, result.Backup); err != nil {
+		return nil, fmt.Errorf("failed to keep legacy database backup: %w", err)
+	}
+	return result, nil
+}
+
+// copyLegacyRows inserts every row of query into tx, returning how many were written
+func copyLegacyRows(legacy *sql.DB, tx *sql.Tx, query, insert string) (int, error) {
+	rows, err := legacy.Query(query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.Prepare(insert)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	copied := 0
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return copied, err
+		}
+		result, err := stmt.Exec(values...)
+		if err != nil {
+			return copied, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return copied, err
+		}
+		copied += int(affected)
+	}
+	return copied, rows.Err()
+}
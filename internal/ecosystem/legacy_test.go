@@ -0,0 +1,160 @@
+package ecosystem
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// legacySchema is the schema the old internal/database package created in context.sqlite
+const legacySchema = `
+CREATE TABLE context_sessions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	project TEXT NOT NULL,
+	timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	context_data TEXT NOT NULL,
+	session_info TEXT,
+	keywords TEXT,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE projects (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT UNIQUE NOT NULL,
+	path TEXT NOT NULL,
+	last_activity DATETIME,
+	git_repo BOOLEAN DEFAULT FALSE,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE tool_messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	from_tool TEXT NOT NULL DEFAULT 'wherewasi',
+	to_tool TEXT NOT NULL,
+	message_type TEXT NOT NULL,
+	data TEXT NOT NULL,
+	processed BOOLEAN DEFAULT FALSE,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	processed_at DATETIME
+);
+CREATE TABLE usage_stats (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	command TEXT NOT NULL,
+	project TEXT,
+	duration_ms INTEGER,
+	success BOOLEAN DEFAULT TRUE,
+	error_message TEXT,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE schema_migrations (
+	version INTEGER PRIMARY KEY,
+	description TEXT NOT NULL,
+	applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO schema_migrations (version, description)
+VALUES (1, 'Initial wherewasi schema with context sessions and project tracking');
+
+INSERT INTO context_sessions (project, timestamp, context_data, session_info, keywords)
+VALUES ('wherewasi', '2025-06-01 12:00:00', 'Old context about the ripcord', 'Context pull', 'ripcord'),
+       ('uroboro', '2025-06-02 12:00:00', 'Old uroboro context', 'Context pull', '');
+INSERT INTO projects (name, path, git_repo) VALUES ('wherewasi', '/src/wherewasi', TRUE), ('existing', '/old/path', TRUE);
+INSERT INTO tool_messages (to_tool, message_type, data) VALUES ('uroboro', 'context_export', '{}');
+INSERT INTO usage_stats (command, project, duration_ms) VALUES ('pull', 'wherewasi', 120);
+`
+
+func createLegacyDatabase(t *testing.T, path string) {
+	t.Helper()
+	legacy, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Failed to create legacy database: %v", err)
+	}
+	defer legacy.Close()
+	if _, err := legacy.Exec(legacySchema); err != nil {
+		t.Fatalf("Failed to create legacy schema: %v", err)
+	}
+}
+
+func TestImportLegacyDatabase(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		shared bool
+	}{
+		// Local mode opens the ecosystem schema at the legacy path itself
+		{name: "SamePath"},
+		{name: "SeparateDatabase", shared: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			legacyPath := filepath.Join(dir, "context.sqlite")
+			dbPath := legacyPath
+			if tc.shared {
+				dbPath = filepath.Join(dir, "ecosystem.sqlite")
+			}
+			createLegacyDatabase(t, legacyPath)
+
+			if tc.shared {
+				seed := openTestDB(t, dbPath, false)
+				seed.TrackProject("existing", "/current/path", ToolUroboro, true)
+				seed.Close()
+			}
+
+			config := DatabaseConfig{ToolName: ToolWherewasi, FallbackPath: dbPath, ForceLocal: true, LegacyPath: legacyPath}
+			db, err := NewEcosystemDB(config)
+			if err != nil {
+				t.Fatalf("Failed to open database: %v", err)
+			}
+			defer db.Close()
+
+			imported := db.LegacyImport()
+			if imported == nil {
+				t.Fatal("Expected the legacy database to be imported")
+			}
+			wantProjects := 2
+			if tc.shared {
+				wantProjects = 1
+			}
+			if imported.Contexts != 2 || imported.Projects != wantProjects || imported.Messages != 1 || imported.Usage != 1 {
+				t.Errorf("Unexpected import counts: %+v", imported)
+			}
+
+			contexts, err := db.SearchStoredContexts("ripcord")
+			if err != nil || len(contexts) != 1 || contexts[0].Project != "wherewasi" {
+				t.Fatalf("Expected the legacy context to be searchable, got %v (%v)", contexts, err)
+			}
+			if contexts[0].Timestamp.Year() != 2025 {
+				t.Errorf("Expected the original timestamp to be kept, got %v", contexts[0].Timestamp)
+			}
+
+			// Projects already in the ecosystem keep their current row
+			existing, err := db.GetProject("existing")
+			if err != nil || existing == nil {
+				t.Fatalf("Expected existing project, got %v (%v)", existing, err)
+			}
+			if tc.shared && existing.Path != "/current/path" {
+				t.Errorf("Expected the ecosystem project to win, got %s", existing.Path)
+			}
+
+			var tool string
+			if err := db.QueryRow(`SELECT tool FROM usage_stats WHERE command = 'pull'`).Scan(&tool); err != nil || tool != ToolWherewasi {
+				t.Errorf("Expected legacy usage attributed to wherewasi, got %q (%v)", tool, err)
+			}
+
+			if _, err := os.Stat(imported.Backup); err != nil {
+				t.Errorf("Expected the legacy database to be kept as a backup: %v", err)
+			}
+			db.Close()
+
+			// Reopening does not import again
+			db, err = NewEcosystemDB(config)
+			if err != nil {
+				t.Fatalf("Failed to reopen database: %v", err)
+			}
+			if db.LegacyImport() != nil {
+				t.Error("Expected the legacy database to be imported only once")
+			}
+			contexts, _ = db.GetRecentContexts("wherewasi", 10)
+			if len(contexts) != 1 {
+				t.Errorf("Expected 1 wherewasi context after reopening, got %d", len(contexts))
+			}
+		})
+	}
+}
@@ -0,0 +1,728 @@
+package ecosystem
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrSchemaTooNew is returned when the database has migrations this binary does not know
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+
+// Migration is one versioned schema change. Migrations are recorded in the shared
+// schema_migrations table by tool and version, so tools number their migrations independently
+// and a version another tool recorded never stands in for one of ours.
+type Migration struct {
+	Version     int
+	Tool        string
+	Description string
+	SQL         string
+}
+
+// Checksum fingerprints the migration SQL so edits to applied migrations can be detected
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.SQL))
+	return hex.EncodeToString(sum[:])
+}
+
+// MigrationStatus is a migration and whether it has been applied
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+	// Checksum is the recorded checksum; it differs from Migration.Checksum() when the SQL changed after applying
+	Checksum string
+	// Unknown migrations are recorded in the database but missing from this binary
+	Unknown bool
+}
+
+// Modified reports whether an applied migration's SQL has changed since it ran
+func (s MigrationStatus) Modified() bool {
+	return s.Applied && !s.Unknown && s.Checksum != "" && s.Checksum != s.Migration.Checksum()
+}
+
+// ToolEcosystem owns the shared tables every tool depends on
+const ToolEcosystem = "ecosystem"
+
+// migrations lists every known schema change in version order
+var migrations = []Migration{
+	{
+		Version:     1,
+		Tool:        ToolEcosystem,
+		Description: "Initial shared ecosystem schema",
+		SQL: `
+	-- QRY Ecosystem Shared Database Schema
+	-- Each tool can function independently, but when pointed to shared DB,
+	-- they gain ecosystem intelligence through overlapping tables
+	
+	-- === SHARED CORE TABLES ===
+	
+	-- Projects tracked across the ecosystem
+	CREATE TABLE IF NOT EXISTS projects (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		description TEXT,
+		path TEXT,
+		git_repo BOOLEAN DEFAULT FALSE,
+		last_activity DATETIME,
+		primary_tool TEXT, -- which tool primarily manages this project
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	-- Cross-tool communication
+	CREATE TABLE IF NOT EXISTS tool_messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		from_tool TEXT NOT NULL,
+		to_tool TEXT NOT NULL,
+		message_type TEXT NOT NULL,
+		data TEXT NOT NULL,
+		processed BOOLEAN DEFAULT FALSE,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		processed_at DATETIME
+	);
+	
+	-- Usage analytics across ecosystem
+	CREATE TABLE IF NOT EXISTS usage_stats (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		tool TEXT NOT NULL,
+		command TEXT NOT NULL,
+		project TEXT,
+		duration_ms INTEGER,
+		success BOOLEAN DEFAULT TRUE,
+		error_message TEXT,
+		session_id TEXT,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	-- Cross-tool insights and recommendations
+	CREATE TABLE IF NOT EXISTS ecosystem_insights (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		insight_type TEXT NOT NULL,
+		source_tool TEXT NOT NULL,
+		target_tool TEXT,
+		project TEXT,
+		confidence REAL DEFAULT 0.0,
+		data TEXT NOT NULL, -- JSON
+		applied BOOLEAN DEFAULT FALSE,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		applied_at DATETIME
+	);
+	
+	-- === SHARED INDEXES ===
+	CREATE INDEX IF NOT EXISTS idx_projects_name ON projects(name);
+	CREATE INDEX IF NOT EXISTS idx_projects_last_activity ON projects(last_activity);
+	CREATE INDEX IF NOT EXISTS idx_tool_messages_to_tool ON tool_messages(to_tool, processed);
+	CREATE INDEX IF NOT EXISTS idx_tool_messages_from_tool ON tool_messages(from_tool);
+	CREATE INDEX IF NOT EXISTS idx_usage_stats_tool ON usage_stats(tool);
+	CREATE INDEX IF NOT EXISTS idx_usage_stats_project ON usage_stats(project);
+	CREATE INDEX IF NOT EXISTS idx_ecosystem_insights_target ON ecosystem_insights(target_tool, applied);
+	CREATE INDEX IF NOT EXISTS idx_ecosystem_insights_project ON ecosystem_insights(project);
+	`,
+	},
+	{
+		Version:     2,
+		Tool:        ToolWherewasi,
+		Description: "Wherewasi context sessions and project tracking",
+		SQL: `
+	-- === WHEREWASI TABLES ===
+	
+	-- Context sessions for ripcord deployments
+	CREATE TABLE IF NOT EXISTS context_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		project TEXT NOT NULL,
+		timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		context_data TEXT NOT NULL,
+		session_info TEXT,
+		keywords TEXT,
+		git_branch TEXT,
+		git_commit TEXT,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	-- Wherewasi-specific indexes
+	CREATE INDEX IF NOT EXISTS idx_context_sessions_project ON context_sessions(project);
+	CREATE INDEX IF NOT EXISTS idx_context_sessions_timestamp ON context_sessions(timestamp);
+	CREATE INDEX IF NOT EXISTS idx_context_sessions_keywords ON context_sessions(keywords);
+	CREATE INDEX IF NOT EXISTS idx_context_sessions_git_branch ON context_sessions(git_branch);
+	`,
+	},
+	{
+		Version:     3,
+		Tool:        ToolUroboro,
+		Description: "Uroboro captures and publications with context linking",
+		SQL: `
+	-- === UROBORO TABLES ===
+	
+	-- Content captures
+	CREATE TABLE IF NOT EXISTS captures (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		content TEXT NOT NULL,
+		project TEXT,
+		tags TEXT,
+		source_tool TEXT DEFAULT 'uroboro',
+		metadata TEXT, -- JSON
+		context_session_id INTEGER,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (context_session_id) REFERENCES context_sessions(id)
+	);
+	
+	-- Published content
+	CREATE TABLE IF NOT EXISTS publications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		content TEXT NOT NULL,
+		format TEXT NOT NULL,
+		type TEXT NOT NULL,
+		source_captures TEXT, -- JSON array of capture IDs
+		project TEXT,
+		target_path TEXT,
+		context_session_id INTEGER,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (context_session_id) REFERENCES context_sessions(id)
+	);
+	
+	-- Uroboro-specific indexes
+	CREATE INDEX IF NOT EXISTS idx_captures_timestamp ON captures(timestamp);
+	CREATE INDEX IF NOT EXISTS idx_captures_project ON captures(project);
+	CREATE INDEX IF NOT EXISTS idx_captures_source_tool ON captures(source_tool);
+	CREATE INDEX IF NOT EXISTS idx_captures_context_session ON captures(context_session_id);
+	CREATE INDEX IF NOT EXISTS idx_publications_type ON publications(type);
+	CREATE INDEX IF NOT EXISTS idx_publications_project ON publications(project);
+	CREATE INDEX IF NOT EXISTS idx_publications_context_session ON publications(context_session_id);
+	`,
+	},
+	{
+		Version:     4,
+		Tool:        ToolExaminator,
+		Description: "Examinator flashcards and study tracking with ecosystem links",
+		SQL: `
+	-- === EXAMINATOR TABLES ===
+	
+	-- Flashcards for spaced repetition
+	CREATE TABLE IF NOT EXISTS flashcards (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		question TEXT NOT NULL,
+		answer TEXT NOT NULL,
+		category TEXT,
+		difficulty INTEGER DEFAULT 1,
+		source_capture_id INTEGER,
+		context_session_id INTEGER,
+		project TEXT,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		last_reviewed DATETIME,
+		next_review DATETIME,
+		ease_factor REAL DEFAULT 2.5,
+		review_count INTEGER DEFAULT 0,
+		correct_streak INTEGER DEFAULT 0,
+		FOREIGN KEY (source_capture_id) REFERENCES captures(id),
+		FOREIGN KEY (context_session_id) REFERENCES context_sessions(id)
+	);
+	
+	-- Study sessions
+	CREATE TABLE IF NOT EXISTS study_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		project TEXT,
+		flashcards_reviewed INTEGER DEFAULT 0,
+		correct_answers INTEGER DEFAULT 0,
+		duration_minutes INTEGER,
+		context_session_id INTEGER,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (context_session_id) REFERENCES context_sessions(id)
+	);
+	
+	-- Flashcard reviews (detailed tracking)
+	CREATE TABLE IF NOT EXISTS flashcard_reviews (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		flashcard_id INTEGER NOT NULL,
+		study_session_id INTEGER,
+		response_quality INTEGER, -- 0-5 scale
+		response_time_ms INTEGER,
+		was_correct BOOLEAN,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (flashcard_id) REFERENCES flashcards(id),
+		FOREIGN KEY (study_session_id) REFERENCES study_sessions(id)
+	);
+	
+	-- Examinator-specific indexes
+	CREATE INDEX IF NOT EXISTS idx_flashcards_project ON flashcards(project);
+	CREATE INDEX IF NOT EXISTS idx_flashcards_next_review ON flashcards(next_review);
+	CREATE INDEX IF NOT EXISTS idx_flashcards_source_capture ON flashcards(source_capture_id);
+	CREATE INDEX IF NOT EXISTS idx_flashcards_context_session ON flashcards(context_session_id);
+	CREATE INDEX IF NOT EXISTS idx_study_sessions_project ON study_sessions(project);
+	CREATE INDEX IF NOT EXISTS idx_flashcard_reviews_flashcard ON flashcard_reviews(flashcard_id);
+	CREATE INDEX IF NOT EXISTS idx_flashcard_reviews_session ON flashcard_reviews(study_session_id);
+	`,
+	},
+	{
+		Version:     5,
+		Tool:        ToolWherewasi,
+		Description: "Wherewasi activity event journal",
+		SQL: `
+	-- Per-file edit journal written by the filesystem watcher
+	CREATE TABLE IF NOT EXISTS activity_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		project TEXT NOT NULL,
+		path TEXT NOT NULL,
+		event_kind TEXT NOT NULL,
+		timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	CREATE INDEX IF NOT EXISTS idx_activity_events_project_timestamp ON activity_events(project, timestamp);
+	`,
+	},
+	{
+		Version:     6,
+		Tool:        ToolWherewasi,
+		Description: "Wherewasi git dirty and upstream state on context sessions",
+		SQL: `
+	ALTER TABLE context_sessions ADD COLUMN git_dirty BOOLEAN DEFAULT FALSE;
+	ALTER TABLE context_sessions ADD COLUMN git_ahead INTEGER;
+	ALTER TABLE context_sessions ADD COLUMN git_behind INTEGER;
+	`,
+	},
+	{
+		Version:     7,
+		Tool:        ToolWherewasi,
+		Description: "Wherewasi project aliases",
+		SQL: `
+	-- Alternative names for projects, used by pull --project
+	CREATE TABLE IF NOT EXISTS project_aliases (
+		alias TEXT PRIMARY KEY,
+		project TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	CREATE INDEX IF NOT EXISTS idx_project_aliases_project ON project_aliases(project);
+	`,
+	},
+	{
+		Version:     8,
+		Tool:        ToolWherewasi,
+		Description: "Wherewasi full-text search over context history",
+		SQL: `
+	-- Timestamps used to be stored in Go's time.String() layout, which SQLite date functions
+	-- cannot parse; rewrite them as UTC so --since filters and ordering compare correctly
+	UPDATE context_sessions
+	SET timestamp = strftime('%Y-%m-%d %H:%M:%f+00:00',
+		substr(timestamp, 1, instr(substr(timestamp, 12), ' ') + 10) ||
+		substr(timestamp, instr(substr(timestamp, 12), ' ') + 12, 3) || ':' ||
+		substr(timestamp, instr(substr(timestamp, 12), ' ') + 15, 2))
+	WHERE julianday(timestamp) IS NULL AND timestamp LIKE '____-__-__ __:__:__% %';
+	
+	-- External-content index over context_sessions, kept in sync by triggers
+	CREATE VIRTUAL TABLE IF NOT EXISTS context_sessions_fts USING fts5(
+		context_data, session_info, keywords,
+		content='context_sessions', content_rowid='id', prefix='2 3'
+	);
+	
+	CREATE TRIGGER IF NOT EXISTS context_sessions_fts_insert AFTER INSERT ON context_sessions BEGIN
+		INSERT INTO context_sessions_fts (rowid, context_data, session_info, keywords)
+		VALUES (new.id, new.context_data, new.session_info, new.keywords);
+	END;
+	
+	CREATE TRIGGER IF NOT EXISTS context_sessions_fts_delete AFTER DELETE ON context_sessions BEGIN
+		INSERT INTO context_sessions_fts (context_sessions_fts, rowid, context_data, session_info, keywords)
+		VALUES ('delete', old.id, old.context_data, old.session_info, old.keywords);
+	END;
+	
+	CREATE TRIGGER IF NOT EXISTS context_sessions_fts_update AFTER UPDATE OF context_data, session_info, keywords ON context_sessions BEGIN
+		INSERT INTO context_sessions_fts (context_sessions_fts, rowid, context_data, session_info, keywords)
+		VALUES ('delete', old.id, old.context_data, old.session_info, old.keywords);
+		INSERT INTO context_sessions_fts (rowid, context_data, session_info, keywords)
+		VALUES (new.id, new.context_data, new.session_info, new.keywords);
+	END;
+	
+	-- Index contexts saved before this migration
+	INSERT INTO context_sessions_fts (context_sessions_fts) VALUES ('rebuild');
+	`,
+	},
+	{
+		Version:     9,
+		Tool:        ToolWherewasi,
+		Description: "Wherewasi notes and checkpoints",
+		SQL: `
+	-- Breadcrumbs left for later, shown in pulls until marked done
+	CREATE TABLE IF NOT EXISTS notes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		project TEXT NOT NULL,
+		branch TEXT,
+		git_commit TEXT,
+		kind TEXT NOT NULL DEFAULT 'note',
+		body TEXT NOT NULL,
+		context_session_id INTEGER,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		done_at DATETIME,
+		FOREIGN KEY (context_session_id) REFERENCES context_sessions(id) ON DELETE SET NULL
+	);
+	
+	CREATE INDEX IF NOT EXISTS idx_notes_project_done ON notes(project, done_at);
+	`,
+	},
+	{
+		Version:     10,
+		Tool:        ToolWherewasi,
+		Description: "Wherewasi context deduplication, compression and maintenance",
+		SQL: `
+	-- content_hash identifies repeated pulls; context_encoding marks compressed context_data
+	ALTER TABLE context_sessions ADD COLUMN content_hash TEXT;
+	ALTER TABLE context_sessions ADD COLUMN context_encoding TEXT;
+	CREATE INDEX IF NOT EXISTS idx_context_sessions_hash ON context_sessions(project, content_hash);
+	
+	-- Compressed context text cannot be tokenized, so the index reads through a view that
+	-- leaves it out; those contexts stay searchable by session info and keywords
+	DROP TRIGGER IF EXISTS context_sessions_fts_insert;
+	DROP TRIGGER IF EXISTS context_sessions_fts_delete;
+	DROP TRIGGER IF EXISTS context_sessions_fts_update;
+	DROP TABLE IF EXISTS context_sessions_fts;
+	
+	CREATE VIEW IF NOT EXISTS context_sessions_searchable AS
+	SELECT id,
+		CASE WHEN context_encoding IS NULL THEN context_data ELSE '' END AS context_data,
+		session_info, keywords
+	FROM context_sessions;
+	
+	CREATE VIRTUAL TABLE IF NOT EXISTS context_sessions_fts USING fts5(
+		context_data, session_info, keywords,
+		content='context_sessions_searchable', content_rowid='id', prefix='2 3'
+	);
+	
+	CREATE TRIGGER IF NOT EXISTS context_sessions_fts_insert AFTER INSERT ON context_sessions BEGIN
+		INSERT INTO context_sessions_fts (rowid, context_data, session_info, keywords)
+		VALUES (new.id, CASE WHEN new.context_encoding IS NULL THEN new.context_data ELSE '' END,
+			new.session_info, new.keywords);
+	END;
+	
+	CREATE TRIGGER IF NOT EXISTS context_sessions_fts_delete AFTER DELETE ON context_sessions BEGIN
+		INSERT INTO context_sessions_fts (context_sessions_fts, rowid, context_data, session_info, keywords)
+		VALUES ('delete', old.id, CASE WHEN old.context_encoding IS NULL THEN old.context_data ELSE '' END,
+			old.session_info, old.keywords);
+	END;
+	
+	CREATE TRIGGER IF NOT EXISTS context_sessions_fts_update
+	AFTER UPDATE OF context_data, context_encoding, session_info, keywords ON context_sessions BEGIN
+		INSERT INTO context_sessions_fts (context_sessions_fts, rowid, context_data, session_info, keywords)
+		VALUES ('delete', old.id, CASE WHEN old.context_encoding IS NULL THEN old.context_data ELSE '' END,
+			old.session_info, old.keywords);
+		INSERT INTO context_sessions_fts (rowid, context_data, session_info, keywords)
+		VALUES (new.id, CASE WHEN new.context_encoding IS NULL THEN new.context_data ELSE '' END,
+			new.session_info, new.keywords);
+	END;
+	
+	INSERT INTO context_sessions_fts (context_sessions_fts) VALUES ('rebuild');
+	
+	-- When periodic maintenance such as VACUUM last ran
+	CREATE TABLE IF NOT EXISTS maintenance_runs (
+		task TEXT PRIMARY KEY,
+		ran_at DATETIME NOT NULL
+	);
+	`,
+	},
+	{
+		Version:     11,
+		Tool:        ToolWherewasi,
+		Description: "Wherewasi persistent file search index",
+		SQL: `
+	-- Projects in the search index; git_tree is the tree of a clean working tree when it
+	-- was last indexed, letting unchanged repositories skip the file walk
+	CREATE TABLE IF NOT EXISTS search_index_projects (
+		project TEXT PRIMARY KEY,
+		root TEXT NOT NULL,
+		git_tree TEXT,
+		indexed_at DATETIME NOT NULL
+	);
+	
+	-- Indexed files with the size and modification time (unix nanoseconds) they were read at
+	CREATE TABLE IF NOT EXISTS search_index_files (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		project TEXT NOT NULL,
+		path TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		mtime INTEGER NOT NULL,
+		UNIQUE (project, path)
+	);
+	
+	-- Trigram index of file text keyed by search_index_files.id; the text itself is not stored,
+	-- so matches are candidates that are confirmed against the files on disk
+	CREATE VIRTUAL TABLE IF NOT EXISTS search_index_fts USING fts5(
+		content, content='', contentless_delete=1, tokenize='trigram'
+	);
+	
+	CREATE TRIGGER IF NOT EXISTS search_index_files_delete AFTER DELETE ON search_index_files BEGIN
+		DELETE FROM search_index_fts WHERE rowid = old.id;
+	END;
+	`,
+	},
+}
+
+// toolMigrations returns the migrations a tool runs: the shared ecosystem schema plus its own
+func toolMigrations(tool string) []Migration {
+	var result []Migration
+	for _, m := range migrations {
+		if m.Tool == ToolEcosystem || m.Tool == tool {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// migrate brings the schema up to date for a tool
+func (edb *EcosystemDB) migrate(tool string) error {
+	_, err := edb.Migrate(tool)
+	return err
+}
+
+// migrationKey identifies a recorded migration
+type migrationKey struct {
+	tool    string
+	version int
+}
+
+// migrationsTableSQL keys migrations by tool and version
+const migrationsTableSQL = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		tool TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		description TEXT NOT NULL,
+		checksum TEXT,
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (tool, version)
+	);
+	`
+
+// ensureMigrationsTable creates schema_migrations, adding the checksum column to tables
+// recorded before checksums existed and rekeying tables keyed by version alone
+func (edb *EcosystemDB) ensureMigrationsTable() error {
+	if _, err := edb.Exec(migrationsTableSQL); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	hasChecksum, err := edb.hasColumn("schema_migrations", "checksum")
+	if err != nil {
+		return err
+	}
+	if !hasChecksum {
+		if _, err := edb.Exec(`ALTER TABLE schema_migrations ADD COLUMN checksum TEXT`); err != nil {
+			return fmt.Errorf("failed to add migration checksums: %w", err)
+		}
+	}
+
+	var toolKeyed int
+	err = edb.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('schema_migrations') WHERE name = 'tool' AND pk > 0`).Scan(&toolKeyed)
+	if err != nil {
+		return fmt.Errorf("failed to inspect schema_migrations: %w", err)
+	}
+	if toolKeyed > 0 {
+		return nil
+	}
+	return edb.rekeyMigrationsTable()
+}
+
+// rekeyMigrationsTable rebuilds a schema_migrations table keyed by version alone so that it is
+// keyed by tool and version, keeping every recorded migration
+func (edb *EcosystemDB) rekeyMigrationsTable() error {
+	tx, err := edb.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin rekeying migrations: %w", err)
+	}
+	defer tx.Rollback()
+
+	steps := []string{
+		`ALTER TABLE schema_migrations RENAME TO schema_migrations_by_version`,
+		migrationsTableSQL,
+		`INSERT INTO schema_migrations (tool, version, description, checksum, applied_at)
+			SELECT tool, version, description, checksum, applied_at FROM schema_migrations_by_version`,
+		`DROP TABLE schema_migrations_by_version`,
+	}
+	for _, step := range steps {
+		if _, err := tx.Exec(step); err != nil {
+			return fmt.Errorf("failed to rekey migrations by tool: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rekeyed migrations: %w", err)
+	}
+	return nil
+}
+
+// hasColumn reports whether a table has a column; missing tables have no columns
+func (edb *EcosystemDB) hasColumn(table, column string) (bool, error) {
+	var count int
+	err := edb.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect %s: %w", table, err)
+	}
+	return count > 0, nil
+}
+
+// appliedMigrations reads schema_migrations keyed by tool and version. It does not modify the
+// database, so it works on tables that predate checksums or do not exist yet.
+func (edb *EcosystemDB) appliedMigrations() (map[migrationKey]MigrationStatus, error) {
+	applied := make(map[migrationKey]MigrationStatus)
+
+	hasTable, err := edb.hasColumn("schema_migrations", "version")
+	if err != nil || !hasTable {
+		return applied, err
+	}
+	checksumColumn := "NULL"
+	if hasChecksum, err := edb.hasColumn("schema_migrations", "checksum"); err != nil {
+		return nil, err
+	} else if hasChecksum {
+		checksumColumn = "checksum"
+	}
+
+	rows, err := edb.Query(`SELECT version, tool, description, ` + checksumColumn + `, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status MigrationStatus
+		var checksum sql.NullString
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&status.Version, &status.Tool, &status.Description, &checksum, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		status.Applied = true
+		status.Checksum = checksum.String
+		if appliedAt.Valid {
+			status.AppliedAt = &appliedAt.Time
+		}
+		applied[migrationKey{status.Tool, status.Version}] = status
+	}
+	return applied, rows.Err()
+}
+
+// MigrationStatus lists a tool's migrations in version order, including migrations recorded
+// by a newer binary that this one does not know
+func (edb *EcosystemDB) MigrationStatus(tool string) ([]MigrationStatus, error) {
+	applied, err := edb.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	known := make(map[migrationKey]bool)
+	for _, m := range toolMigrations(tool) {
+		key := migrationKey{m.Tool, m.Version}
+		known[key] = true
+		status := MigrationStatus{Migration: m}
+		if recorded, ok := applied[key]; ok {
+			status.Applied = true
+			status.AppliedAt = recorded.AppliedAt
+			status.Checksum = recorded.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+
+	for key, recorded := range applied {
+		if known[key] || (recorded.Tool != ToolEcosystem && recorded.Tool != tool) {
+			continue
+		}
+		recorded.Unknown = true
+		statuses = append(statuses, recorded)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Version != statuses[j].Version {
+			return statuses[i].Version < statuses[j].Version
+		}
+		return statuses[i].Tool < statuses[j].Tool
+	})
+	return statuses, nil
+}
+
+// PendingMigrations returns the migrations a tool still needs, or ErrSchemaTooNew when the
+// database has migrations this binary does not know
+func (edb *EcosystemDB) PendingMigrations(tool string) ([]Migration, error) {
+	statuses, err := edb.MigrationStatus(tool)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	var unknown []string
+	for _, status := range statuses {
+		switch {
+		case status.Unknown:
+			unknown = append(unknown, fmt.Sprintf("%s v%d", status.Tool, status.Version))
+		case !status.Applied:
+			pending = append(pending, status.Migration)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: it has %s; upgrade %s", ErrSchemaTooNew, strings.Join(unknown, ", "), tool)
+	}
+	return pending, nil
+}
+
+// Migrate applies a tool's pending migrations in order, each in its own transaction.
+// Checksums are backfilled for migrations applied before they were recorded.
+func (edb *EcosystemDB) Migrate(tool string) ([]Migration, error) {
+	pending, err := edb.PendingMigrations(tool)
+	if err != nil {
+		return nil, err
+	}
+	if err := edb.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	for _, m := range toolMigrations(tool) {
+		if _, err := edb.Exec(`UPDATE schema_migrations SET checksum = ? WHERE tool = ? AND version = ? AND checksum IS NULL`, m.Checksum(), m.Tool, m.Version); err != nil {
+			return nil, fmt.Errorf("failed to record checksum for migration %d: %w", m.Version, err)
+		}
+	}
+
+	var applied []Migration
+	for _, m := range pending {
+		ran, err := edb.applyMigration(m)
+		if err != nil {
+			return applied, err
+		}
+		if ran {
+			applied = append(applied, m)
+		}
+	}
+	return applied, nil
+}
+
+// applyMigration runs one migration unless another process applied it first
+func (edb *EcosystemDB) applyMigration(m Migration) (bool, error) {
+	tx, err := edb.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin migration %d: %w", m.Version, err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE tool = ? AND version = ?`, m.Tool, m.Version).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check migration %d: %w", m.Version, err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return false, fmt.Errorf("failed to apply migration %d (%s): %w", m.Version, m.Description, err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO schema_migrations (version, tool, description, checksum) VALUES (?, ?, ?, ?)
+	`, m.Version, m.Tool, m.Description, m.Checksum())
+	if err != nil {
+		return false, fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit migration %d: %w", m.Version, err)
+	}
+	return true, nil
+}
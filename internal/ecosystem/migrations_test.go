@@ -0,0 +1,236 @@
+package ecosystem
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func openTestDB(t *testing.T, path string, skipMigrations bool) *EcosystemDB {
+	t.Helper()
+	db, err := NewEcosystemDB(DatabaseConfig{
+		ToolName:       ToolWherewasi,
+		FallbackPath:   path,
+		ForceLocal:     true,
+		SkipMigrations: skipMigrations,
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrateFresh(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "test.sqlite"), true)
+
+	pending, err := db.PendingMigrations(ToolWherewasi)
+	if err != nil {
+		t.Fatalf("Failed to list pending migrations: %v", err)
+	}
+	if len(pending) != len(toolMigrations(ToolWherewasi)) {
+		t.Errorf("Expected every migration pending, got %d", len(pending))
+	}
+
+	applied, err := db.Migrate(ToolWherewasi)
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if len(applied) != len(pending) {
+		t.Errorf("Expected %d migrations applied, got %d", len(pending), len(applied))
+	}
+
+	// Other tools' tables are left to their own binaries
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'captures'`).Scan(&count)
+	if count != 0 {
+		t.Error("Expected uroboro tables not to be created by wherewasi")
+	}
+
+	applied, err = db.Migrate(ToolWherewasi)
+	if err != nil || len(applied) != 0 {
+		t.Errorf("Expected second migrate to be a no-op, got %d applied, err %v", len(applied), err)
+	}
+}
+
+func TestMigrateLegacyTable(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "legacy.sqlite"), true)
+
+	// Databases from before versioned migrations have no checksum column
+	_, err := db.Exec(`
+	CREATE TABLE schema_migrations (
+		version INTEGER PRIMARY KEY,
+		tool TEXT NOT NULL,
+		description TEXT NOT NULL,
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+	for _, m := range migrations[:2] {
+		if _, err := db.Exec(m.SQL); err != nil {
+			t.Fatalf("Failed to apply legacy schema: %v", err)
+		}
+		db.Exec(`INSERT INTO schema_migrations (version, tool, description) VALUES (?, ?, ?)`, m.Version, m.Tool, m.Description)
+	}
+
+	applied, err := db.Migrate(ToolWherewasi)
+	if err != nil {
+		t.Fatalf("Failed to migrate legacy database: %v", err)
+	}
+	if len(applied) != len(toolMigrations(ToolWherewasi))-2 {
+		t.Errorf("Expected only newer migrations to run, got %d", len(applied))
+	}
+
+	statuses, err := db.MigrationStatus(ToolWherewasi)
+	if err != nil {
+		t.Fatalf("Failed to read status: %v", err)
+	}
+	for _, status := range statuses {
+		if !status.Applied || status.Checksum != status.Migration.Checksum() {
+			t.Errorf("Expected v%d applied with a backfilled checksum, got %+v", status.Version, status)
+		}
+	}
+
+	db.Exec(`UPDATE schema_migrations SET checksum = 'edited' WHERE version = 2`)
+	statuses, _ = db.MigrationStatus(ToolWherewasi)
+	for _, status := range statuses {
+		if status.Modified() != (status.Version == 2) {
+			t.Errorf("Expected only v2 to be reported as modified, v%d: %v", status.Version, status.Modified())
+		}
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "newer.sqlite")
+	db := openTestDB(t, path, false)
+
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version, tool, description) VALUES (999, ?, 'from the future')`, ToolWherewasi); err != nil {
+		t.Fatalf("Failed to record future migration: %v", err)
+	}
+	// Unknown migrations of other tools do not concern wherewasi
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version, tool, description) VALUES (998, ?, 'uroboro upgrade')`, ToolUroboro); err != nil {
+		t.Fatalf("Failed to record other tool migration: %v", err)
+	}
+	db.Close()
+
+	_, err := NewEcosystemDB(DatabaseConfig{ToolName: ToolWherewasi, FallbackPath: path, ForceLocal: true})
+	if !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Expected ErrSchemaTooNew, got %v", err)
+	}
+
+	_, err = NewEcosystemDB(DatabaseConfig{ToolName: ToolUroboro, FallbackPath: path, ForceLocal: true})
+	if !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Expected uroboro to refuse its own unknown migration, got %v", err)
+	}
+}
+
+func TestMigrateNormalizesContextTimestamps(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "timestamps.sqlite"), true)
+	if err := db.ensureMigrationsTable(); err != nil {
+		t.Fatalf("Failed to create migrations table: %v", err)
+	}
+	for _, m := range toolMigrations(ToolWherewasi) {
+		if m.Version >= 8 {
+			break
+		}
+		if _, err := db.applyMigration(m); err != nil {
+			t.Fatalf("Failed to apply migration %d: %v", m.Version, err)
+		}
+	}
+
+	// The layout earlier binaries wrote, including the monotonic clock reading
+	_, err := db.Exec(`INSERT INTO context_sessions (project, timestamp, context_data) VALUES ('old', ?, 'before full-text search')`,
+		"2025-06-01 14:30:15.123456789 +0200 CEST m=+0.028358739")
+	if err != nil {
+		t.Fatalf("Failed to insert old context: %v", err)
+	}
+
+	if _, err := db.Migrate(ToolWherewasi); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	var timestamp string
+	if err := db.QueryRow(`SELECT datetime(timestamp) FROM context_sessions WHERE project = 'old'`).Scan(&timestamp); err != nil {
+		t.Fatalf("Failed to read normalized timestamp: %v", err)
+	}
+	if timestamp != "2025-06-01 12:30:15" {
+		t.Errorf("Expected the timestamp converted to UTC, got %s", timestamp)
+	}
+
+	sessions, err := db.SearchStoredContexts("before")
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("Expected contexts from before the migration to be indexed, got %d (%v)", len(sessions), err)
+	}
+}
+
+func TestMigrateSameVersionOtherTool(t *testing.T) {
+	for _, layout := range []struct {
+		name  string
+		table string
+	}{
+		{"keyed by tool", ""},
+		// Tables from before migrations were keyed by tool allowed one row per version
+		{"keyed by version", `
+		CREATE TABLE schema_migrations (
+			version INTEGER PRIMARY KEY,
+			tool TEXT NOT NULL,
+			description TEXT NOT NULL,
+			checksum TEXT,
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		`},
+	} {
+		t.Run(layout.name, func(t *testing.T) {
+			db := openTestDB(t, filepath.Join(t.TempDir(), "shared.sqlite"), true)
+			if layout.table != "" {
+				if _, err := db.Exec(layout.table); err != nil {
+					t.Fatalf("Failed to create migrations table: %v", err)
+				}
+			} else if err := db.ensureMigrationsTable(); err != nil {
+				t.Fatalf("Failed to create migrations table: %v", err)
+			}
+			for _, m := range toolMigrations(ToolWherewasi) {
+				if m.Version >= 8 {
+					break
+				}
+				if _, err := db.Exec(m.SQL); err != nil {
+					t.Fatalf("Failed to apply migration %d: %v", m.Version, err)
+				}
+				db.Exec(`INSERT INTO schema_migrations (version, tool, description, checksum) VALUES (?, ?, ?, ?)`, m.Version, m.Tool, m.Description, m.Checksum())
+			}
+			// Another tool numbered one of its own migrations 8 and got there first
+			if _, err := db.Exec(`INSERT INTO schema_migrations (version, tool, description) VALUES (8, ?, 'uroboro tags')`, ToolUroboro); err != nil {
+				t.Fatalf("Failed to record other tool migration: %v", err)
+			}
+
+			applied, err := db.Migrate(ToolWherewasi)
+			if err != nil {
+				t.Fatalf("Failed to migrate: %v", err)
+			}
+			if len(applied) == 0 || applied[0].Version != 8 || applied[0].Tool != ToolWherewasi {
+				t.Fatalf("Expected wherewasi v8 onwards to run, got %+v", applied)
+			}
+			var count int
+			db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'context_sessions_fts'`).Scan(&count)
+			if count != 1 {
+				t.Error("Expected the v8 full-text index to be created")
+			}
+
+			var tool string
+			if err := db.QueryRow(`SELECT tool FROM schema_migrations WHERE version = 8 AND description = 'uroboro tags'`).Scan(&tool); err != nil || tool != ToolUroboro {
+				t.Errorf("Expected the other tool's v8 to be kept, got %q (%v)", tool, err)
+			}
+			statuses, err := db.MigrationStatus(ToolWherewasi)
+			if err != nil {
+				t.Fatalf("Failed to read status: %v", err)
+			}
+			for _, status := range statuses {
+				if !status.Applied || status.Unknown || status.Tool == ToolUroboro {
+					t.Errorf("Expected only wherewasi migrations, all applied, got %+v", status)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package ecosystem
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Note kinds
+const (
+	NoteKindNote       = "note"
+	NoteKindCheckpoint = "checkpoint"
+)
+
+// Note is a breadcrumb left for later, optionally linked to the context saved with it
+type Note struct {
+	ID        int64     `json:"id"`
+	Project   string    `json:"project"`
+	Branch    string    `json:"branch,omitempty"`
+	Commit    string    `json:"commit,omitempty"`
+	Kind      string    `json:"kind"`
+	Body      string    `json:"body"`
+	ContextID *int64    `json:"context_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// DoneAt is set once the note is marked done
+	DoneAt *time.Time `json:"done_at,omitempty"`
+}
+
+// NoteFilter selects notes; an empty Project means every project
+type NoteFilter struct {
+	Project string
+	Branch  string
+	// IncludeDone also returns notes that were marked done
+	IncludeDone bool
+	Limit       int
+}
+
+// AddNote saves a note, stamped with the git state when provided
+func (edb *EcosystemDB) AddNote(note Note) (*Note, error) {
+	if note.Kind == "" {
+		note.Kind = NoteKindNote
+	}
+	note.CreatedAt = time.Now()
+
+	result, err := edb.Exec(`
+		INSERT INTO notes (project, branch, git_commit, kind, body, context_session_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, note.Project, nullableString(note.Branch), nullableString(note.Commit), note.Kind, note.Body,
+		note.ContextID, note.CreatedAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to add note: %w", err)
+	}
+
+	note.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insert ID: %w", err)
+	}
+	return &note, nil
+}
+
+// GetNotes lists notes matching the filter, newest first
+func (edb *EcosystemDB) GetNotes(filter NoteFilter) ([]Note, error) {
+	query := `
+		SELECT id, project, COALESCE(branch, ''), COALESCE(git_commit, ''), kind, body,
+			context_session_id, created_at, done_at
+		FROM notes
+		WHERE 1 = 1`
+	var args []interface{}
+	if filter.Project != "" {
+		query += ` AND project = ?`
+		args = append(args, filter.Project)
+	}
+	if filter.Branch != "" {
+		query += ` AND branch = ?`
+		args = append(args, filter.Branch)
+	}
+	if !filter.IncludeDone {
+		query += ` AND done_at IS NULL`
+	}
+	query += ` ORDER BY created_at DESC, id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := edb.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []Note
+	for rows.Next() {
+		var note Note
+		var contextID sql.NullInt64
+		var doneAt sql.NullTime
+		if err := rows.Scan(&note.ID, &note.Project, &note.Branch, &note.Commit, &note.Kind, &note.Body,
+			&contextID, &note.CreatedAt, &doneAt); err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		if contextID.Valid {
+			note.ContextID = &contextID.Int64
+		}
+		if doneAt.Valid {
+			note.DoneAt = &doneAt.Time
+		}
+		notes = append(notes, note)
+	}
+	return notes, rows.Err()
+}
+
+// MarkNoteDone marks an open note as done so it no longer shows up in pulls
+func (edb *EcosystemDB) MarkNoteDone(id int64) error {
+	result, err := edb.Exec(`UPDATE notes SET done_at = ? WHERE id = ? AND done_at IS NULL`, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark note done: %w", err)
+	}
+	return requireAffected(result, fmt.Sprintf("open note #%d", id))
+}
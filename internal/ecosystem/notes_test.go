@@ -0,0 +1,70 @@
+package ecosystem
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNotes(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "notes.sqlite"), false)
+
+	add := func(note Note) *Note {
+		t.Helper()
+		saved, err := db.AddNote(note)
+		if err != nil {
+			t.Fatalf("Failed to add note: %v", err)
+		}
+		return saved
+	}
+	first := add(Note{Project: "wherewasi", Branch: "main", Commit: "abc1234", Body: "Check the daemon shutdown"})
+	session, err := db.SaveContext("wherewasi", "context", "Checkpoint", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to save context: %v", err)
+	}
+	checkpoint := add(Note{Project: "wherewasi", Branch: "feature/x", Kind: NoteKindCheckpoint, Body: "Halfway through the parser", ContextID: &session.ID})
+	add(Note{Project: "uroboro", Body: "Unrelated"})
+
+	if first.Kind != NoteKindNote || first.ID == 0 {
+		t.Errorf("Expected a note kind and ID by default, got %+v", first)
+	}
+
+	notes, err := db.GetNotes(NoteFilter{Project: "wherewasi"})
+	if err != nil {
+		t.Fatalf("Failed to get notes: %v", err)
+	}
+	if len(notes) != 2 || notes[0].ID != checkpoint.ID || notes[1].ID != first.ID {
+		t.Fatalf("Expected both wherewasi notes newest first, got %+v", notes)
+	}
+	if notes[0].ContextID == nil || *notes[0].ContextID != session.ID || notes[1].Commit != "abc1234" {
+		t.Errorf("Expected the context link and commit to round-trip, got %+v", notes)
+	}
+
+	onBranch, err := db.GetNotes(NoteFilter{Project: "wherewasi", Branch: "main"})
+	if err != nil || len(onBranch) != 1 || onBranch[0].ID != first.ID {
+		t.Errorf("Expected only the main note, got %+v, %v", onBranch, err)
+	}
+
+	if err := db.MarkNoteDone(first.ID); err != nil {
+		t.Fatalf("Failed to mark note done: %v", err)
+	}
+	if err := db.MarkNoteDone(first.ID); err == nil {
+		t.Error("Expected marking a done note again to fail")
+	}
+	if err := db.MarkNoteDone(9999); err == nil {
+		t.Error("Expected marking a missing note to fail")
+	}
+
+	open, err := db.GetNotes(NoteFilter{Project: "wherewasi"})
+	if err != nil || len(open) != 1 || open[0].ID != checkpoint.ID {
+		t.Errorf("Expected only the checkpoint to remain open, got %+v, %v", open, err)
+	}
+	all, err := db.GetNotes(NoteFilter{IncludeDone: true, Limit: 10})
+	if err != nil || len(all) != 3 {
+		t.Fatalf("Expected every note with done included, got %+v, %v", all, err)
+	}
+	for _, note := range all {
+		if (note.ID == first.ID) != (note.DoneAt != nil) {
+			t.Errorf("Expected only note #%d to be done, got %+v", first.ID, note)
+		}
+	}
+}
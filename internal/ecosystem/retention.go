@@ -0,0 +1,275 @@
+package ecosystem
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// CompressionGzip stores context_data gzip-compressed. Compressed contexts are still listed,
+// shown and compared, but full-text search only covers their session info and keywords.
+const CompressionGzip = "gzip"
+
+// maintenanceVacuum is the maintenance_runs task recorded by Vacuum
+const maintenanceVacuum = "vacuum"
+
+// ContextPrune selects saved contexts to delete. Each set limit applies on its own: a context
+// is pruned when it is older than OlderThan or beyond the newest KeepPerProject of its project.
+// Contexts linked to open notes are always kept.
+type ContextPrune struct {
+	OlderThan      time.Time
+	KeepPerProject int
+	// DryRun counts the contexts that would be pruned without deleting them
+	DryRun bool
+}
+
+// VacuumResult is the database file size before and after a VACUUM
+type VacuumResult struct {
+	Before int64 `json:"before"`
+	After  int64 `json:"after"`
+}
+
+// Reclaimed is how many bytes the VACUUM freed
+func (r VacuumResult) Reclaimed() int64 {
+	return r.Before - r.After
+}
+
+func validateCompression(compression string) error {
+	switch compression {
+	case "", CompressionGzip:
+		return nil
+	}
+	return fmt.Errorf("unsupported context compression %q (supported: %s)", compression, CompressionGzip)
+}
+
+// contentHash identifies a context by its text
+func contentHash(contextData string) string {
+	sum := sha256.Sum256([]byte(contextData))
+	return hex.EncodeToString(sum[:])
+}
+
+// encodeContext returns the value stored in context_data and its context_encoding
+func encodeContext(contextData, compression string) (interface{}, string, error) {
+	if compression != CompressionGzip {
+		return contextData, "", nil
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(contextData)); err != nil {
+		return nil, "", fmt.Errorf("failed to compress context: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to compress context: %w", err)
+	}
+	return buf.Bytes(), CompressionGzip, nil
+}
+
+// decodeContext reverses encodeContext
+func decodeContext(data, encoding string) (string, error) {
+	switch encoding {
+	case "":
+		return data, nil
+	case CompressionGzip:
+		reader, err := gzip.NewReader(bytes.NewReader([]byte(data)))
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress context: %w", err)
+		}
+		defer reader.Close()
+		plain, err := io.ReadAll(reader)
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress context: %w", err)
+		}
+		return string(plain), nil
+	}
+	return "", fmt.Errorf("unknown context encoding %q", encoding)
+}
+
+// latestDuplicate returns the project's latest session when it holds the same context, or 0
+func latestDuplicate(tx *sql.Tx, project, hash, sessionInfo, keywords string) (int64, error) {
+	var id int64
+	var latestHash sql.NullString
+	var latestInfo, latestKeywords string
+	err := tx.QueryRow(`
+		SELECT id, content_hash, COALESCE(session_info, ''), COALESCE(keywords, '')
+		FROM context_sessions
+		WHERE project = ?
+		ORDER BY timestamp DESC, id DESC
+		LIMIT 1
+	`, project).Scan(&id, &latestHash, &latestInfo, &latestKeywords)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to check for duplicate context: %w", err)
+	}
+
+	if latestHash.String != hash || latestInfo != sessionInfo || latestKeywords != keywords {
+		return 0, nil
+	}
+	return id, nil
+}
+
+// PruneContexts deletes saved contexts past the retention limits, returning how many were
+// (or with DryRun, would be) deleted
+func (edb *EcosystemDB) PruneContexts(prune ContextPrune) (int, error) {
+	if prune.OlderThan.IsZero() && prune.KeepPerProject <= 0 {
+		return 0, errors.New("no retention limit given")
+	}
+
+	selection := `
+		SELECT id FROM (
+			SELECT id, timestamp,
+				ROW_NUMBER() OVER (PARTITION BY project ORDER BY timestamp DESC, id DESC) AS position
+			FROM context_sessions
+		)
+		WHERE (0`
+	var args []interface{}
+	if !prune.OlderThan.IsZero() {
+		selection += ` OR julianday(timestamp) < julianday(?)`
+		args = append(args, prune.OlderThan.UTC())
+	}
+	if prune.KeepPerProject > 0 {
+		selection += ` OR position > ?`
+		args = append(args, prune.KeepPerProject)
+	}
+	selection += `)
+		AND id NOT IN (
+			SELECT context_session_id FROM notes
+			WHERE done_at IS NULL AND context_session_id IS NOT NULL
+		)`
+
+	if prune.DryRun {
+		var count int
+		if err := edb.QueryRow(`SELECT COUNT(*) FROM (`+selection+`)`, args...).Scan(&count); err != nil {
+			return 0, fmt.Errorf("failed to count prunable contexts: %w", err)
+		}
+		return count, nil
+	}
+
+	result, err := edb.Exec(`DELETE FROM context_sessions WHERE id IN (`+selection+`)`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune contexts: %w", err)
+	}
+	pruned, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check affected rows: %w", err)
+	}
+	return int(pruned), nil
+}
+
+// CompressContexts gzip-compresses every saved context that is stored as plain text
+func (edb *EcosystemDB) CompressContexts() (int, error) {
+	rows, err := edb.Query(`SELECT id, context_data FROM context_sessions WHERE context_encoding IS NULL`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list uncompressed contexts: %w", err)
+	}
+	plain := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var data string
+		if err := rows.Scan(&id, &data); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan context: %w", err)
+		}
+		plain[id] = data
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list uncompressed contexts: %w", err)
+	}
+
+	tx, err := edb.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for id, data := range plain {
+		compressed, encoding, err := encodeContext(data, CompressionGzip)
+		if err != nil {
+			return 0, err
+		}
+		// Contexts saved before deduplication get their hash on the way
+		_, err = tx.Exec(`
+			UPDATE context_sessions
+			SET context_data = ?, context_encoding = ?, content_hash = COALESCE(content_hash, ?)
+			WHERE id = ?
+		`, compressed, encoding, contentHash(data), id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to compress context #%d: %w", id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to compress contexts: %w", err)
+	}
+	return len(plain), nil
+}
+
+// Vacuum rebuilds the database file to return space freed by deletions to the filesystem
+func (edb *EcosystemDB) Vacuum() (*VacuumResult, error) {
+	before, err := edb.fileSize()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := edb.Exec(`VACUUM`); err != nil {
+		return nil, fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	after, err := edb.fileSize()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = edb.Exec(`
+		INSERT INTO maintenance_runs (task, ran_at) VALUES (?, ?)
+		ON CONFLICT(task) DO UPDATE SET ran_at = excluded.ran_at
+	`, maintenanceVacuum, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to record vacuum: %w", err)
+	}
+	return &VacuumResult{Before: before, After: after}, nil
+}
+
+// VacuumIfDue runs Vacuum when it last ran more than interval ago, returning nil when it was not due
+func (edb *EcosystemDB) VacuumIfDue(interval time.Duration) (*VacuumResult, error) {
+	var due bool
+	err := edb.QueryRow(`
+		SELECT COALESCE(MAX(julianday(ran_at)) < julianday(?), 1)
+		FROM maintenance_runs WHERE task = ?
+	`, time.Now().Add(-interval).UTC(), maintenanceVacuum).Scan(&due)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check last vacuum: %w", err)
+	}
+	if !due {
+		return nil, nil
+	}
+	return edb.Vacuum()
+}
+
+// fileSize is the size of the database including its write-ahead log, after checkpointing
+func (edb *EcosystemDB) fileSize() (int64, error) {
+	if _, err := edb.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return 0, fmt.Errorf("failed to checkpoint database: %w", err)
+	}
+
+	var size int64
+	for _, path := range []string{edb.dbPath, edb.dbPath + "-wal"} {
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to check database size: %w", err)
+		}
+		size += info.Size()
+	}
+	return size, nil
+}
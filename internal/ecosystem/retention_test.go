@@ -0,0 +1,160 @@
+package ecosystem
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveContextDeduplicates(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "dedup.sqlite"), false)
+
+	first, err := db.SaveContext("wherewasi", "same context", "Context pull", "", &GitState{Branch: "main"})
+	if err != nil {
+		t.Fatalf("Failed to save context: %v", err)
+	}
+	again, err := db.SaveContext("wherewasi", "same context", "Context pull", "", &GitState{Branch: "main", Dirty: true})
+	if err != nil {
+		t.Fatalf("Failed to save context: %v", err)
+	}
+	if again.ID != first.ID {
+		t.Errorf("Expected the repeated pull to refresh #%d, got #%d", first.ID, again.ID)
+	}
+	stored, err := db.GetContext(first.ID)
+	if err != nil || stored == nil || !stored.GitDirty || stored.Timestamp.Before(first.Timestamp) {
+		t.Errorf("Expected the refreshed session to carry the new git state, got %+v, %v", stored, err)
+	}
+
+	// Different text, session info or project, or an older duplicate, are stored separately
+	for _, save := range []struct{ project, data, info string }{
+		{"wherewasi", "same context", "Checkpoint: parser"},
+		{"uroboro", "same context", "Context pull"},
+		{"wherewasi", "changed context", "Context pull"},
+		{"wherewasi", "same context", "Context pull"},
+	} {
+		if _, err := db.SaveContext(save.project, save.data, save.info, "", nil); err != nil {
+			t.Fatalf("Failed to save context: %v", err)
+		}
+	}
+	sessions, err := db.ListContexts(ContextSearch{})
+	if err != nil || len(sessions) != 5 {
+		t.Errorf("Expected 5 stored contexts, got %d, %v", len(sessions), err)
+	}
+}
+
+func TestContextCompression(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "compressed.sqlite")
+	if _, err := NewEcosystemDB(DatabaseConfig{ToolName: ToolWherewasi, FallbackPath: path, ForceLocal: true, Compression: "zstd"}); err == nil {
+		t.Error("Expected an unsupported compression to be rejected")
+	}
+	db, err := NewEcosystemDB(DatabaseConfig{ToolName: ToolWherewasi, FallbackPath: path, ForceLocal: true, Compression: CompressionGzip})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	text := strings.Repeat("Pulled the ripcord on the parser\n", 50)
+	compressed, err := db.SaveContext("wherewasi", text, "Context pull", "parser", nil)
+	if err != nil {
+		t.Fatalf("Failed to save context: %v", err)
+	}
+	var encoding string
+	var size int
+	if err := db.QueryRow(`SELECT context_encoding, length(context_data) FROM context_sessions WHERE id = ?`, compressed.ID).Scan(&encoding, &size); err != nil {
+		t.Fatalf("Failed to read stored context: %v", err)
+	}
+	if encoding != CompressionGzip || size >= len(text) {
+		t.Errorf("Expected a gzip-compressed context smaller than %d bytes, got %s %d", len(text), encoding, size)
+	}
+
+	stored, err := db.GetContext(compressed.ID)
+	if err != nil || stored == nil || stored.ContextData != text {
+		t.Fatalf("Expected the context to decompress, got %+v, %v", stored, err)
+	}
+	if matches, err := db.SearchContexts(ContextSearch{Query: "parser"}); err != nil || len(matches) != 1 {
+		t.Errorf("Expected compressed contexts to match on keywords, got %+v, %v", matches, err)
+	}
+
+	plain := openTestDB(t, filepath.Join(t.TempDir(), "plain.sqlite"), false)
+	if _, err := plain.SaveContext("wherewasi", text, "Context pull", "", nil); err != nil {
+		t.Fatalf("Failed to save context: %v", err)
+	}
+	count, err := plain.CompressContexts()
+	if err != nil || count != 1 {
+		t.Fatalf("Expected one context compressed, got %d, %v", count, err)
+	}
+	sessions, err := plain.ListContexts(ContextSearch{})
+	if err != nil || len(sessions) != 1 || sessions[0].ContextData != text {
+		t.Errorf("Expected the compressed context to read back unchanged, got %+v, %v", sessions, err)
+	}
+}
+
+func TestPruneContexts(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "prune.sqlite"), false)
+
+	age := func(id int64, d time.Duration) {
+		t.Helper()
+		if _, err := db.Exec(`UPDATE context_sessions SET timestamp = ? WHERE id = ?`, time.Now().Add(-d).UTC(), id); err != nil {
+			t.Fatalf("Failed to age context: %v", err)
+		}
+	}
+	var ids []int64
+	for i := 0; i < 4; i++ {
+		session, err := db.SaveContext("wherewasi", strings.Repeat("x", i+1), "Context pull", "", nil)
+		if err != nil {
+			t.Fatalf("Failed to save context: %v", err)
+		}
+		age(session.ID, time.Duration(4-i)*time.Hour)
+		ids = append(ids, session.ID)
+	}
+	old, err := db.SaveContext("uroboro", "ancient", "Context pull", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to save context: %v", err)
+	}
+	age(old.ID, 100*24*time.Hour)
+	if _, err := db.AddNote(Note{Project: "wherewasi", Kind: NoteKindCheckpoint, Body: "keep me", ContextID: &ids[0]}); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	if _, err := db.PruneContexts(ContextPrune{}); err == nil {
+		t.Error("Expected a prune without limits to be rejected")
+	}
+
+	prune := ContextPrune{OlderThan: time.Now().Add(-90 * 24 * time.Hour), KeepPerProject: 2, DryRun: true}
+	count, err := db.PruneContexts(prune)
+	if err != nil || count != 2 {
+		t.Fatalf("Expected 2 prunable contexts, got %d, %v", count, err)
+	}
+	if sessions, _ := db.ListContexts(ContextSearch{}); len(sessions) != 5 {
+		t.Errorf("Expected a dry run to keep every context, got %d", len(sessions))
+	}
+
+	prune.DryRun = false
+	if count, err := db.PruneContexts(prune); err != nil || count != 2 {
+		t.Fatalf("Expected 2 pruned contexts, got %d, %v", count, err)
+	}
+	sessions, err := db.ListContexts(ContextSearch{})
+	if err != nil {
+		t.Fatalf("Failed to list contexts: %v", err)
+	}
+	kept := make(map[int64]bool)
+	for _, session := range sessions {
+		kept[session.ID] = true
+	}
+	// The oldest wherewasi context is linked to an open checkpoint; the second oldest is past the limit
+	if len(sessions) != 3 || !kept[ids[0]] || kept[ids[1]] || kept[old.ID] {
+		t.Errorf("Expected contexts %d, %d and %d to remain, got %v", ids[0], ids[2], ids[3], kept)
+	}
+	if matches, err := db.SearchContexts(ContextSearch{Query: "ancient"}); err != nil || len(matches) != 0 {
+		t.Errorf("Expected pruned contexts to leave the search index, got %+v, %v", matches, err)
+	}
+
+	result, err := db.VacuumIfDue(24 * time.Hour)
+	if err != nil || result == nil || result.After <= 0 {
+		t.Fatalf("Expected the first vacuum to run, got %+v, %v", result, err)
+	}
+	if result, err := db.VacuumIfDue(24 * time.Hour); err != nil || result != nil {
+		t.Errorf("Expected the vacuum not to be due again, got %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,154 @@
+package ecosystem
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultSearchLimit is how many history matches are returned when no limit is given
+const DefaultSearchLimit = 10
+
+// Snippet markers around the matched terms in ContextMatch.Snippet
+const (
+	SnippetMatchStart = "**"
+	SnippetMatchEnd   = "**"
+)
+
+// ContextSearch is a full-text search over saved contexts
+type ContextSearch struct {
+	// Query supports "exact phrases", prefix* terms and AND/OR/NOT
+	Query   string
+	Project string
+	Branch  string
+	// Since keeps contexts saved at or after this time when set
+	Since time.Time
+	Limit int
+}
+
+// ContextMatch is a saved context matching a search with the best matching excerpt
+type ContextMatch struct {
+	ContextSession
+	Snippet string `json:"snippet"`
+	// Rank is the bm25 score; lower is a better match
+	Rank float64 `json:"rank"`
+}
+
+// SearchContexts runs a ranked full-text search over saved contexts, best matches first.
+// Keywords weigh more than the session info, which weighs more than the context text.
+func (edb *EcosystemDB) SearchContexts(search ContextSearch) ([]ContextMatch, error) {
+	match, err := ftsQuery(search.Query)
+	if err != nil {
+		return nil, err
+	}
+	limit := search.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+
+	query := `
+		SELECT ` + contextSessionColumns + `, matches.snippet, matches.rank
+		FROM context_sessions
+		JOIN (
+			SELECT rowid,
+				snippet(context_sessions_fts, -1, ?, ?, '…', 16) AS snippet,
+				bm25(context_sessions_fts, 1.0, 2.0, 4.0) AS rank
+			FROM context_sessions_fts
+			WHERE context_sessions_fts MATCH ?
+		) AS matches ON matches.rowid = context_sessions.id
+		WHERE 1 = 1`
+	args := []interface{}{SnippetMatchStart, SnippetMatchEnd, match}
+	if search.Project != "" {
+		query += ` AND project = ?`
+		args = append(args, search.Project)
+	}
+	if search.Branch != "" {
+		query += ` AND git_branch = ?`
+		args = append(args, search.Branch)
+	}
+	if !search.Since.IsZero() {
+		query += ` AND julianday(timestamp) >= julianday(?)`
+		args = append(args, search.Since.UTC())
+	}
+	query += `
+		ORDER BY matches.rank, timestamp DESC
+		LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := edb.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search contexts: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []ContextMatch
+	for rows.Next() {
+		var result ContextMatch
+		if err := scanContextSession(rows, &result.ContextSession, &result.Snippet, &result.Rank); err != nil {
+			return nil, err
+		}
+		result.Snippet = strings.Join(strings.Fields(result.Snippet), " ")
+		matches = append(matches, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search contexts: %w", err)
+	}
+	return matches, nil
+}
+
+// ftsQuery turns a search into an FTS5 expression. Phrases, prefix* terms and the AND, OR and
+// NOT operators pass through; every other term is quoted so punctuation such as main.go or
+// feature/x is searched literally instead of failing as FTS5 syntax.
+func ftsQuery(query string) (string, error) {
+	var terms []string
+	for rest := strings.TrimSpace(query); rest != ""; rest = strings.TrimSpace(rest) {
+		var term string
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				// An unclosed phrase runs to the end of the query
+				term, rest = rest[1:], ""
+			} else {
+				term, rest = rest[1:end+1], rest[end+2:]
+			}
+			prefix := strings.HasPrefix(rest, "*")
+			rest = strings.TrimPrefix(rest, "*")
+			if quoted := quoteTerm(term, prefix); quoted != "" {
+				terms = append(terms, quoted)
+			}
+			continue
+		}
+
+		if end := strings.IndexAny(rest, " \t\n\""); end >= 0 {
+			term, rest = rest[:end], rest[end:]
+		} else {
+			term, rest = rest, ""
+		}
+		switch term {
+		case "AND", "OR", "NOT":
+			terms = append(terms, term)
+		default:
+			if quoted := quoteTerm(strings.TrimSuffix(term, "*"), strings.HasSuffix(term, "*")); quoted != "" {
+				terms = append(terms, quoted)
+			}
+		}
+	}
+
+	if len(terms) == 0 {
+		return "", errors.New("empty search query")
+	}
+	return strings.Join(terms, " "), nil
+}
+
+// quoteTerm quotes a term as an FTS5 string, optionally as a prefix
+func quoteTerm(term string, prefix bool) string {
+	if strings.TrimSpace(term) == "" {
+		return ""
+	}
+	quoted := `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	if prefix {
+		quoted += "*"
+	}
+	return quoted
+}
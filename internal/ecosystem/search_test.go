@@ -0,0 +1,131 @@
+package ecosystem
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFTSQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "ripcord", want: `"ripcord"`},
+		{query: "ripc*", want: `"ripc"*`},
+		{query: `"exact phrase" other`, want: `"exact phrase" "other"`},
+		{query: `"prefix phra"*`, want: `"prefix phra"*`},
+		{query: "whisper OR ripcord NOT uroboro", want: `"whisper" OR "ripcord" NOT "uroboro"`},
+		{query: "main.go feature/x", want: `"main.go" "feature/x"`},
+		{query: `"unclosed phrase`, want: `"unclosed phrase"`},
+	}
+	for _, tt := range tests {
+		got, err := ftsQuery(tt.query)
+		if err != nil || got != tt.want {
+			t.Errorf("ftsQuery(%q) = %q, %v; want %q", tt.query, got, err, tt.want)
+		}
+	}
+
+	if _, err := ftsQuery(` "" `); err == nil {
+		t.Error("Expected an empty query to be rejected")
+	}
+}
+
+func TestSearchContexts(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "search.sqlite"), false)
+
+	save := func(project, data, info, keywords string, git *GitState) *ContextSession {
+		t.Helper()
+		session, err := db.SaveContext(project, data, info, keywords, git)
+		if err != nil {
+			t.Fatalf("Failed to save context: %v", err)
+		}
+		return session
+	}
+	save("wherewasi", "Pulled the ripcord while fixing the daemon", "Context pull", "", nil)
+	save("wherewasi", "Daemon notes only", "Context pull", "ripcord", &GitState{Branch: "feature/x"})
+	save("uroboro", "Capture pipeline, nothing about parachutes", "Context pull", "", nil)
+	old := save("miqro", "Whisper transcription and the ripcord idea", "Context pull", "", nil)
+	if _, err := db.Exec(`UPDATE context_sessions SET timestamp = ? WHERE id = ?`, time.Now().Add(-30*24*time.Hour).UTC(), old.ID); err != nil {
+		t.Fatalf("Failed to age context: %v", err)
+	}
+
+	search := func(s ContextSearch) []ContextMatch {
+		t.Helper()
+		matches, err := db.SearchContexts(s)
+		if err != nil {
+			t.Fatalf("Search %+v failed: %v", s, err)
+		}
+		return matches
+	}
+
+	t.Run("Ranking", func(t *testing.T) {
+		matches := search(ContextSearch{Query: "ripcord"})
+		if len(matches) != 3 {
+			t.Fatalf("Expected 3 ripcord matches, got %d", len(matches))
+		}
+		// Keywords weigh more than the context text
+		if matches[0].Keywords != "ripcord" {
+			t.Errorf("Expected the keyword match to rank first, got %q", matches[0].ContextData)
+		}
+		for i := 1; i < len(matches); i++ {
+			if matches[i].Rank < matches[i-1].Rank {
+				t.Errorf("Expected matches ordered by rank, got %v then %v", matches[i-1].Rank, matches[i].Rank)
+			}
+		}
+	})
+
+	t.Run("Snippet", func(t *testing.T) {
+		matches := search(ContextSearch{Query: "transcription"})
+		if len(matches) != 1 || !strings.Contains(matches[0].Snippet, SnippetMatchStart+"transcription"+SnippetMatchEnd) {
+			t.Errorf("Expected a highlighted snippet, got %+v", matches)
+		}
+	})
+
+	t.Run("Syntax", func(t *testing.T) {
+		if matches := search(ContextSearch{Query: `"ripcord idea"`}); len(matches) != 1 || matches[0].Project != "miqro" {
+			t.Errorf("Expected the phrase to match only miqro, got %d", len(matches))
+		}
+		if matches := search(ContextSearch{Query: "parach*"}); len(matches) != 1 {
+			t.Errorf("Expected the prefix to match parachutes, got %d", len(matches))
+		}
+		if matches := search(ContextSearch{Query: "ripcord NOT daemon"}); len(matches) != 1 || matches[0].Project != "miqro" {
+			t.Errorf("Expected NOT to exclude the daemon contexts, got %d", len(matches))
+		}
+		if matches := search(ContextSearch{Query: "whisper OR capture"}); len(matches) != 2 {
+			t.Errorf("Expected OR to match both, got %d", len(matches))
+		}
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		if matches := search(ContextSearch{Query: "ripcord", Project: "wherewasi"}); len(matches) != 2 {
+			t.Errorf("Expected 2 wherewasi matches, got %d", len(matches))
+		}
+		if matches := search(ContextSearch{Query: "ripcord", Branch: "feature/x"}); len(matches) != 1 {
+			t.Errorf("Expected 1 match on feature/x, got %d", len(matches))
+		}
+		if matches := search(ContextSearch{Query: "ripcord", Since: time.Now().Add(-24 * time.Hour)}); len(matches) != 2 {
+			t.Errorf("Expected the month-old context to be filtered out, got %d", len(matches))
+		}
+		if matches := search(ContextSearch{Query: "ripcord", Limit: 1}); len(matches) != 1 {
+			t.Errorf("Expected the limit to apply, got %d", len(matches))
+		}
+	})
+
+	t.Run("Triggers", func(t *testing.T) {
+		if _, err := db.Exec(`UPDATE context_sessions SET keywords = 'skydive' WHERE id = ?`, old.ID); err != nil {
+			t.Fatalf("Failed to update context: %v", err)
+		}
+		if matches := search(ContextSearch{Query: "skydive"}); len(matches) != 1 {
+			t.Errorf("Expected the updated keywords to be indexed, got %d", len(matches))
+		}
+
+		if _, err := db.Exec(`DELETE FROM context_sessions WHERE id = ?`, old.ID); err != nil {
+			t.Fatalf("Failed to delete context: %v", err)
+		}
+		if matches := search(ContextSearch{Query: "whisper OR skydive"}); len(matches) != 0 {
+			t.Errorf("Expected deleted contexts to leave the index, got %d", len(matches))
+		}
+	})
+}
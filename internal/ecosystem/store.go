@@ -0,0 +1,68 @@
+package ecosystem
+
+import "time"
+
+// ContextStore is the persistence wherewasi needs for context sessions, notes, project tracking,
+// activity, the search index and database maintenance. EcosystemDB implements it against both the
+// shared ecosystem database and the local fallback.
+type ContextStore interface {
+	SaveContext(project, contextData, sessionInfo, keywords string, git *GitState) (*ContextSession, error)
+	GetRecentContexts(project string, limit int) ([]ContextSession, error)
+	GetRecentContextsOnBranch(project, branch string, limit int) ([]ContextSession, error)
+	SearchStoredContexts(keyword string) ([]ContextSession, error)
+	SearchContexts(search ContextSearch) ([]ContextMatch, error)
+	ListContexts(search ContextSearch) ([]ContextSession, error)
+	GetContext(id int64) (*ContextSession, error)
+	DeleteContext(id int64) error
+	PruneContexts(prune ContextPrune) (int, error)
+	CompressContexts() (int, error)
+
+	AddNote(note Note) (*Note, error)
+	GetNotes(filter NoteFilter) ([]Note, error)
+	MarkNoteDone(id int64) error
+
+	TrackProject(name, path, primaryTool string, isGitRepo bool) error
+	GetProjects() ([]*Project, error)
+	GetRecentProjects(limit int) ([]*Project, error)
+	GetProject(name string) (*Project, error)
+	SetProjectDescription(name, description string) error
+	RemoveProject(name string) error
+	AddProjectAlias(alias, project string) error
+	RemoveProjectAlias(alias string) error
+	ResolveProjectAlias(alias string) (string, error)
+	GetProjectAliases() (map[string][]string, error)
+
+	RecordProjectActivity(activity ProjectActivityMessageData) error
+	GetProjectActivity(project string, since time.Time, limit int) ([]*ToolMessage, error)
+	RecordActivityEvents(events []ActivityEvent) error
+	GetRecentEdits(project string, since time.Time, limit int) ([]FileEditSummary, error)
+
+	UpdateFileIndex(update FileIndexUpdate) error
+	MarkFileIndexed(project, root, tree string) error
+	IndexedFiles(project string) ([]IndexedFile, error)
+	FindIndexedFiles(project string, substrings []string) ([]string, error)
+	IndexedProjects() ([]IndexedProject, error)
+	ClearFileIndex(project string) error
+
+	TrackUsage(tool, command, project, sessionID string, durationMs int, success bool, errorMsg string) error
+	GetUsage(tool string, since time.Time) ([]UsageRecord, error)
+	SendToolMessage(fromTool, toTool, messageType, data string) error
+
+	Export(filter ExportFilter) (*Export, error)
+	Import(export *Export, opts ImportOptions) (*ImportResult, error)
+	Backup(dst string) error
+
+	MigrationStatus(tool string) ([]MigrationStatus, error)
+	PendingMigrations(tool string) ([]Migration, error)
+	Migrate(tool string) ([]Migration, error)
+	Vacuum() (*VacuumResult, error)
+	VacuumIfDue(interval time.Duration) (*VacuumResult, error)
+
+	// DatabasePath, IsShared and LegacyImport describe how the store was opened
+	DatabasePath() string
+	IsShared() bool
+	LegacyImport() *LegacyImport
+	Close() error
+}
+
+var _ ContextStore = (*EcosystemDB)(nil)
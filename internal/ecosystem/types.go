@@ -29,6 +29,21 @@ const (
 	MessageTypePomodoroComplete  = "pomodoro_complete"
 )
 
+// Activity kinds recorded in project_activity messages
+const (
+	ActivityCommit       = "commit"
+	ActivityBranchSwitch = "branch_switch"
+	ActivityMerge        = "merge"
+)
+
+// Event kinds recorded in the activity journal
+const (
+	EventKindCreate = "create"
+	EventKindWrite  = "write"
+	EventKindRemove = "remove"
+	EventKindRename = "rename"
+)
+
 // Insight types for ecosystem intelligence
 const (
 	InsightTypeStudyRecommendation = "study_recommendation"
@@ -87,9 +102,61 @@ type ContextSession struct {
 	Keywords    string    `json:"keywords"`
 	GitBranch   *string   `json:"git_branch"`
 	GitCommit   *string   `json:"git_commit"`
+	GitDirty    bool      `json:"git_dirty"`
+	GitAhead    *int      `json:"git_ahead"`
+	GitBehind   *int      `json:"git_behind"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
+// GitState captures the repository state at the time a context is saved
+type GitState struct {
+	Branch      string `json:"branch"`
+	Commit      string `json:"commit"`
+	Dirty       bool   `json:"dirty"`
+	HasUpstream bool   `json:"has_upstream"`
+	Ahead       int    `json:"ahead"`
+	Behind      int    `json:"behind"`
+}
+
+// ActivityEvent represents a single journaled file event
+type ActivityEvent struct {
+	ID        int64     `json:"id"`
+	Project   string    `json:"project"`
+	Path      string    `json:"path"`
+	Kind      string    `json:"event_kind"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// FileEditSummary aggregates journaled events for one file
+type FileEditSummary struct {
+	Path     string    `json:"path"`
+	LastKind string    `json:"last_kind"`
+	LastEdit time.Time `json:"last_edit"`
+	Events   int       `json:"events"`
+}
+
+// String renders a git state as "branch @ sha (dirty, ↑ahead ↓behind)"
+func (g *GitState) String() string {
+	commit := g.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+
+	var flags []string
+	if g.Dirty {
+		flags = append(flags, "dirty")
+	}
+	if g.HasUpstream {
+		flags = append(flags, fmt.Sprintf("↑%d ↓%d", g.Ahead, g.Behind))
+	}
+
+	result := fmt.Sprintf("%s @ %s", g.Branch, commit)
+	if len(flags) > 0 {
+		result += fmt.Sprintf(" (%s)", strings.Join(flags, ", "))
+	}
+	return result
+}
+
 // Capture represents a uroboro content capture
 type Capture struct {
 	ID               int64      `json:"id"`
@@ -166,6 +233,8 @@ type ProjectActivityMessageData struct {
 	Project     string `json:"project"`
 	Activity    string `json:"activity"`
 	Tool        string `json:"tool"`
+	Detail      string `json:"detail,omitempty"`
+	Files       []string `json:"files,omitempty"`
 	GitBranch   *string `json:"git_branch,omitempty"`
 	GitCommit   *string `json:"git_commit,omitempty"`
 }
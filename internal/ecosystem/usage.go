@@ -0,0 +1,217 @@
+package ecosystem
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// UsageSectionPrefix marks usage records that time a single context section, e.g. "section:commits"
+const UsageSectionPrefix = "section:"
+
+// UsageRecord is one row of usage_stats
+type UsageRecord struct {
+	ID           int64         `json:"id"`
+	Tool         string        `json:"tool"`
+	Command      string        `json:"command"`
+	Project      string        `json:"project,omitempty"`
+	SessionID    string        `json:"session_id,omitempty"`
+	Duration     time.Duration `json:"duration"`
+	Success      bool          `json:"success"`
+	ErrorMessage string        `json:"error_message,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// GetUsage lists a tool's usage records since a time (all when zero), oldest first
+func (edb *EcosystemDB) GetUsage(tool string, since time.Time) ([]UsageRecord, error) {
+	query := `
+		SELECT id, tool, command, COALESCE(project, ''), COALESCE(session_id, ''), COALESCE(duration_ms, 0),
+			success, COALESCE(error_message, ''), created_at
+		FROM usage_stats
+		WHERE tool = ?`
+	args := []interface{}{tool}
+	if !since.IsZero() {
+		query += ` AND julianday(created_at) >= julianday(?)`
+		args = append(args, since.UTC())
+	}
+	query += ` ORDER BY created_at, id`
+
+	rows, err := edb.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	defer rows.Close()
+
+	var records []UsageRecord
+	for rows.Next() {
+		var record UsageRecord
+		var durationMs int64
+		var success sql.NullBool
+		if err := rows.Scan(&record.ID, &record.Tool, &record.Command, &record.Project, &record.SessionID,
+			&durationMs, &success, &record.ErrorMessage, &record.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		record.Duration = time.Duration(durationMs) * time.Millisecond
+		// success defaults to true in the schema
+		record.Success = !success.Valid || success.Bool
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// DurationStats summarizes a set of durations
+type DurationStats struct {
+	Count int           `json:"count"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// NewDurationStats computes nearest-rank percentiles over durations
+func NewDurationStats(durations []time.Duration) DurationStats {
+	if len(durations) == 0 {
+		return DurationStats{}
+	}
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	percentile := func(p int) time.Duration {
+		rank := (p*len(sorted) + 99) / 100
+		if rank < 1 {
+			rank = 1
+		}
+		return sorted[rank-1]
+	}
+	return DurationStats{
+		Count: len(sorted),
+		P50:   percentile(50),
+		P90:   percentile(90),
+		P99:   percentile(99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// CommandStats counts runs and failures of one command
+type CommandStats struct {
+	Command   string `json:"command"`
+	Runs      int    `json:"runs"`
+	Failures  int    `json:"failures"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// FailureRate is the share of runs that failed, from 0 to 1
+func (c CommandStats) FailureRate() float64 {
+	if c.Runs == 0 {
+		return 0
+	}
+	return float64(c.Failures) / float64(c.Runs)
+}
+
+// SectionStats is how long one context section takes to build
+type SectionStats struct {
+	Name     string        `json:"name"`
+	Mean     time.Duration `json:"mean"`
+	Failures int           `json:"failures"`
+	DurationStats
+}
+
+// ProjectCount is how often a project was pulled
+type ProjectCount struct {
+	Project string `json:"project"`
+	Count   int    `json:"count"`
+}
+
+// UsageSummary aggregates usage records for wherewasi stats
+type UsageSummary struct {
+	// Pulls are the latencies of successful runs of the pull command
+	Pulls DurationStats `json:"pulls"`
+	// PullsWithin counts successful pulls at or under the target passed to SummarizeUsage
+	PullsWithin int `json:"pulls_within"`
+	// Projects are the most pulled projects first
+	Projects []ProjectCount `json:"projects"`
+	// Commands are ordered by failures, then runs
+	Commands []CommandStats `json:"commands"`
+	// Sections are ordered slowest first by mean duration
+	Sections []SectionStats `json:"sections"`
+}
+
+// SummarizeUsage aggregates records of pullCommand runs, all commands and section timings
+func SummarizeUsage(records []UsageRecord, pullCommand string, target time.Duration) UsageSummary {
+	var summary UsageSummary
+	var pulls []time.Duration
+	pulledProjects := make(map[string]int)
+	commands := make(map[string]*CommandStats)
+	sections := make(map[string][]UsageRecord)
+
+	for _, record := range records {
+		if name, ok := strings.CutPrefix(record.Command, UsageSectionPrefix); ok {
+			sections[name] = append(sections[name], record)
+			continue
+		}
+
+		stats := commands[record.Command]
+		if stats == nil {
+			stats = &CommandStats{Command: record.Command}
+			commands[record.Command] = stats
+		}
+		stats.Runs++
+		if !record.Success {
+			stats.Failures++
+			stats.LastError = record.ErrorMessage
+		}
+
+		if record.Command == pullCommand && record.Success {
+			pulls = append(pulls, record.Duration)
+			if record.Duration <= target {
+				summary.PullsWithin++
+			}
+			if record.Project != "" {
+				pulledProjects[record.Project]++
+			}
+		}
+	}
+	summary.Pulls = NewDurationStats(pulls)
+
+	for project, count := range pulledProjects {
+		summary.Projects = append(summary.Projects, ProjectCount{Project: project, Count: count})
+	}
+	sort.Slice(summary.Projects, func(i, j int) bool {
+		a, b := summary.Projects[i], summary.Projects[j]
+		return a.Count > b.Count || a.Count == b.Count && a.Project < b.Project
+	})
+
+	for _, stats := range commands {
+		summary.Commands = append(summary.Commands, *stats)
+	}
+	sort.Slice(summary.Commands, func(i, j int) bool {
+		a, b := summary.Commands[i], summary.Commands[j]
+		if a.Failures != b.Failures {
+			return a.Failures > b.Failures
+		}
+		return a.Runs > b.Runs || a.Runs == b.Runs && a.Command < b.Command
+	})
+
+	for name, runs := range sections {
+		durations := make([]time.Duration, 0, len(runs))
+		var total time.Duration
+		stats := SectionStats{Name: name}
+		for _, run := range runs {
+			durations = append(durations, run.Duration)
+			total += run.Duration
+			if !run.Success {
+				stats.Failures++
+			}
+		}
+		stats.DurationStats = NewDurationStats(durations)
+		stats.Mean = total / time.Duration(len(runs))
+		summary.Sections = append(summary.Sections, stats)
+	}
+	sort.Slice(summary.Sections, func(i, j int) bool {
+		a, b := summary.Sections[i], summary.Sections[j]
+		return a.Mean > b.Mean || a.Mean == b.Mean && a.Name < b.Name
+	})
+	return summary
+}
@@ -0,0 +1,72 @@
+package ecosystem
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewDurationStats(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	stats := NewDurationStats(durations)
+	want := DurationStats{Count: 100, P50: 50 * time.Millisecond, P90: 90 * time.Millisecond, P99: 99 * time.Millisecond, Max: 100 * time.Millisecond}
+	if stats != want {
+		t.Errorf("Expected %+v, got %+v", want, stats)
+	}
+	if single := NewDurationStats([]time.Duration{time.Second}); single.P50 != time.Second || single.P99 != time.Second {
+		t.Errorf("Expected every percentile of one run to be that run, got %+v", single)
+	}
+	if empty := NewDurationStats(nil); empty != (DurationStats{}) {
+		t.Errorf("Expected empty stats, got %+v", empty)
+	}
+}
+
+func TestUsageSummary(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "usage.sqlite"), false)
+
+	track := func(command, project, session string, duration time.Duration, errorMsg string) {
+		t.Helper()
+		if err := db.TrackUsage(ToolWherewasi, command, project, session, int(duration.Milliseconds()), errorMsg == "", errorMsg); err != nil {
+			t.Fatalf("Failed to track usage: %v", err)
+		}
+	}
+	track("pull", "wherewasi", "a", 800*time.Millisecond, "")
+	track(UsageSectionPrefix+"commits", "wherewasi", "a", 300*time.Millisecond, "")
+	track(UsageSectionPrefix+"search", "wherewasi", "a", 400*time.Millisecond, "")
+	track("pull", "wherewasi", "b", 12*time.Second, "")
+	track(UsageSectionPrefix+"commits", "wherewasi", "b", 100*time.Millisecond, "")
+	track(UsageSectionPrefix+"search", "wherewasi", "b", 11*time.Second, "timed out")
+	track("pull", "uroboro", "c", 2*time.Second, "")
+	track("pull", "uroboro", "d", time.Second, "exit status 1")
+	track("note", "wherewasi", "e", 10*time.Millisecond, "")
+	if err := db.TrackUsage(ToolUroboro, "capture", "", "", 5, true, ""); err != nil {
+		t.Fatalf("Failed to track usage: %v", err)
+	}
+
+	records, err := db.GetUsage(ToolWherewasi, time.Now().Add(-time.Hour))
+	if err != nil || len(records) != 9 {
+		t.Fatalf("Expected 9 wherewasi records, got %d, %v", len(records), err)
+	}
+	if future, err := db.GetUsage(ToolWherewasi, time.Now().Add(time.Hour)); err != nil || len(future) != 0 {
+		t.Errorf("Expected no records in the future, got %d, %v", len(future), err)
+	}
+
+	summary := SummarizeUsage(records, "pull", 10*time.Second)
+	if summary.Pulls.Count != 3 || summary.PullsWithin != 2 || summary.Pulls.Max != 12*time.Second || summary.Pulls.P50 != 2*time.Second {
+		t.Errorf("Expected 3 successful pulls, 2 within target, got %+v within %d", summary.Pulls, summary.PullsWithin)
+	}
+	if len(summary.Projects) != 2 || summary.Projects[0] != (ProjectCount{Project: "wherewasi", Count: 2}) {
+		t.Errorf("Expected wherewasi as the most pulled project, got %+v", summary.Projects)
+	}
+	if len(summary.Commands) != 2 || summary.Commands[0].Command != "pull" || summary.Commands[0].Runs != 4 ||
+		summary.Commands[0].Failures != 1 || summary.Commands[0].LastError != "exit status 1" || summary.Commands[0].FailureRate() != 0.25 {
+		t.Errorf("Expected pull failures first, got %+v", summary.Commands)
+	}
+	if len(summary.Sections) != 2 || summary.Sections[0].Name != "search" || summary.Sections[0].Failures != 1 ||
+		summary.Sections[0].Mean != 5700*time.Millisecond || summary.Sections[1].Count != 2 {
+		t.Errorf("Expected search as the slowest section, got %+v", summary.Sections)
+	}
+}
@@ -0,0 +1,307 @@
+package gitinfo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is a parsed commit object
+type Commit struct {
+	SHA     string
+	Parents []string
+	Author  string
+	// AuthorEmail and AuthorDate come from the author line; Date is the committer date,
+	// which git uses for ordering and --since
+	AuthorEmail string
+	AuthorDate  time.Time
+	Date        time.Time
+	// Subject is the first paragraph of the message on one line, Body the rest
+	Subject string
+	Body    string
+	// Files lists the paths the commit changed, when requested with LogOptions.Files
+	Files []string
+}
+
+// ShortSHA abbreviates the commit ID for display
+func (c Commit) ShortSHA() string {
+	if len(c.SHA) > 7 {
+		return c.SHA[:7]
+	}
+	return c.SHA
+}
+
+// LogOptions selects commits for Log
+type LogOptions struct {
+	// Revisions are passed to rev-list, e.g. "abc123..HEAD"; HEAD when empty
+	Revisions []string
+	// Since keeps commits committed at or after a time; zero keeps all
+	Since time.Time
+	// Limit is the maximum number of commits; zero means no limit
+	Limit int
+	// Files also lists the paths each commit changed
+	Files bool
+	// Paths keeps commits touching these files or directories, relative to the repository root
+	Paths []string
+}
+
+// Log lists commits newest first, like git log
+func (r *Repo) Log(ctx context.Context, opts LogOptions) ([]Commit, error) {
+	shas, err := r.revList(ctx, opts)
+	if err != nil || len(shas) == 0 {
+		return nil, err
+	}
+
+	commits := make([]Commit, 0, len(shas))
+	for _, sha := range shas {
+		commit, err := r.ReadCommit(ctx, sha)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, *commit)
+	}
+
+	if opts.Files {
+		files, err := r.changedFiles(ctx, shas)
+		if err != nil {
+			return nil, err
+		}
+		for i := range commits {
+			commits[i].Files = files[commits[i].SHA]
+		}
+	}
+	return commits, nil
+}
+
+// Count is the number of commits selected by revisions, e.g. "HEAD..abc123"
+func (r *Repo) Count(ctx context.Context, revisions ...string) (int, error) {
+	out, err := r.output(ctx, append([]string{"rev-list", "--count"}, revisions...)...)
+	if err != nil {
+		return 0, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(out))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse commit count %q: %w", out, err)
+	}
+	return count, nil
+}
+
+// Tree is the hash of the tree a revision points at; identical trees mean identical content
+func (r *Repo) Tree(ctx context.Context, revision string) (string, error) {
+	out, err := r.output(ctx, "rev-parse", "--verify", "--end-of-options", revision+"^{tree}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// revList resolves the commits selected by opts, newest first
+func (r *Repo) revList(ctx context.Context, opts LogOptions) ([]string, error) {
+	args := []string{"rev-list"}
+	if opts.Limit > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", opts.Limit))
+	}
+	if !opts.Since.IsZero() {
+		args = append(args, "--since="+opts.Since.UTC().Format(time.RFC3339))
+	}
+	if len(opts.Revisions) == 0 {
+		args = append(args, "HEAD")
+	} else {
+		args = append(args, opts.Revisions...)
+	}
+	args = append(args, "--")
+	args = append(args, opts.Paths...)
+
+	out, err := r.output(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// ReadCommit reads one commit object through the repository's cat-file session
+func (r *Repo) ReadCommit(ctx context.Context, sha string) (*Commit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.batch == nil {
+		batch, err := startCatFile(r.command(context.Background(), "cat-file", "--batch"))
+		if err != nil {
+			return nil, err
+		}
+		r.batch = batch
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	kind, data, err := r.batch.read(sha)
+	if err != nil {
+		// The session is unusable after a protocol error
+		r.batch.close()
+		r.batch = nil
+		return nil, err
+	}
+	if kind != "commit" {
+		return nil, fmt.Errorf("object %s is a %s, not a commit", sha, kind)
+	}
+	return parseCommit(sha, data)
+}
+
+// changedFiles lists the paths each commit changed using one diff-tree process
+func (r *Repo) changedFiles(ctx context.Context, shas []string) (map[string][]string, error) {
+	cmd := r.command(ctx, "diff-tree", "--stdin", "-r", "--root", "--name-only", "-z")
+	cmd.Stdin = strings.NewReader(strings.Join(shas, "\n") + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, gitError(ctx, cmd.Args[1:], stderr.String(), err)
+	}
+
+	// Output is "<sha>\0<path>\0<path>\0<sha>\0..."; merges are skipped without a header,
+	// so headers are recognized as the next commits still expected
+	expected := make(map[string]bool, len(shas))
+	for _, sha := range shas {
+		expected[sha] = true
+	}
+	files := make(map[string][]string)
+	current := ""
+	for _, field := range strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00") {
+		if expected[field] {
+			current = field
+			delete(expected, field)
+			continue
+		}
+		if current != "" && field != "" {
+			files[current] = append(files[current], field)
+		}
+	}
+	return files, nil
+}
+
+// parseCommit parses a raw commit object
+func parseCommit(sha string, data []byte) (*Commit, error) {
+	header, message, _ := bytes.Cut(data, []byte("\n\n"))
+	commit := &Commit{SHA: sha}
+
+	for _, line := range strings.Split(string(header), "\n") {
+		// Continuation lines of multi-line headers such as gpgsig start with a space
+		if strings.HasPrefix(line, " ") {
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "parent":
+			commit.Parents = append(commit.Parents, value)
+		case "author":
+			name, email, when, err := parseSignature(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse author of %s: %w", sha, err)
+			}
+			commit.Author, commit.AuthorEmail, commit.AuthorDate = name, email, when
+		case "committer":
+			_, _, when, err := parseSignature(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse committer of %s: %w", sha, err)
+			}
+			commit.Date = when
+		}
+	}
+
+	// Like git's %s, the subject is the first paragraph joined into one line
+	text := strings.TrimSpace(string(message))
+	subject, body, _ := strings.Cut(text, "\n\n")
+	commit.Subject = strings.Join(strings.Fields(subject), " ")
+	commit.Body = strings.TrimSpace(body)
+	return commit, nil
+}
+
+// parseSignature parses "Name <email> 1700000000 +0100"
+func parseSignature(value string) (string, string, time.Time, error) {
+	open := strings.LastIndex(value, "<")
+	end := strings.LastIndex(value, ">")
+	if open < 0 || end < open {
+		return "", "", time.Time{}, fmt.Errorf("malformed signature %q", value)
+	}
+	name := strings.TrimSpace(value[:open])
+	email := value[open+1 : end]
+
+	fields := strings.Fields(value[end+1:])
+	if len(fields) != 2 {
+		return "", "", time.Time{}, fmt.Errorf("malformed signature date %q", value[end+1:])
+	}
+	seconds, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("malformed signature date %q", fields[0])
+	}
+	offset, err := time.Parse("-0700", fields[1])
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("malformed signature zone %q", fields[1])
+	}
+	_, zoneOffset := offset.Zone()
+	when := time.Unix(seconds, 0).In(time.FixedZone("", zoneOffset))
+	return name, email, when, nil
+}
+
+// catFile is a running git cat-file --batch process
+type catFile struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+func startCatFile(cmd *exec.Cmd) (*catFile, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start git cat-file: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start git cat-file: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start git cat-file: %w", err)
+	}
+	return &catFile{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// read fetches an object, returning its type and contents
+func (c *catFile) read(object string) (string, []byte, error) {
+	if _, err := fmt.Fprintln(c.stdin, object); err != nil {
+		return "", nil, fmt.Errorf("failed to write to git cat-file: %w", err)
+	}
+	// "<sha> <type> <size>\n<contents>\n", or "<object> missing\n"
+	line, err := c.stdout.ReadString('\n')
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read from git cat-file: %w", err)
+	}
+	fields := strings.Fields(line)
+	// Unknown objects are reported as "<object> missing" or "<object> ambiguous"
+	if len(fields) == 2 {
+		return "", nil, fmt.Errorf("object %s %s", object, fields[1])
+	}
+	if len(fields) != 3 {
+		return "", nil, fmt.Errorf("unexpected git cat-file header %q", strings.TrimSpace(line))
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return "", nil, fmt.Errorf("unexpected git cat-file header %q", strings.TrimSpace(line))
+	}
+	data := make([]byte, size+1)
+	if _, err := io.ReadFull(c.stdout, data); err != nil {
+		return "", nil, fmt.Errorf("failed to read object %s: %w", object, err)
+	}
+	return fields[1], data[:size], nil
+}
+
+func (c *catFile) close() error {
+	c.stdin.Close()
+	return c.cmd.Wait()
+}
@@ -0,0 +1,17 @@
+package gitinfo
+
+import "context"
+
+// Patch is the unified diff a commit made, limited to paths when given. Merge commits have no
+// single diff and return "".
+func (r *Repo) Patch(ctx context.Context, sha string, paths ...string) (string, error) {
+	args := append([]string{"diff-tree", "-p", "-r", "--root", "--no-commit-id", "--no-color", sha, "--"}, paths...)
+	return r.output(ctx, args...)
+}
+
+// WorkingPatch is the unified diff of uncommitted changes to tracked files, staged or not,
+// limited to paths when given
+func (r *Repo) WorkingPatch(ctx context.Context, paths ...string) (string, error) {
+	args := append([]string{"diff", "--no-color", "--no-ext-diff", "HEAD", "--"}, paths...)
+	return r.output(ctx, args...)
+}
@@ -0,0 +1,102 @@
+// Package gitinfo reads commits, working tree status, branches and stashes from a git
+// repository through git's machine-readable interfaces: rev-list and a long-lived
+// cat-file --batch session for commits, and porcelain v2 for status.
+package gitinfo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrNotRepository is returned by Open outside a git working tree
+	ErrNotRepository = errors.New("not a git repository")
+	// ErrNoCommits is returned when HEAD does not point at a commit yet
+	ErrNoCommits = errors.New("no commits yet")
+)
+
+// Repo is a git working tree. Its methods are safe for concurrent use; Close stops the
+// cat-file session started by Log.
+type Repo struct {
+	root string
+
+	mu    sync.Mutex
+	batch *catFile
+}
+
+// Open finds the repository containing dir, or the current directory when dir is ""
+func Open(ctx context.Context, dir string) (*Repo, error) {
+	r := &Repo{root: dir}
+	root, err := r.output(ctx, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	r.root = strings.TrimSpace(root)
+	return r, nil
+}
+
+// Root is the top-level directory of the working tree
+func (r *Repo) Root() string {
+	return r.root
+}
+
+// Close stops the cat-file session, if one was started
+func (r *Repo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.batch == nil {
+		return nil
+	}
+	err := r.batch.close()
+	r.batch = nil
+	return err
+}
+
+// command prepares a git command running in the repository. Messages stay untranslated,
+// since gitError matches on their text.
+func (r *Repo) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.root
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	return cmd
+}
+
+// output runs git and returns its stdout, turning failures into errors carrying git's message
+func (r *Repo) output(ctx context.Context, args ...string) (string, error) {
+	cmd := r.command(ctx, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", gitError(ctx, args, stderr.String(), err)
+	}
+	return string(out), nil
+}
+
+// gitError describes a failed git command, mapping known failures to the package errors
+func gitError(ctx context.Context, args []string, stderr string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("git %s: %w", args[0], ctxErr)
+	}
+	message := strings.TrimSpace(stderr)
+	switch {
+	case strings.Contains(message, "not a git repository"):
+		return ErrNotRepository
+	case strings.Contains(message, "bad revision 'HEAD'"),
+		strings.Contains(message, "ambiguous argument 'HEAD'"),
+		strings.Contains(message, "does not have any commits yet"):
+		return ErrNoCommits
+	}
+	if message == "" {
+		return fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	// git prefixes its messages with "fatal: "; only the first line is useful
+	message, _, _ = strings.Cut(strings.TrimPrefix(message, "fatal: "), "\n")
+	return fmt.Errorf("git %s failed: %s", args[0], message)
+}
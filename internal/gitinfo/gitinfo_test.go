@@ -0,0 +1,258 @@
+package gitinfo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// git runs a git command in dir with a fixed identity
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Ada", "GIT_AUTHOR_EMAIL=ada@example.com",
+		"GIT_COMMITTER_NAME=Bob", "GIT_COMMITTER_EMAIL=bob@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+// testRepo creates a repository with two commits on main
+func testRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	git(t, dir, "init", "-q", "-b", "main")
+	writeFile(t, dir, "README.md", "# ripcord\n")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "Initial commit")
+	writeFile(t, dir, "main.go", "package main\n")
+	writeFile(t, dir, "README.md", "# ripcord\n\nPull it.\n")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "Add main\nwith a wrapped subject", "-m", "Explains why.\n\nIn detail.")
+	return dir
+}
+
+func openRepo(t *testing.T, dir string) *Repo {
+	t.Helper()
+	repo, err := Open(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func TestOpen(t *testing.T) {
+	dir := testRepo(t)
+	sub := filepath.Join(dir, "internal")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	root, _ := filepath.EvalSymlinks(dir)
+	if repo := openRepo(t, sub); repo.Root() != root {
+		t.Errorf("Expected root %s, got %s", root, repo.Root())
+	}
+
+	if _, err := Open(context.Background(), t.TempDir()); !errors.Is(err, ErrNotRepository) {
+		t.Errorf("Expected ErrNotRepository outside a repository, got %v", err)
+	}
+}
+
+func TestTranslatedGit(t *testing.T) {
+	// git translates its messages under LANGUAGE unless the locale is plain C
+	t.Setenv("LANGUAGE", "de")
+	t.Setenv("LC_ALL", "C.UTF-8")
+
+	if _, err := Open(context.Background(), t.TempDir()); !errors.Is(err, ErrNotRepository) {
+		t.Errorf("Expected ErrNotRepository whatever the locale, got %v", err)
+	}
+	empty := t.TempDir()
+	git(t, empty, "init", "-q")
+	if _, err := openRepo(t, empty).Log(context.Background(), LogOptions{}); !errors.Is(err, ErrNoCommits) {
+		t.Errorf("Expected ErrNoCommits whatever the locale, got %v", err)
+	}
+}
+
+func TestLog(t *testing.T) {
+	dir := testRepo(t)
+	repo := openRepo(t, dir)
+	ctx := context.Background()
+
+	commits, err := repo.Log(ctx, LogOptions{Files: true})
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("Expected 2 commits, got %d", len(commits))
+	}
+	latest := commits[0]
+	if latest.Subject != "Add main with a wrapped subject" || latest.Body != "Explains why.\n\nIn detail." {
+		t.Errorf("Expected subject and body to be split like git log, got %q and %q", latest.Subject, latest.Body)
+	}
+	if latest.Author != "Ada" || latest.AuthorEmail != "ada@example.com" || time.Since(latest.Date) > time.Minute {
+		t.Errorf("Expected author Ada committed just now, got %+v", latest)
+	}
+	if !reflect.DeepEqual(latest.Files, []string{"README.md", "main.go"}) || !reflect.DeepEqual(commits[1].Files, []string{"README.md"}) {
+		t.Errorf("Expected changed files per commit, got %v and %v", latest.Files, commits[1].Files)
+	}
+	if len(latest.Parents) != 1 || latest.Parents[0] != commits[1].SHA || len(latest.ShortSHA()) != 7 {
+		t.Errorf("Expected the parent to be the first commit, got %+v", latest)
+	}
+
+	if limited, err := repo.Log(ctx, LogOptions{Limit: 1}); err != nil || len(limited) != 1 || limited[0].Files != nil {
+		t.Errorf("Expected one commit without files, got %+v, %v", limited, err)
+	}
+	if future, err := repo.Log(ctx, LogOptions{Since: time.Now().Add(time.Hour)}); err != nil || len(future) != 0 {
+		t.Errorf("Expected no commits in the future, got %d, %v", len(future), err)
+	}
+	if since, err := repo.Log(ctx, LogOptions{Revisions: []string{commits[1].SHA + "..HEAD"}}); err != nil || len(since) != 1 {
+		t.Errorf("Expected one commit in the range, got %d, %v", len(since), err)
+	}
+	if count, err := repo.Count(ctx, "HEAD"); err != nil || count != 2 {
+		t.Errorf("Expected 2 commits, got %d, %v", count, err)
+	}
+	if tree, err := repo.Tree(ctx, "HEAD"); err != nil || len(tree) != 40 || tree == latest.SHA {
+		t.Errorf("Expected the tree hash of HEAD, got %q, %v", tree, err)
+	}
+	if _, err := repo.Log(ctx, LogOptions{Revisions: []string{"no-such-branch"}}); err == nil {
+		t.Error("Expected an unknown revision to be an error")
+	}
+	if _, err := repo.ReadCommit(ctx, "0000000000000000000000000000000000000000"); err == nil {
+		t.Error("Expected a missing object to be an error")
+	}
+	// The cat-file session recovers after an error
+	if commit, err := repo.ReadCommit(ctx, latest.SHA); err != nil || commit.Subject != latest.Subject {
+		t.Errorf("Expected to read the commit again, got %+v, %v", commit, err)
+	}
+
+	empty := t.TempDir()
+	git(t, empty, "init", "-q")
+	if _, err := openRepo(t, empty).Log(ctx, LogOptions{}); !errors.Is(err, ErrNoCommits) {
+		t.Errorf("Expected ErrNoCommits in an empty repository, got %v", err)
+	}
+}
+
+func TestPathsAndPatches(t *testing.T) {
+	dir := testRepo(t)
+	repo := openRepo(t, dir)
+	ctx := context.Background()
+
+	commits, err := repo.Log(ctx, LogOptions{Paths: []string{"main.go"}})
+	if err != nil || len(commits) != 1 || commits[0].Subject != "Add main with a wrapped subject" {
+		t.Fatalf("Expected only the commit touching main.go, got %+v, %v", commits, err)
+	}
+
+	patch, err := repo.Patch(ctx, commits[0].SHA, "main.go")
+	if err != nil {
+		t.Fatalf("Failed to read patch: %v", err)
+	}
+	if !strings.Contains(patch, "+++ b/main.go") || !strings.Contains(patch, "+package main") || strings.Contains(patch, "README.md") {
+		t.Errorf("Expected the main.go part of the commit, got:\n%s", patch)
+	}
+
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+	working, err := repo.WorkingPatch(ctx, "main.go")
+	if err != nil || !strings.Contains(working, "+func main() {}") {
+		t.Errorf("Expected the uncommitted change, got %q, %v", working, err)
+	}
+	if clean, err := repo.WorkingPatch(ctx, "README.md"); err != nil || clean != "" {
+		t.Errorf("Expected no diff for an unchanged file, got %q, %v", clean, err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	upstream := testRepo(t)
+	dir := t.TempDir()
+	git(t, dir, "clone", "-q", upstream, ".")
+	ctx := context.Background()
+
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+	writeFile(t, dir, "notes.txt", "todo\n")
+	git(t, dir, "mv", "README.md", "README")
+	writeFile(t, dir, "new.go", "package main\n")
+	git(t, dir, "add", "new.go")
+	git(t, dir, "commit", "-q", "-m", "Add new.go", "--", "new.go")
+
+	status, err := openRepo(t, dir).Status(ctx)
+	if err != nil {
+		t.Fatalf("Failed to read status: %v", err)
+	}
+	if status.Branch != "main" || status.Upstream != "origin/main" || !status.HasUpstream() || status.Ahead != 1 || status.Behind != 0 || len(status.Commit) != 40 {
+		t.Errorf("Expected main one ahead of origin/main, got %+v", status)
+	}
+	want := []StatusEntry{
+		{Index: 'R', Worktree: Unmodified, Path: "README", OrigPath: "README.md"},
+		{Index: Unmodified, Worktree: 'M', Path: "main.go"},
+		{Index: '?', Worktree: '?', Path: "notes.txt"},
+	}
+	if !reflect.DeepEqual(status.Entries, want) {
+		t.Errorf("Expected %+v, got %+v", want, status.Entries)
+	}
+	if codes := []string{status.Entries[0].Code(), status.Entries[1].Code(), status.Entries[2].Code()}; !reflect.DeepEqual(codes, []string{"R ", " M", "??"}) {
+		t.Errorf("Expected short status codes, got %v", codes)
+	}
+	if !status.Dirty() || !status.Staged() {
+		t.Errorf("Expected a dirty tree with staged changes")
+	}
+
+	git(t, dir, "checkout", "-q", "--detach")
+	if detached, err := openRepo(t, dir).Status(ctx); err != nil || !detached.Detached() || detached.HasUpstream() {
+		t.Errorf("Expected a detached HEAD without upstream, got %+v, %v", detached, err)
+	}
+
+	empty := t.TempDir()
+	git(t, empty, "init", "-q")
+	if initial, err := openRepo(t, empty).Status(ctx); err != nil || initial.Commit != "" || initial.Dirty() {
+		t.Errorf("Expected a clean repository without commits, got %+v, %v", initial, err)
+	}
+}
+
+func TestBranchesAndStashes(t *testing.T) {
+	dir := testRepo(t)
+	git(t, dir, "branch", "feature/x")
+	writeFile(t, dir, "main.go", "package main\n\n// wip\n")
+	git(t, dir, "stash", "push", "-q", "-m", "half-done parser")
+	repo := openRepo(t, dir)
+	ctx := context.Background()
+
+	branches, err := repo.Branches(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list branches: %v", err)
+	}
+	current := 0
+	for _, branch := range branches {
+		if branch.Current {
+			current++
+			if branch.Name != "main" {
+				t.Errorf("Expected main to be current, got %s", branch.Name)
+			}
+		}
+	}
+	if len(branches) != 2 || current != 1 || branches[0].Commit != branches[1].Commit || branches[0].Date.IsZero() {
+		t.Errorf("Expected main and feature/x at the same commit, got %+v", branches)
+	}
+
+	stashes, err := repo.Stashes(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list stashes: %v", err)
+	}
+	if len(stashes) != 1 || stashes[0].Ref != "stash@{0}" || stashes[0].Message != "On main: half-done parser" || len(stashes[0].Commit) != 40 {
+		t.Errorf("Expected the stash, got %+v", stashes)
+	}
+}
@@ -0,0 +1,98 @@
+package gitinfo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Branch is a local branch
+type Branch struct {
+	Name     string
+	Commit   string
+	Upstream string
+	// Current marks the checked out branch
+	Current bool
+	// Date is the committer date of the branch tip
+	Date time.Time
+}
+
+// Stash is an entry of git stash list
+type Stash struct {
+	// Ref is the stash's reflog name, e.g. "stash@{0}"
+	Ref     string
+	Commit  string
+	Message string
+	Date    time.Time
+}
+
+// fieldSeparator separates fields of a formatted ref or reflog entry
+const fieldSeparator = "\x1f"
+
+// Branches lists local branches, most recently committed first
+func (r *Repo) Branches(ctx context.Context) ([]Branch, error) {
+	out, err := r.output(ctx, "for-each-ref", "--sort=-committerdate",
+		"--format=%(refname:short)%1f%(objectname)%1f%(upstream:short)%1f%(HEAD)%1f%(committerdate:unix)",
+		"refs/heads")
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []Branch
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, fieldSeparator)
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected git for-each-ref line %q", line)
+		}
+		date, err := parseUnix(fields[4])
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, Branch{
+			Name:     fields[0],
+			Commit:   fields[1],
+			Upstream: fields[2],
+			Current:  fields[3] == "*",
+			Date:     date,
+		})
+	}
+	return branches, nil
+}
+
+// Stashes lists stash entries, newest first
+func (r *Repo) Stashes(ctx context.Context) ([]Stash, error) {
+	out, err := r.output(ctx, "stash", "list", "-z", "--format=%gd%x1f%H%x1f%ct%x1f%gs")
+	if err != nil {
+		return nil, err
+	}
+
+	var stashes []Stash
+	for _, record := range strings.Split(strings.TrimSuffix(out, "\x00"), "\x00") {
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, fieldSeparator, 4)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected git stash entry %q", record)
+		}
+		date, err := parseUnix(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		stashes = append(stashes, Stash{Ref: fields[0], Commit: fields[1], Date: date, Message: fields[3]})
+	}
+	return stashes, nil
+}
+
+// parseUnix parses a unix timestamp printed by git
+func parseUnix(value string) (time.Time, error) {
+	var seconds int64
+	if _, err := fmt.Sscanf(value, "%d", &seconds); err != nil {
+		return time.Time{}, fmt.Errorf("unexpected git timestamp %q", value)
+	}
+	return time.Unix(seconds, 0), nil
+}
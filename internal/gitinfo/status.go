@@ -0,0 +1,169 @@
+package gitinfo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Unmodified is the status code of an unchanged side of a StatusEntry
+const Unmodified = ' '
+
+// StatusEntry is a changed path with git's two-letter status code: Index is the staged
+// change and Worktree the unstaged one, e.g. 'M', 'A', 'D', 'R', or '?' for untracked files
+type StatusEntry struct {
+	Index    byte
+	Worktree byte
+	Path     string
+	// OrigPath is the path a renamed or copied file came from
+	OrigPath string
+	// Conflicted marks unmerged paths
+	Conflicted bool
+}
+
+// Code is the two-letter status as git status --short shows it, so "M " is a staged
+// modification, " M" an unstaged one, "MM" both and "??" an untracked file
+func (e StatusEntry) Code() string {
+	return string([]byte{e.Index, e.Worktree})
+}
+
+// Untracked reports whether the path is not tracked by git
+func (e StatusEntry) Untracked() bool {
+	return e.Index == '?'
+}
+
+// Staged reports whether the entry has changes in the index
+func (e StatusEntry) Staged() bool {
+	return e.Index != Unmodified && !e.Untracked()
+}
+
+// Status is the branch and working tree state from git status --porcelain=v2
+type Status struct {
+	// Commit is HEAD, or "" before the first commit
+	Commit string
+	// Branch is the checked out branch, or "" when HEAD is detached
+	Branch   string
+	Upstream string
+	// UpstreamGone marks an upstream that is configured but no longer exists
+	UpstreamGone bool
+	// Ahead and Behind count commits relative to Upstream
+	Ahead   int
+	Behind  int
+	Entries []StatusEntry
+}
+
+// Detached reports whether HEAD is not on a branch
+func (s *Status) Detached() bool {
+	return s.Branch == ""
+}
+
+// HasUpstream reports whether the branch tracks an upstream that exists
+func (s *Status) HasUpstream() bool {
+	return s.Upstream != "" && !s.UpstreamGone
+}
+
+// Dirty reports whether anything is changed or untracked
+func (s *Status) Dirty() bool {
+	return len(s.Entries) > 0
+}
+
+// Staged reports whether any change is staged for commit
+func (s *Status) Staged() bool {
+	for _, entry := range s.Entries {
+		if entry.Staged() {
+			return true
+		}
+	}
+	return false
+}
+
+// Status reads the branch and working tree state in one git status call
+func (r *Repo) Status(ctx context.Context) (*Status, error) {
+	out, err := r.output(ctx, "status", "--porcelain=v2", "--branch", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return parseStatus(out)
+}
+
+// parseStatus parses NUL-terminated porcelain v2 output with branch headers
+func parseStatus(out string) (*Status, error) {
+	status := &Status{}
+	// branch.ab only follows branch.upstream when the upstream exists
+	tracking := false
+	records := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if record == "" {
+			continue
+		}
+		kind, rest, _ := strings.Cut(record, " ")
+		switch kind {
+		case "#":
+			if strings.HasPrefix(rest, "branch.ab ") {
+				tracking = true
+			}
+			if err := status.parseHeader(rest); err != nil {
+				return nil, err
+			}
+		case "1", "2", "u":
+			// Ordinary, renamed and unmerged entries have 7, 8 and 9 fields before the path
+			fields := map[string]int{"1": 7, "2": 8, "u": 9}[kind]
+			parts := strings.SplitN(rest, " ", fields+1)
+			if len(parts) != fields+1 || len(parts[0]) != 2 {
+				return nil, fmt.Errorf("unexpected git status entry %q", record)
+			}
+			entry := StatusEntry{
+				Index:      statusCode(parts[0][0]),
+				Worktree:   statusCode(parts[0][1]),
+				Path:       parts[fields],
+				Conflicted: kind == "u",
+			}
+			// The original path of a rename follows as its own record
+			if kind == "2" && i+1 < len(records) {
+				i++
+				entry.OrigPath = records[i]
+			}
+			status.Entries = append(status.Entries, entry)
+		case "?":
+			status.Entries = append(status.Entries, StatusEntry{Index: '?', Worktree: '?', Path: rest})
+		case "!":
+			// Ignored files are only listed with --ignored
+		default:
+			return nil, fmt.Errorf("unexpected git status entry %q", record)
+		}
+	}
+	status.UpstreamGone = status.Upstream != "" && !tracking
+	return status, nil
+}
+
+// parseHeader reads a "# branch.*" line
+func (s *Status) parseHeader(header string) error {
+	key, value, _ := strings.Cut(header, " ")
+	switch key {
+	case "branch.oid":
+		if value != "(initial)" {
+			s.Commit = value
+		}
+	case "branch.head":
+		if value != "(detached)" {
+			s.Branch = value
+		}
+	case "branch.upstream":
+		s.Upstream = value
+	case "branch.ab":
+		// "+<ahead> -<behind>"
+		if _, err := fmt.Sscanf(value, "+%d -%d", &s.Ahead, &s.Behind); err != nil {
+			return fmt.Errorf("unexpected git status header %q: %w", header, err)
+		}
+	}
+	return nil
+}
+
+// statusCode converts porcelain v2's '.' for unchanged to the ' ' used by the short format
+func statusCode(code byte) byte {
+	if code == '.' {
+		return Unmodified
+	}
+	return code
+}
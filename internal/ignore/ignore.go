@@ -0,0 +1,203 @@
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Matcher evaluates gitignore-style patterns collected from a project tree
+type Matcher struct {
+	root  string
+	rules []rule
+}
+
+// rule is a single compiled ignore pattern
+type rule struct {
+	base    string // directory the pattern file lives in, relative to root ("" for root)
+	regex   *regexp.Regexp
+	negate  bool
+	dirOnly bool
+	// anchored patterns match against the path relative to base, others against the name only
+	anchored bool
+}
+
+// New creates a matcher for root, loading the root .gitignore and .git/info/exclude
+func New(root string) *Matcher {
+	m := &Matcher{root: root}
+	m.loadFile("", filepath.Join(root, ".git", "info", "exclude"))
+	m.AddFile("", ".gitignore")
+	return m
+}
+
+// AddFile loads patterns from the named file in dir (relative to root), if it exists
+func (m *Matcher) AddFile(dir, name string) {
+	m.loadFile(dir, filepath.Join(m.root, filepath.FromSlash(dir), name))
+}
+
+// AddPatterns adds patterns scoped to dir (relative to root)
+func (m *Matcher) AddPatterns(dir string, patterns ...string) {
+	for _, pattern := range patterns {
+		if r, ok := compile(filepath.ToSlash(dir), pattern); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+}
+
+// Match reports whether relPath (relative to root) is ignored, including via an ignored parent directory
+func (m *Matcher) Match(relPath string, isDir bool) bool {
+	relPath = strings.Trim(filepath.ToSlash(relPath), "/")
+	if relPath == "" || relPath == "." {
+		return false
+	}
+
+	parts := strings.Split(relPath, "/")
+	for i := range parts {
+		if parts[i] == ".git" {
+			return true
+		}
+		current := strings.Join(parts[:i+1], "/")
+		last := i == len(parts)-1
+		if m.matchOne(current, !last || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOne applies the rules to a single path; the last matching rule wins
+func (m *Matcher) matchOne(relPath string, isDir bool) bool {
+	ignored := false
+	name := path.Base(relPath)
+
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+
+		target := relPath
+		if r.base != "" {
+			if !strings.HasPrefix(relPath, r.base+"/") {
+				continue
+			}
+			target = strings.TrimPrefix(relPath, r.base+"/")
+		}
+		if !r.anchored {
+			target = name
+		}
+
+		if r.regex.MatchString(target) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+func (m *Matcher) loadFile(dir, filePath string) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	m.AddPatterns(dir, patterns...)
+}
+
+// compile parses one line of an ignore file
+func compile(base, line string) (rule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	r := rule{base: strings.Trim(base, "/")}
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to its base directory
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	regex, err := regexp.Compile("^" + globToRegexp(line) + "$")
+	if err != nil {
+		return rule{}, false
+	}
+	r.regex = regex
+	return r, true
+}
+
+// MatchGlob reports whether name matches a gitignore-style glob, where ** spans directories
+func MatchGlob(pattern, name string) bool {
+	regex, err := regexp.Compile("^" + globToRegexp(strings.Trim(pattern, "/")) + "$")
+	if err != nil {
+		return false
+	}
+	return regex.MatchString(strings.Trim(filepath.ToSlash(name), "/"))
+}
+
+// globToRegexp translates gitignore glob syntax, including **, into a regular expression
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					// "**/" matches zero or more leading directories
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	root := t.TempDir()
+	gitignore := "# build output\n*.log\n/bin\nbuild/\ndocs/**/*.tmp\n!keep.log\n"
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte(gitignore), 0644); err != nil {
+		t.Fatalf("Failed to write .gitignore: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create subdirectory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "sub", ".gitignore"), []byte("local.txt\n"), 0644); err != nil {
+		t.Fatalf("Failed to write nested .gitignore: %v", err)
+	}
+
+	m := New(root)
+	m.AddFile("sub", ".gitignore")
+
+	testCases := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"main.go", false, false},
+		{"debug.log", false, true},
+		{"nested/deep/trace.log", false, true},
+		{"keep.log", false, false},
+		{"bin", true, true},
+		{"bin/wherewasi", false, true},
+		{"cmd/bin", true, false},
+		{"build", true, true},
+		{"build/output.txt", false, true},
+		{"build", false, false},
+		{"docs/a/b/c.tmp", false, true},
+		{"docs/c.tmp", false, true},
+		{"other/c.tmp", false, false},
+		{"sub/local.txt", false, true},
+		{"local.txt", false, false},
+		{".git/config", false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			if got := m.Match(tc.path, tc.isDir); got != tc.ignored {
+				t.Errorf("Match(%q, %v) = %v, expected %v", tc.path, tc.isDir, got, tc.ignored)
+			}
+		})
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	testCases := []struct {
+		glob     string
+		expected string
+	}{
+		{"*.go", `[^/]*\.go`},
+		{"a?c", `a[^/]c`},
+		{"**/vendor", `(?:.*/)?vendor`},
+		{"logs/**", `logs/.*`},
+		{"[!a]b", `[^a]b`},
+	}
+
+	for _, tc := range testCases {
+		if got := globToRegexp(tc.glob); got != tc.expected {
+			t.Errorf("globToRegexp(%q) = %q, expected %q", tc.glob, got, tc.expected)
+		}
+	}
+}
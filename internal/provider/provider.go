@@ -0,0 +1,235 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/config"
+	"github.com/QRY91/wherewasi/internal/contextdoc"
+)
+
+// Request describes the context being pulled
+type Request struct {
+	// Project is the --project reference (name, alias or path), or "" for the current directory.
+	// The resolved project name is doc.Project.
+	Project string
+	Days    int
+	Keyword string
+	// Regex and CaseSensitive control how Keyword matches; by default it is literal and
+	// case-insensitive
+	Regex         bool
+	CaseSensitive bool
+	// PerProject caps keyword search hits per project; 0 means no cap
+	PerProject int
+	// Config is the loaded user config; never nil
+	Config *config.Config
+}
+
+// ContextProvider fills one section of a context document
+type ContextProvider interface {
+	// Name identifies the provider in config and is the section it renders
+	Name() string
+	// Provide fills doc, which only holds the header, and should return once ctx is done.
+	// Providers run concurrently, each on its own document.
+	Provide(ctx context.Context, req Request, doc *contextdoc.Document) error
+}
+
+// Func adapts a function to a ContextProvider
+func Func(name string, fn func(ctx context.Context, req Request, doc *contextdoc.Document) error) ContextProvider {
+	return funcProvider{name: name, fn: fn}
+}
+
+type funcProvider struct {
+	name string
+	fn   func(ctx context.Context, req Request, doc *contextdoc.Document) error
+}
+
+func (p funcProvider) Name() string { return p.name }
+
+func (p funcProvider) Provide(ctx context.Context, req Request, doc *contextdoc.Document) error {
+	return p.fn(ctx, req, doc)
+}
+
+// Registry holds context providers in registration order
+type Registry struct {
+	mu        sync.Mutex
+	providers []ContextProvider
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a provider; names must be unique
+func (r *Registry) Register(p ContextProvider) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.providers {
+		if existing.Name() == p.Name() {
+			return fmt.Errorf("context provider %q already registered", p.Name())
+		}
+	}
+	r.providers = append(r.providers, p)
+	return nil
+}
+
+// Names lists registered providers in registration order
+func (r *Registry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.namesLocked()
+}
+
+// Resolve applies the section config, returning enabled providers in run order.
+// Unknown names in the config are reported as an error alongside the resolved providers.
+func (r *Registry) Resolve(cfg config.Sections) ([]ContextProvider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byName := make(map[string]ContextProvider, len(r.providers))
+	for _, p := range r.providers {
+		byName[p.Name()] = p
+	}
+
+	var unknown []string
+	disabled := make(map[string]bool)
+	for _, name := range cfg.Disabled {
+		if byName[name] == nil {
+			unknown = append(unknown, name)
+		}
+		disabled[name] = true
+	}
+
+	var resolved []ContextProvider
+	seen := make(map[string]bool)
+	for _, name := range cfg.Order {
+		p := byName[name]
+		if p == nil {
+			unknown = append(unknown, name)
+			continue
+		}
+		if !seen[name] && !disabled[name] {
+			resolved = append(resolved, p)
+		}
+		seen[name] = true
+	}
+	for _, p := range r.providers {
+		if !seen[p.Name()] && !disabled[p.Name()] {
+			resolved = append(resolved, p)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return resolved, fmt.Errorf("unknown context section(s): %s (available: %s)",
+			strings.Join(unknown, ", "), strings.Join(r.namesLocked(), ", "))
+	}
+	return resolved, nil
+}
+
+func (r *Registry) namesLocked() []string {
+	names := make([]string, len(r.providers))
+	for i, p := range r.providers {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+// ErrTimedOut is the timing error of a provider that had not finished by the deadline
+var ErrTimedOut = errors.New("timed out")
+
+// Timing is how long a provider took to run and the error it returned, if any
+type Timing struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+}
+
+// TimedOut reports whether the provider missed the deadline
+func (t Timing) TimedOut() bool {
+	return errors.Is(t.Err, ErrTimedOut)
+}
+
+// outcome is what a provider left in its fork of the document
+type outcome struct {
+	doc      *contextdoc.Document
+	err      error
+	duration time.Duration
+}
+
+// Build runs the providers concurrently, each on a fork of doc, and merges their sections
+// back in order. Providers still running when ctx is done are marked as timed out and their
+// output is discarded. A failing provider does not stop the others; their errors are joined.
+// The returned timings list every provider in run order.
+func Build(ctx context.Context, providers []ContextProvider, req Request, doc *contextdoc.Document) ([]Timing, error) {
+	start := time.Now()
+	outcomes := make([]chan outcome, len(providers))
+	for i, p := range providers {
+		outcomes[i] = make(chan outcome, 1)
+		go func(p ContextProvider, fork *contextdoc.Document, done chan<- outcome) {
+			start := time.Now()
+			err := p.Provide(ctx, req, fork)
+			done <- outcome{doc: fork, err: err, duration: time.Since(start)}
+		}(p, doc.Fork(), outcomes[i])
+	}
+
+	doc.Order = make([]string, 0, len(providers))
+	timings := make([]Timing, 0, len(providers))
+	var errs []error
+	for i, p := range providers {
+		doc.Order = append(doc.Order, p.Name())
+
+		out, finished := wait(ctx, outcomes[i])
+		// A provider that gave up because of the deadline has timed out as well
+		if !finished || ctx.Err() != nil && errors.Is(out.err, ctx.Err()) {
+			doc.MarkTimedOut(p.Name())
+			timings = append(timings, Timing{Name: p.Name(), Duration: time.Since(start), Err: ErrTimedOut})
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), ErrTimedOut))
+			continue
+		}
+
+		doc.Merge(p.Name(), out.doc)
+		timings = append(timings, Timing{Name: p.Name(), Duration: out.duration, Err: out.err})
+		if out.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), out.err))
+		}
+	}
+	return timings, errors.Join(errs...)
+}
+
+// wait returns a provider's outcome, or false when ctx is done first
+func wait(ctx context.Context, done <-chan outcome) (outcome, bool) {
+	select {
+	case out := <-done:
+		return out, true
+	case <-ctx.Done():
+		// Prefer a provider that finished just as the deadline passed
+		select {
+		case out := <-done:
+			return out, true
+		default:
+			return outcome{}, false
+		}
+	}
+}
+
+// defaultRegistry holds the providers registered with Register
+var defaultRegistry = NewRegistry()
+
+// Register adds a provider to the default registry, panicking on duplicate names.
+// It is meant to be called from init functions.
+func Register(p ContextProvider) {
+	if err := defaultRegistry.Register(p); err != nil {
+		panic(err)
+	}
+}
+
+// Default returns the registry used by Register
+func Default() *Registry {
+	return defaultRegistry
+}
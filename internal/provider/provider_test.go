@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/config"
+	"github.com/QRY91/wherewasi/internal/contextdoc"
+)
+
+func testRegistry(t *testing.T) *Registry {
+	r := NewRegistry()
+	for _, name := range []string{"lint", "coverage", "todos"} {
+		name := name
+		err := r.Register(Func(name, func(ctx context.Context, req Request, doc *contextdoc.Document) error {
+			doc.AddSection(name, strings.ToUpper(name), req.Keyword)
+			return nil
+		}))
+		if err != nil {
+			t.Fatalf("Failed to register %s: %v", name, err)
+		}
+	}
+	return r
+}
+
+func names(providers []ContextProvider) []string {
+	var result []string
+	for _, p := range providers {
+		result = append(result, p.Name())
+	}
+	return result
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	r := testRegistry(t)
+	if err := r.Register(Func("todos", nil)); err == nil {
+		t.Error("Expected duplicate registration to fail")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	r := testRegistry(t)
+
+	testCases := []struct {
+		name     string
+		sections config.Sections
+		expected []string
+		wantErr  bool
+	}{
+		{"default", config.Sections{}, []string{"lint", "coverage", "todos"}, false},
+		{"ordered", config.Sections{Order: []string{"todos", "lint"}}, []string{"todos", "lint", "coverage"}, false},
+		{"disabled", config.Sections{Disabled: []string{"coverage"}}, []string{"lint", "todos"}, false},
+		{"ordered and disabled", config.Sections{Order: []string{"coverage"}, Disabled: []string{"coverage"}}, []string{"lint", "todos"}, false},
+		{"unknown", config.Sections{Order: []string{"tests"}, Disabled: []string{"typos"}}, []string{"lint", "coverage", "todos"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			providers, err := r.Resolve(tc.sections)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if got := names(providers); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	r := testRegistry(t)
+	failing := errors.New("no test runner")
+	if err := r.Register(Func("tests", func(ctx context.Context, req Request, doc *contextdoc.Document) error {
+		return failing
+	})); err != nil {
+		t.Fatalf("Failed to register tests: %v", err)
+	}
+
+	providers, err := r.Resolve(config.Sections{Order: []string{"todos"}})
+	if err != nil {
+		t.Fatalf("Failed to resolve providers: %v", err)
+	}
+
+	doc := contextdoc.New()
+	timings, err := Build(context.Background(), providers, Request{Keyword: "fixme"}, doc)
+	if !errors.Is(err, failing) {
+		t.Errorf("Expected provider error to be reported, got %v", err)
+	}
+	if len(timings) != len(providers) || timings[0].Name != "todos" || !errors.Is(timings[len(timings)-1].Err, failing) {
+		t.Errorf("Expected a timing per provider in run order, got %+v", timings)
+	}
+	if len(doc.Sections) != 3 {
+		t.Errorf("Expected remaining providers to run, got %d sections", len(doc.Sections))
+	}
+
+	text := doc.Text()
+	if strings.Index(text, "TODOS:") > strings.Index(text, "LINT:") {
+		t.Errorf("Expected sections in configured order:\n%s", text)
+	}
+	if strings.Contains(text, "KEY FILES") {
+		t.Errorf("Expected built-in sections without a provider to be omitted:\n%s", text)
+	}
+}
+
+func TestBuildTimeout(t *testing.T) {
+	r := testRegistry(t)
+	release := make(chan struct{})
+	defer close(release)
+	if err := r.Register(Func("stuck", func(ctx context.Context, req Request, doc *contextdoc.Document) error {
+		// Ignores ctx, like a provider blocked on a slow command
+		<-release
+		doc.AddSection("stuck", "STUCK", "late")
+		return nil
+	})); err != nil {
+		t.Fatalf("Failed to register stuck: %v", err)
+	}
+	if err := r.Register(Func("patient", func(ctx context.Context, req Request, doc *contextdoc.Document) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})); err != nil {
+		t.Fatalf("Failed to register patient: %v", err)
+	}
+	providers, err := r.Resolve(config.Sections{})
+	if err != nil {
+		t.Fatalf("Failed to resolve providers: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	doc := contextdoc.New()
+	start := time.Now()
+	timings, err := Build(ctx, providers, Request{}, doc)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected Build to return at the deadline, took %s", elapsed)
+	}
+	if !errors.Is(err, ErrTimedOut) {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+	for _, timing := range timings {
+		slow := timing.Name == "stuck" || timing.Name == "patient"
+		if timing.TimedOut() != slow {
+			t.Errorf("Expected only stuck and patient to time out, got %s: %v", timing.Name, timing.Err)
+		}
+	}
+	if len(doc.Sections) != 3 || !reflect.DeepEqual(doc.TimedOut, []string{"stuck", "patient"}) {
+		t.Errorf("Expected 3 finished sections and 2 timed out, got %d and %v", len(doc.Sections), doc.TimedOut)
+	}
+	if text := doc.Text(); !strings.Contains(text, "STUCK (timed out)") || !strings.Contains(text, "LINT:") {
+		t.Errorf("Expected the stuck section marked as timed out:\n%s", text)
+	}
+}
@@ -0,0 +1,250 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/QRY91/wherewasi/internal/gitinfo"
+)
+
+// Limits on one write to the index, so large refreshes are committed in steps
+const (
+	indexBatchFiles = 256
+	indexBatchBytes = 8 << 20
+)
+
+// Index is a persistent trigram index of project files in the ecosystem database, so a
+// search reads only the files that can match instead of every file of every project.
+//
+// Only git repositories are indexed; other targets, such as a working directory outside any
+// project, are walked on every search. A refresh re-reads the files whose size or modification
+// time changed and skips the walk entirely when a repository's working tree is clean and its
+// tree is the one indexed last time.
+type Index struct {
+	db ecosystem.ContextStore
+	// MaxFileSize is the largest file whose text is indexed (default DefaultMaxFileSize)
+	MaxFileSize int64
+
+	// mu serializes writes, which SQLite allows one at a time
+	mu sync.Mutex
+}
+
+// RefreshResult is what a refresh changed in a project
+type RefreshResult struct {
+	Project string
+	// Unchanged is set when the clean git tree matched the last refresh and nothing was read
+	Unchanged bool
+	Added     int
+	Updated   int
+	Removed   int
+}
+
+// NewIndex returns the index kept in db
+func NewIndex(db ecosystem.ContextStore) *Index {
+	return &Index{db: db, MaxFileSize: DefaultMaxFileSize}
+}
+
+// Search refreshes the targets' indexes, then searches the files that can match. Results are
+// the same as Search over the targets.
+func (ix *Index) Search(ctx context.Context, targets []Target, query Query, opts Options) ([]Hit, error) {
+	if _, err := query.Compile(); err != nil {
+		return nil, err
+	}
+	indexed, _, err := ix.Refresh(ctx, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	substrings := query.Substrings()
+	candidates := make([]Target, len(targets))
+	for i, target := range targets {
+		candidates[i] = target
+		if !indexed[i] {
+			continue
+		}
+		files, err := ix.db.FindIndexedFiles(target.Project, substrings)
+		if err != nil {
+			return nil, err
+		}
+		candidates[i].Files = files
+	}
+	return Search(ctx, candidates, query, opts)
+}
+
+// Refresh brings the targets' indexes up to date concurrently. indexed reports, per target,
+// whether it is a git repository kept in the index.
+func (ix *Index) Refresh(ctx context.Context, targets []Target) (indexed []bool, results []RefreshResult, err error) {
+	known, err := ix.db.IndexedProjects()
+	if err != nil {
+		return nil, nil, err
+	}
+	previous := make(map[string]ecosystem.IndexedProject, len(known))
+	for _, project := range known {
+		previous[project.Project] = project
+	}
+
+	indexed = make([]bool, len(targets))
+	refreshed := make([]*RefreshResult, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			refreshed[i], errs[i] = ix.refresh(ctx, target, previous[target.Project])
+			indexed[i] = refreshed[i] != nil
+		}(i, target)
+	}
+	wg.Wait()
+
+	for _, result := range refreshed {
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+	return indexed, results, errors.Join(errs...)
+}
+
+// Rebuild drops the targets from the index and indexes them from scratch
+func (ix *Index) Rebuild(ctx context.Context, targets []Target) ([]RefreshResult, error) {
+	ix.mu.Lock()
+	for _, target := range targets {
+		if err := ix.db.ClearFileIndex(target.Project); err != nil {
+			ix.mu.Unlock()
+			return nil, err
+		}
+	}
+	ix.mu.Unlock()
+	_, results, err := ix.Refresh(ctx, targets)
+	return results, err
+}
+
+// UpToDate reports whether a refresh of the project would skip the walk: its working tree is
+// clean and has the tree that was indexed
+func (ix *Index) UpToDate(ctx context.Context, project ecosystem.IndexedProject) bool {
+	tree, ok := cleanTree(ctx, project.Root)
+	return ok && tree != "" && tree == project.Tree
+}
+
+// refresh updates one target, returning nil without error when it is not a git repository
+func (ix *Index) refresh(ctx context.Context, target Target, previous ecosystem.IndexedProject) (*RefreshResult, error) {
+	tree, ok := cleanTree(ctx, target.Root)
+	if !ok {
+		return nil, ctx.Err()
+	}
+	result := &RefreshResult{Project: target.Project}
+	if tree != "" && tree == previous.Tree && target.Root == previous.Root {
+		result.Unchanged = true
+		return result, nil
+	}
+
+	known, err := ix.db.IndexedFiles(target.Project)
+	if err != nil {
+		return nil, err
+	}
+	stamps := make(map[string]ecosystem.IndexedFile, len(known))
+	for _, file := range known {
+		stamps[file.Path] = file
+	}
+
+	update := ecosystem.FileIndexUpdate{Project: target.Project, Root: target.Root}
+	pending := 0
+	var writeErr error
+	flush := func() {
+		if writeErr == nil && (len(update.Put) > 0 || len(update.Remove) > 0) {
+			ix.mu.Lock()
+			writeErr = ix.db.UpdateFileIndex(update)
+			ix.mu.Unlock()
+		}
+		update.Put, update.Remove, pending = nil, nil, 0
+	}
+
+	seen := make(map[string]bool, len(known))
+	Walk(ctx, target.Root, func(rel, path string) {
+		seen[rel] = true
+		info, err := os.Stat(path)
+		if err != nil {
+			return
+		}
+		stamp, exists := stamps[rel]
+		if exists && stamp.Unchanged(info.Size(), info.ModTime()) {
+			return
+		}
+		if exists {
+			result.Updated++
+		} else {
+			result.Added++
+		}
+
+		file := ecosystem.IndexFile{IndexedFile: ecosystem.IndexedFile{Path: rel, Size: info.Size(), ModTime: info.ModTime()}}
+		file.Content = ix.readText(path, info.Size())
+		update.Put = append(update.Put, file)
+		pending += len(file.Content)
+		if len(update.Put) >= indexBatchFiles || pending >= indexBatchBytes {
+			flush()
+		}
+	})
+	// An interrupted walk has not seen every file, so nothing can be judged removed
+	if err := ctx.Err(); err != nil {
+		flush()
+		return nil, err
+	}
+
+	for _, file := range known {
+		if !seen[file.Path] {
+			update.Remove = append(update.Remove, file.Path)
+			result.Removed++
+		}
+	}
+	flush()
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if err := ix.db.MarkFileIndexed(target.Project, target.Root, tree); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// readText returns a file's text for the index, or "" for binary, unreadable and oversized files
+func (ix *Index) readText(path string, size int64) string {
+	limit := ix.MaxFileSize
+	if limit <= 0 {
+		limit = DefaultMaxFileSize
+	}
+	if size > limit {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || IsBinary(data) {
+		return ""
+	}
+	return strings.ToValidUTF8(string(data), " ")
+}
+
+// cleanTree returns the tree of a git working tree with nothing changed or untracked, or ""
+// when it has changes or no commits. ok is false when root is not a git repository.
+func cleanTree(ctx context.Context, root string) (tree string, ok bool) {
+	repo, err := gitinfo.Open(ctx, root)
+	if err != nil {
+		return "", false
+	}
+	defer repo.Close()
+
+	status, err := repo.Status(ctx)
+	if err != nil || status.Dirty() || status.Commit == "" {
+		return "", true
+	}
+	tree, err = repo.Tree(ctx, "HEAD")
+	if err != nil {
+		return "", true
+	}
+	return tree, true
+}
@@ -0,0 +1,141 @@
+package search
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+)
+
+// git runs a git command in dir with a fixed identity
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Ada", "GIT_AUTHOR_EMAIL=ada@example.com",
+		"GIT_COMMITTER_NAME=Ada", "GIT_COMMITTER_EMAIL=ada@example.com", "GIT_CONFIG_GLOBAL=/dev/null")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
+
+func openIndex(t *testing.T) *Index {
+	t.Helper()
+	db, err := ecosystem.NewEcosystemDB(ecosystem.DatabaseConfig{
+		ToolName:     ecosystem.ToolWherewasi,
+		FallbackPath: filepath.Join(t.TempDir(), "index.sqlite"),
+		ForceLocal:   true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewIndex(db)
+}
+
+func TestIndex(t *testing.T) {
+	repo := t.TempDir()
+	writeTree(t, repo, map[string]string{
+		".gitignore":    "build/\n",
+		"main.go":       "package main\n\n// Pull the Ripcord\nfunc ripcord() {}\n",
+		"docs/guide.md": "the ripcord (a.k.a. cord)\n",
+		"build/out.txt": "ripcord\n",
+		"image.png":     "ripcord\x00\x01",
+		"notes.txt":     "nothing here\n",
+	})
+	git(t, repo, "init", "-q")
+	git(t, repo, "add", ".")
+	git(t, repo, "commit", "-q", "-m", "Initial commit")
+	plain := t.TempDir()
+	writeTree(t, plain, map[string]string{"todo.txt": "pack the ripcord\n"})
+
+	index := openIndex(t)
+	ctx := context.Background()
+	targets := []Target{{Project: "api", Root: repo}, {Project: "scratch", Root: plain}}
+
+	indexed, results, err := index.Refresh(ctx, targets)
+	if err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	if !reflect.DeepEqual(indexed, []bool{true, false}) {
+		t.Errorf("Expected only the git repository to be indexed, got %v", indexed)
+	}
+	if len(results) != 1 || results[0].Added != 5 || results[0].Unchanged {
+		t.Errorf("Expected five files added, got %+v", results)
+	}
+
+	query := Query{Pattern: "ripcord"}
+	want, err := Search(ctx, targets, query, Options{})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	hits, err := index.Search(ctx, targets, query, Options{})
+	if err != nil {
+		t.Fatalf("Indexed search failed: %v", err)
+	}
+	if !reflect.DeepEqual(hits, want) {
+		t.Errorf("Expected the indexed search to match a full search:\n%+v\n%+v", hits, want)
+	}
+	if got := files(hits); !reflect.DeepEqual(got, []string{"api:docs/guide.md", "api:main.go", "api:main.go", "scratch:todo.txt"}) {
+		t.Errorf("Unexpected hits %v", got)
+	}
+
+	// A clean tree that was indexed is not walked again
+	if _, results, _ := index.Refresh(ctx, targets[:1]); len(results) != 1 || !results[0].Unchanged {
+		t.Errorf("Expected an unchanged tree to skip the walk, got %+v", results)
+	}
+
+	// Only changed files are read again
+	writeTree(t, repo, map[string]string{"notes.txt": "remember the ripcord\n", "new.go": "package ripcord\n"})
+	os.Remove(filepath.Join(repo, "docs", "guide.md"))
+	if _, results, _ := index.Refresh(ctx, targets[:1]); len(results) != 1 || results[0] != (RefreshResult{Project: "api", Added: 1, Updated: 1, Removed: 1}) {
+		t.Errorf("Expected one file added, updated and removed, got %+v", results)
+	}
+	hits, err = index.Search(ctx, targets[:1], Query{Pattern: `remember|package ripcord`, Regex: true}, Options{})
+	if err != nil || !reflect.DeepEqual(files(hits), []string{"api:new.go", "api:notes.txt"}) {
+		t.Errorf("Expected a regex without required text to search every indexed file, got %v, %v", files(hits), err)
+	}
+
+	// The uncommitted changes keep the tree from being trusted
+	projects, err := index.db.IndexedProjects()
+	if err != nil || len(projects) != 1 || projects[0].Tree != "" || projects[0].Files != 5 || index.UpToDate(ctx, projects[0]) {
+		t.Errorf("Expected a dirty project with five files, got %+v, %v", projects, err)
+	}
+	git(t, repo, "add", "-A")
+	git(t, repo, "commit", "-q", "-m", "Update notes")
+	if _, results, _ := index.Refresh(ctx, targets[:1]); len(results) != 1 || results[0].Unchanged {
+		t.Errorf("Expected a new tree to be walked, got %+v", results)
+	}
+	if projects, _ := index.db.IndexedProjects(); !index.UpToDate(ctx, projects[0]) {
+		t.Errorf("Expected the committed tree to be up to date, got %+v", projects[0])
+	}
+
+	results, err = index.Rebuild(ctx, targets)
+	if err != nil || len(results) != 1 || results[0].Added != 5 {
+		t.Errorf("Expected a rebuild to add every file again, got %+v, %v", results, err)
+	}
+}
+
+func TestQuerySubstrings(t *testing.T) {
+	tests := []struct {
+		query Query
+		want  []string
+	}{
+		{Query{Pattern: "a.b(c"}, []string{"a.b(c"}},
+		{Query{Pattern: `func (main|init)\(`, Regex: true}, []string{"func ", "("}},
+		// Case-folded literals come back upper case; the index ignores case anyway
+		{Query{Pattern: `(?i)parse(Args)+\d{2,}x?`, Regex: true}, []string{"PARSE", "ARGS"}},
+		{Query{Pattern: `foo|bar`, Regex: true}, nil},
+		{Query{Pattern: `unclosed(`, Regex: true}, nil},
+		{Query{}, nil},
+	}
+	for _, tt := range tests {
+		if got := tt.query.Substrings(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Substrings(%+v) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
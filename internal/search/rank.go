@@ -0,0 +1,162 @@
+package search
+
+import (
+	"context"
+	"math"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/gitinfo"
+)
+
+// Weights of the ranking signals; a hit's score is their weighted sum, each signal being
+// between 0 and 1
+const (
+	weightDensity   = 3.0
+	weightEntry     = 1.0
+	weightModified  = 0.5
+	weightCommitted = 0.75
+	weightProject   = 1.0
+	weightCurrent   = 1.5
+)
+
+// RecencyHalfLife is how long ago an edit, commit or project activity counts half as much
+// as one just now
+const RecencyHalfLife = 7 * 24 * time.Hour
+
+// entryPoints are file names that describe or start a project
+var entryPoints = map[string]bool{
+	"main.go": true, "main.rs": true, "lib.rs": true, "main.py": true, "__main__.py": true,
+	"app.py": true, "index.js": true, "index.ts": true, "main.js": true, "main.ts": true,
+	"main.c": true, "main.cpp": true, "main.swift": true, "main.zig": true,
+}
+
+// ProjectSignals is what ranking knows about a searched project
+type ProjectSignals struct {
+	// Current marks the project the search runs in
+	Current bool
+	// LastActivity is when the project was last worked on, or zero when unknown
+	LastActivity time.Time
+	// Committed maps files, relative to the project root, to when a recent commit last touched them
+	Committed map[string]time.Time
+}
+
+// Rank orders hits most relevant first and sets their Score. A file scores for its match
+// density, being a README or entry point, and being recently modified or committed; its
+// project scores for recent activity and for being the current one. Hits in the same file
+// share a score and stay together in line order.
+func Rank(hits []Hit, signals map[string]ProjectSignals, now time.Time) []Hit {
+	type fileKey struct{ project, file string }
+	matches := make(map[fileKey]int)
+	for _, hit := range hits {
+		matches[fileKey{hit.Project, hit.File}]++
+	}
+
+	ranked := append([]Hit(nil), hits...)
+	for i := range ranked {
+		hit := &ranked[i]
+		project := signals[hit.Project]
+		score := weightDensity * density(matches[fileKey{hit.Project, hit.File}], hit.FileLines)
+		if isEntryPoint(hit.File) {
+			score += weightEntry
+		}
+		score += weightModified * recency(hit.FileModTime, now)
+		score += weightCommitted * recency(project.Committed[hit.File], now)
+		score += weightProject * recency(project.LastActivity, now)
+		if project.Current {
+			score += weightCurrent
+		}
+		hit.Score = score
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Project != b.Project {
+			return a.Project < b.Project
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return ranked
+}
+
+// CommitTimes maps the files changed by commits since a time to when they were last
+// committed, or returns nil when root is not a git repository
+func CommitTimes(ctx context.Context, root string, since time.Time, limit int) map[string]time.Time {
+	repo, err := gitinfo.Open(ctx, root)
+	if err != nil {
+		return nil
+	}
+	defer repo.Close()
+	commits, err := repo.Log(ctx, gitinfo.LogOptions{Since: since, Limit: limit, Files: true})
+	if err != nil {
+		return nil
+	}
+
+	// Paths are relative to the top of the repository, which may be above root
+	prefix := ""
+	if rel, err := relativeTo(repo.Root(), root); err == nil && rel != "." {
+		prefix = rel + "/"
+	}
+	times := make(map[string]time.Time)
+	for _, commit := range commits {
+		for _, file := range commit.Files {
+			if !strings.HasPrefix(file, prefix) {
+				continue
+			}
+			file = strings.TrimPrefix(file, prefix)
+			// Commits are newest first, so the first time seen is the latest
+			if _, ok := times[file]; !ok {
+				times[file] = commit.Date
+			}
+		}
+	}
+	return times
+}
+
+// relativeTo is target relative to base with forward slashes, resolving symlinks in both
+func relativeTo(base, target string) (string, error) {
+	if resolved, err := filepath.EvalSymlinks(base); err == nil {
+		base = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+	rel, err := filepath.Rel(base, target)
+	return filepath.ToSlash(rel), err
+}
+
+// density is how concentrated the matches are in a file: a few matches in a short file
+// score as high as many in a long one
+func density(matches, lines int) float64 {
+	if lines < 1 {
+		lines = 1
+	}
+	return math.Min(1, float64(matches)/math.Sqrt(float64(lines)))
+}
+
+// recency decays from 1 for now by half every RecencyHalfLife; zero times score 0
+func recency(t, now time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	age := now.Sub(t)
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, float64(age)/float64(RecencyHalfLife))
+}
+
+// isEntryPoint reports whether a file is a README or a conventional program entry point
+func isEntryPoint(file string) bool {
+	name := path.Base(file)
+	return strings.HasPrefix(strings.ToLower(name), "readme") || entryPoints[name]
+}
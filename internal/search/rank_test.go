@@ -0,0 +1,68 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRank(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-365 * 24 * time.Hour)
+	hit := func(project, file string, line, lines int, modTime time.Time) Hit {
+		return Hit{Project: project, File: file, Line: line, FileLines: lines, FileModTime: modTime}
+	}
+	hits := []Hit{
+		// An alphabetically early, long-idle project with one match in a long file
+		hit("aardvark", "docs/history.md", 900, 1000, old),
+		hit("api", "internal/big.go", 10, 900, old),
+		hit("api", "README.md", 3, 9, old),
+		hit("api", "internal/big.go", 20, 900, old),
+		hit("web", "src/app.ts", 1, 100, now),
+		hit("web", "src/old.ts", 1, 100, old),
+	}
+	signals := map[string]ProjectSignals{
+		"api": {Current: true},
+		"web": {LastActivity: now.Add(-time.Hour), Committed: map[string]time.Time{"src/old.ts": now}},
+	}
+
+	ranked := Rank(hits, signals, now)
+	if got := files(ranked); !reflect.DeepEqual(got, []string{
+		"api:README.md", "web:src/old.ts", "web:src/app.ts", "api:internal/big.go", "api:internal/big.go", "aardvark:docs/history.md",
+	}) {
+		t.Errorf("Unexpected ranking %v", got)
+	}
+	if ranked[3].Line != 10 || ranked[4].Line != 20 {
+		t.Errorf("Expected hits in one file to stay in line order, got %+v", ranked[3:5])
+	}
+	for i := 1; i < len(ranked); i++ {
+		if ranked[i].Score > ranked[i-1].Score {
+			t.Errorf("Expected scores in descending order, got %v after %v", ranked[i].Score, ranked[i-1].Score)
+		}
+	}
+	if hits[0].Score != 0 {
+		t.Error("Expected Rank not to modify its input")
+	}
+}
+
+func TestCommitTimes(t *testing.T) {
+	repo := t.TempDir()
+	writeTree(t, repo, map[string]string{"app/main.go": "package main\n", "README.md": "# repo\n"})
+	git(t, repo, "init", "-q")
+	git(t, repo, "add", ".")
+	git(t, repo, "commit", "-q", "-m", "Initial commit")
+
+	ctx := context.Background()
+	times := CommitTimes(ctx, filepath.Join(repo, "app"), time.Now().Add(-time.Hour), 10)
+	if len(times) != 1 || time.Since(times["main.go"]) > time.Minute {
+		t.Errorf("Expected paths relative to the project inside the repository, got %v", times)
+	}
+	if times := CommitTimes(ctx, repo, time.Now().Add(time.Hour), 10); len(times) != 0 {
+		t.Errorf("Expected no files committed in the future, got %v", times)
+	}
+	if times := CommitTimes(ctx, t.TempDir(), time.Time{}, 10); times != nil {
+		t.Errorf("Expected nil outside a repository, got %v", times)
+	}
+}
@@ -0,0 +1,349 @@
+// Package search finds text in project trees without external tools. It walks
+// repositories concurrently, honours .gitignore and .wherewasiignore files, and
+// skips binary and oversized files.
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/QRY91/wherewasi/internal/ignore"
+)
+
+// IgnoreFile holds search-only ignore patterns, in gitignore syntax, in any project directory
+const IgnoreFile = ".wherewasiignore"
+
+// DefaultExcludes are skipped unless an ignore file re-includes them with "!"
+var DefaultExcludes = []string{"node_modules/", "vendor/"}
+
+// Defaults for Options
+const (
+	DefaultMaxFileSize = 1 << 20
+	DefaultMaxPerFile  = 20
+)
+
+// binarySniffLength is how much of a file is checked for NUL bytes, as git does
+const binarySniffLength = 8000
+
+// Query is what to look for
+type Query struct {
+	Pattern string
+	// Regex treats Pattern as a Go regular expression instead of literal text
+	Regex bool
+	// CaseSensitive disables the default case-insensitive matching
+	CaseSensitive bool
+}
+
+// Compile turns the query into a regular expression
+func (q Query) Compile() (*regexp.Regexp, error) {
+	if q.Pattern == "" {
+		return nil, errors.New("empty search pattern")
+	}
+	pattern := q.Pattern
+	if !q.Regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !q.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search pattern %q: %w", q.Pattern, err)
+	}
+	return re, nil
+}
+
+// Substrings returns text every match contains, for narrowing the files to search with an
+// index. A regular expression without such text, e.g. "foo|bar", returns none.
+func (q Query) Substrings() []string {
+	if q.Pattern == "" {
+		return nil
+	}
+	if !q.Regex {
+		return []string{q.Pattern}
+	}
+	re, err := syntax.Parse(q.Pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	return requiredLiterals(re.Simplify())
+}
+
+// requiredLiterals collects the literals a match of re cannot do without
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return requiredLiterals(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var literals []string
+		for _, sub := range re.Sub {
+			literals = append(literals, requiredLiterals(sub)...)
+		}
+		return literals
+	}
+	return nil
+}
+
+// Options tunes a search; zero values use the defaults
+type Options struct {
+	// Context is how many lines before and after each hit to return
+	Context int
+	// MaxPerFile caps hits per file (default DefaultMaxPerFile)
+	MaxPerFile int
+	// MaxFileSize skips larger files (default DefaultMaxFileSize bytes)
+	MaxFileSize int64
+	// Workers is how many files are searched in parallel (default GOMAXPROCS)
+	Workers int
+}
+
+// Target is a project tree to search
+type Target struct {
+	Project string
+	Root    string
+	// Files, when not nil, are the files to search, relative to Root, instead of walking it
+	Files []string
+}
+
+// Hit is a matching line
+type Hit struct {
+	Project string
+	// File is relative to the target root, with forward slashes
+	File string
+	// Line and Column are 1-based; Column counts characters
+	Line    int
+	Column  int
+	Snippet string
+	// Before and After are up to Options.Context surrounding lines
+	Before []string
+	After  []string
+	// FileLines and FileModTime describe the whole file, for ranking
+	FileLines   int
+	FileModTime time.Time
+	// Score is set by Rank; higher is more relevant
+	Score float64
+}
+
+// file is a file queued for searching
+type file struct {
+	target int
+	rel    string
+	path   string
+}
+
+// Search looks for query in every target and returns hits ordered by target, file and line.
+// It stops early with ctx's error, returning the hits found so far.
+func Search(ctx context.Context, targets []Target, query Query, opts Options) ([]Hit, error) {
+	re, err := query.Compile()
+	if err != nil {
+		return nil, err
+	}
+	if opts.MaxPerFile <= 0 {
+		opts.MaxPerFile = DefaultMaxPerFile
+	}
+	if opts.MaxFileSize <= 0 {
+		opts.MaxFileSize = DefaultMaxFileSize
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+
+	files := make(chan file)
+	var walkers sync.WaitGroup
+	for i, target := range targets {
+		walkers.Add(1)
+		go func(i int, target Target) {
+			defer walkers.Done()
+			send := func(rel, path string) {
+				select {
+				case files <- file{target: i, rel: rel, path: path}:
+				case <-ctx.Done():
+				}
+			}
+			if target.Files == nil {
+				Walk(ctx, target.Root, send)
+				return
+			}
+			for _, rel := range target.Files {
+				if ctx.Err() != nil {
+					return
+				}
+				send(rel, filepath.Join(target.Root, filepath.FromSlash(rel)))
+			}
+		}(i, target)
+	}
+	go func() {
+		walkers.Wait()
+		close(files)
+	}()
+
+	type result struct {
+		target int
+		hits   []Hit
+	}
+	var mu sync.Mutex
+	var results []result
+	var workers sync.WaitGroup
+	for w := 0; w < opts.Workers; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for f := range files {
+				if ctx.Err() != nil {
+					continue
+				}
+				hits := searchFile(f.path, re, opts)
+				if len(hits) == 0 {
+					continue
+				}
+				for i := range hits {
+					hits[i].Project = targets[f.target].Project
+					hits[i].File = f.rel
+				}
+				mu.Lock()
+				results = append(results, result{target: f.target, hits: hits})
+				mu.Unlock()
+			}
+		}()
+	}
+	workers.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].target != results[j].target {
+			return results[i].target < results[j].target
+		}
+		return results[i].hits[0].File < results[j].hits[0].File
+	})
+	var hits []Hit
+	for _, r := range results {
+		hits = append(hits, r.hits...)
+	}
+	return hits, ctx.Err()
+}
+
+// Walk calls fn for every regular file below root that is not ignored; binary files are
+// not filtered here. rel is relative to root with forward slashes. Walking stops when ctx is done.
+func Walk(ctx context.Context, root string, fn func(rel, path string)) {
+	matcher := ignore.New(root)
+	matcher.AddPatterns("", DefaultExcludes...)
+	// Loaded again after the defaults so "!vendor/" can re-include them
+	matcher.AddFile("", ".gitignore")
+	matcher.AddFile("", IgnoreFile)
+
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			// Unreadable directories are skipped rather than failing the search
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if entry.IsDir() {
+			if matcher.Match(rel, true) {
+				return filepath.SkipDir
+			}
+			matcher.AddFile(rel, ".gitignore")
+			matcher.AddFile(rel, IgnoreFile)
+			return nil
+		}
+		if !entry.Type().IsRegular() || matcher.Match(rel, false) {
+			return nil
+		}
+		fn(rel, path)
+		return nil
+	})
+}
+
+// searchFile returns the matching lines of a text file
+func searchFile(path string, re *regexp.Regexp, opts Options) []Hit {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.Size() > opts.MaxFileSize {
+		return nil
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil || IsBinary(data) {
+		return nil
+	}
+	hits := matchLines(data, re, opts)
+	lines := bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		lines++
+	}
+	for i := range hits {
+		hits[i].FileLines = lines
+		hits[i].FileModTime = info.ModTime()
+	}
+	return hits
+}
+
+// IsBinary reports whether data looks like a binary file: a NUL byte near the start
+func IsBinary(data []byte) bool {
+	if len(data) > binarySniffLength {
+		data = data[:binarySniffLength]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// matchLines finds matches line by line, keeping opts.Context lines around each
+func matchLines(data []byte, re *regexp.Regexp, opts Options) []Hit {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSuffix(scanner.Text(), "\r"))
+	}
+
+	var hits []Hit
+	for i, line := range lines {
+		loc := re.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+		hit := Hit{
+			Line:    i + 1,
+			Column:  utf8.RuneCountInString(line[:loc[0]]) + 1,
+			Snippet: strings.TrimSpace(line),
+		}
+		if opts.Context > 0 {
+			hit.Before = append([]string{}, lines[max(0, i-opts.Context):i]...)
+			hit.After = append([]string{}, lines[i+1:min(len(lines), i+1+opts.Context)]...)
+		}
+		hits = append(hits, hit)
+		if len(hits) >= opts.MaxPerFile {
+			break
+		}
+	}
+	return hits
+}
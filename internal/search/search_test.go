@@ -0,0 +1,109 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTree creates files below root from a map of relative path to content
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func files(hits []Hit) []string {
+	var result []string
+	for _, hit := range hits {
+		result = append(result, hit.Project+":"+hit.File)
+	}
+	return result
+}
+
+func TestSearch(t *testing.T) {
+	api := t.TempDir()
+	web := t.TempDir()
+	writeTree(t, api, map[string]string{
+		".gitignore":              "build/\n*.log\n",
+		".wherewasiignore":        "fixtures/\n",
+		"main.go":                 "package main\n\n// Pull the Ripcord\nfunc ripcord() {}\n",
+		"docs/guide.md":           "First line\nthe ripcord (a.k.a. cord)\nLast line\n",
+		"build/out.txt":           "ripcord\n",
+		"debug.log":               "ripcord\n",
+		"fixtures/sample.txt":     "ripcord\n",
+		"node_modules/lib/x.js":   "ripcord\n",
+		"vendor/dep/dep.go":       "ripcord\n",
+		"image.png":               "ripcord\x00\x01",
+		"nested/.gitignore":       "secret.txt\n",
+		"nested/secret.txt":       "ripcord\n",
+		"nested/visible.txt":      "ripcord",
+		"nested/.wherewasiignore": "*.tmp\n",
+		"nested/scratch.tmp":      "ripcord\n",
+	})
+	writeTree(t, web, map[string]string{
+		".wherewasiignore":  "!vendor/\n",
+		"vendor/kept.js":    "ripcord()\n",
+		"src/app.ts":        "const a = 1\nconst ripCord = 2\n",
+		"src/unrelated.txt": "nothing here\n",
+	})
+	targets := []Target{{Project: "api", Root: api}, {Project: "web", Root: web}}
+	ctx := context.Background()
+
+	hits, err := Search(ctx, targets, Query{Pattern: "ripcord"}, Options{Context: 1})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	want := []string{"api:docs/guide.md", "api:main.go", "api:main.go", "api:nested/visible.txt", "web:src/app.ts", "web:vendor/kept.js"}
+	if got := files(hits); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	guide := hits[0]
+	if guide.Line != 2 || guide.Column != 5 || guide.Snippet != "the ripcord (a.k.a. cord)" ||
+		!reflect.DeepEqual(guide.Before, []string{"First line"}) || !reflect.DeepEqual(guide.After, []string{"Last line"}) {
+		t.Errorf("Expected a typed hit with surrounding lines, got %+v", guide)
+	}
+	if hits[1].Line != 3 || hits[1].Column != 13 || hits[2].Line != 4 {
+		t.Errorf("Expected both main.go lines, got %+v and %+v", hits[1], hits[2])
+	}
+
+	sensitive, err := Search(ctx, targets, Query{Pattern: "Ripcord", CaseSensitive: true}, Options{})
+	if err != nil || !reflect.DeepEqual(files(sensitive), []string{"api:main.go"}) {
+		t.Errorf("Expected only the capitalized match, got %v, %v", files(sensitive), err)
+	}
+	literal, err := Search(ctx, targets, Query{Pattern: "a.k.a."}, Options{})
+	if err != nil || !reflect.DeepEqual(files(literal), []string{"api:docs/guide.md"}) {
+		t.Errorf("Expected a literal match of dots, got %v, %v", files(literal), err)
+	}
+	regex, err := Search(ctx, targets, Query{Pattern: `rip\w+\(`, Regex: true}, Options{})
+	if err != nil || !reflect.DeepEqual(files(regex), []string{"api:main.go", "web:vendor/kept.js"}) {
+		t.Errorf("Expected regex matches, got %v, %v", files(regex), err)
+	}
+	if _, err := Search(ctx, targets, Query{Pattern: "rip(", Regex: true}, Options{}); err == nil {
+		t.Error("Expected an invalid regex to be rejected")
+	}
+	if limited, _ := Search(ctx, targets[:1], Query{Pattern: "ripcord"}, Options{MaxPerFile: 1, MaxFileSize: 20}); !reflect.DeepEqual(files(limited), []string{"api:nested/visible.txt"}) {
+		t.Errorf("Expected large files skipped, got %v", files(limited))
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := Search(cancelled, targets, Query{Pattern: "ripcord"}, Options{}); err == nil {
+		t.Error("Expected a cancelled search to report the context error")
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	if IsBinary([]byte("plain text\n")) || !IsBinary([]byte("PNG\x00data")) {
+		t.Error("Expected NUL bytes to mark binary files")
+	}
+}
@@ -0,0 +1,161 @@
+// Package symbols finds where Go identifiers are declared, so a context can be focused on a
+// function or type by name rather than by file.
+package symbols
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/QRY91/wherewasi/internal/search"
+)
+
+// Declaration kinds
+const (
+	KindFunc   = "func"
+	KindMethod = "method"
+	KindType   = "type"
+	KindVar    = "var"
+	KindConst  = "const"
+)
+
+// Declaration is where a package-level identifier or method is declared
+type Declaration struct {
+	// Name is the identifier, or "Type.Method" for methods
+	Name string
+	Kind string
+	// File is relative to the searched root, with forward slashes
+	File string
+	Line int
+	// Signature is the first line of the declaration
+	Signature string
+}
+
+// Test reports whether the declaration is in a _test.go file
+func (d Declaration) Test() bool {
+	return strings.HasSuffix(d.File, "_test.go")
+}
+
+// Find returns the declarations of name in the Go files below root, honouring the same ignore
+// files as searching. name is a package-level func, type, var or const, or a method given as
+// "Type.Method" or just "Method". Declarations outside tests come first. Files that do not parse
+// are skipped.
+func Find(ctx context.Context, root, name string) ([]Declaration, error) {
+	typeName, ident, isMethod := strings.Cut(name, ".")
+	if !isMethod {
+		ident, typeName = typeName, ""
+	}
+	if !token.IsIdentifier(ident) || typeName != "" && !token.IsIdentifier(typeName) {
+		return nil, nil
+	}
+
+	var found []Declaration
+	search.Walk(ctx, root, func(rel, path string) {
+		if !strings.HasSuffix(rel, ".go") {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || !bytes.Contains(data, []byte(ident)) {
+			return
+		}
+		found = append(found, findInFile(rel, data, typeName, ident)...)
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Test() != found[j].Test() {
+			return !found[i].Test()
+		}
+		if found[i].File != found[j].File {
+			return found[i].File < found[j].File
+		}
+		return found[i].Line < found[j].Line
+	})
+	return found, nil
+}
+
+// findInFile returns the declarations of ident in one file; typeName restricts them to methods
+// of that type
+func findInFile(rel string, data []byte, typeName, ident string) []Declaration {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, rel, data, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(string(data), "\n")
+	declaration := func(name, kind string, pos token.Pos) Declaration {
+		line := fset.Position(pos).Line
+		return Declaration{Name: name, Kind: kind, File: rel, Line: line, Signature: strings.TrimSpace(lines[line-1])}
+	}
+
+	var found []Declaration
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Name.Name != ident {
+				continue
+			}
+			if decl.Recv == nil {
+				if typeName == "" {
+					found = append(found, declaration(ident, KindFunc, decl.Pos()))
+				}
+				continue
+			}
+			receiver := receiverType(decl.Recv.List[0].Type)
+			if typeName == "" || typeName == receiver {
+				found = append(found, declaration(receiver+"."+ident, KindMethod, decl.Pos()))
+			}
+		case *ast.GenDecl:
+			if typeName != "" {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.Name == ident {
+						found = append(found, declaration(ident, KindType, spec.Pos()))
+					}
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						if name.Name != ident {
+							continue
+						}
+						kind := KindVar
+						if decl.Tok == token.CONST {
+							kind = KindConst
+						}
+						found = append(found, declaration(ident, kind, name.Pos()))
+					}
+				}
+			}
+		}
+	}
+	return found
+}
+
+// receiverType is the type name of a method receiver such as T, *T or *T[K]
+func receiverType(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
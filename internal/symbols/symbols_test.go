@@ -0,0 +1,80 @@
+package symbols
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"store.go": `package store
+
+// Store keeps things
+type Store[K comparable] struct{}
+
+const (
+	Limit = 10
+	other = 1
+)
+
+var Default, Backup = New(), New()
+
+func New() *Store[string] { return nil }
+
+func (s *Store[K]) Get(key K) {}
+`,
+		"cache/cache.go":       "package cache\n\ntype Cache struct{}\n\nfunc (c Cache) Get() {}\n",
+		"store_test.go":        "package store\n\nfunc New() {}\n",
+		"broken.go":            "package store\n\nfunc New( {\n",
+		"vendor/dep/dep.go":    "package dep\n\nfunc New() {}\n",
+		"notes/New.txt":        "func New() {}\n",
+		"cache/cache_extra.go": "package cache\n\n// New is mentioned but not declared\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+	find := func(name string) []string {
+		t.Helper()
+		found, err := Find(ctx, root, name)
+		if err != nil {
+			t.Fatalf("Find(%q) failed: %v", name, err)
+		}
+		var result []string
+		for _, d := range found {
+			result = append(result, d.Kind+" "+d.Name+" "+d.File)
+		}
+		return result
+	}
+
+	if got := find("New"); !reflect.DeepEqual(got, []string{"func New store.go", "func New store_test.go"}) {
+		t.Errorf("Expected the declaration before the test one, got %v", got)
+	}
+	if got := find("Get"); !reflect.DeepEqual(got, []string{"method Cache.Get cache/cache.go", "method Store.Get store.go"}) {
+		t.Errorf("Expected methods of every type, got %v", got)
+	}
+	if got := find("Store.Get"); !reflect.DeepEqual(got, []string{"method Store.Get store.go"}) {
+		t.Errorf("Expected only the method of Store, got %v", got)
+	}
+	if got := find("Backup"); !reflect.DeepEqual(got, []string{"var Backup store.go"}) {
+		t.Errorf("Expected the second name of a var spec, got %v", got)
+	}
+	if got := find("Limit"); !reflect.DeepEqual(got, []string{"const Limit store.go"}) {
+		t.Errorf("Expected the constant, got %v", got)
+	}
+	if got := find("not-an-identifier"); got != nil {
+		t.Errorf("Expected nothing for an invalid name, got %v", got)
+	}
+
+	found, _ := Find(ctx, root, "Store")
+	if len(found) != 1 || found[0].Line != 4 || found[0].Signature != "type Store[K comparable] struct{}" {
+		t.Errorf("Expected the type's line and signature, got %+v", found)
+	}
+}
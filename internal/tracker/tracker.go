@@ -0,0 +1,210 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/QRY91/wherewasi/internal/workspace"
+)
+
+// maxCommitsPerPoll caps how many new commits are recorded for a single HEAD move
+const maxCommitsPerPoll = 20
+
+// Config holds the settings for a tracking run
+type Config struct {
+	Workspace workspace.Options
+	Interval  time.Duration
+	Logger    *log.Logger
+}
+
+// Tracker polls ecosystem projects for commits and branch switches, and journals
+// file edits through a filesystem watcher
+type Tracker struct {
+	db        ecosystem.ContextStore
+	workspace workspace.Options
+	interval  time.Duration
+	logger    *log.Logger
+	states    map[string]*projectState
+	watcher   *Watcher
+}
+
+// projectState is the last observed git state of a single project
+type projectState struct {
+	branch string
+	commit string
+}
+
+// New creates a tracker writing to the given database
+func New(db ecosystem.ContextStore, config Config) *Tracker {
+	logger := config.Logger
+	if logger == nil {
+		logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	interval := config.Interval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	return &Tracker{
+		db:        db,
+		workspace: config.Workspace,
+		interval:  interval,
+		logger:    logger,
+		states:    make(map[string]*projectState),
+	}
+}
+
+// Run polls until the context is cancelled
+func (t *Tracker) Run(ctx context.Context) error {
+	var roots []string
+	for _, root := range t.workspace.Roots {
+		roots = append(roots, root.Path)
+	}
+	t.logger.Printf("tracking %s every %s", strings.Join(roots, ", "), t.interval)
+
+	watcher, err := NewWatcher(t.db, t.logger)
+	if err != nil {
+		// Commits and branch switches are still recorded without edit journaling
+		t.logger.Printf("file watching disabled: %v", err)
+	} else {
+		t.watcher = watcher
+		defer watcher.Close()
+		go watcher.Run(ctx)
+	}
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.Poll(); err != nil {
+			t.logger.Printf("poll failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			t.logger.Printf("tracking stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll inspects every project once and records what changed since the previous poll.
+// Discovery runs on every poll so new repositories are picked up without a restart, and
+// projects registered with 'wherewasi projects add' are tracked wherever they live.
+func (t *Tracker) Poll() error {
+	projects, err := workspace.Discover(t.workspace)
+	if err != nil {
+		// Unreadable roots are logged while the rest of the ecosystem is still tracked
+		t.logger.Printf("discovery: %v", err)
+	}
+	if err := workspace.Sync(t.db, projects); err != nil {
+		return err
+	}
+	registered, err := workspace.Registered(t.db, projects)
+	if err != nil {
+		t.logger.Printf("registered projects: %v", err)
+	}
+	projects = append(projects, registered...)
+
+	for _, project := range projects {
+		if t.watcher != nil && !t.watcher.Watching(project.Path) {
+			if err := t.watcher.AddProject(project.Name, project.Path); err != nil {
+				t.logger.Printf("%s: %v", project.Name, err)
+			}
+		}
+		if err := t.pollProject(project); err != nil {
+			t.logger.Printf("%s: %v", project.Name, err)
+		}
+	}
+	return nil
+}
+
+func (t *Tracker) pollProject(project workspace.Project) error {
+	name, path := project.Name, project.Path
+
+	current := readProjectState(path)
+
+	previous, seen := t.states[path]
+	t.states[path] = current
+	if !seen {
+		// First observation only establishes a baseline
+		return nil
+	}
+
+	var activities []ecosystem.ProjectActivityMessageData
+
+	if previous.branch != current.branch {
+		activities = append(activities, ecosystem.ProjectActivityMessageData{
+			Activity: ecosystem.ActivityBranchSwitch,
+			Detail:   fmt.Sprintf("%s → %s", previous.branch, current.branch),
+		})
+	} else if previous.commit != current.commit && previous.commit != "" {
+		for _, commit := range newCommits(path, previous.commit, current.commit) {
+			activities = append(activities, ecosystem.ProjectActivityMessageData{
+				Activity: ecosystem.ActivityCommit,
+				Detail:   commit,
+			})
+		}
+	}
+
+	if len(activities) == 0 {
+		return nil
+	}
+
+	branch, commit := current.branch, current.commit
+	for _, activity := range activities {
+		activity.Project = name
+		activity.Tool = ecosystem.ToolWherewasi
+		activity.GitBranch = &branch
+		activity.GitCommit = &commit
+		if err := t.db.RecordProjectActivity(activity); err != nil {
+			return err
+		}
+		t.logger.Printf("%s: %s %s", name, activity.Activity, activity.Detail)
+	}
+
+	return t.db.TrackProject(name, path, ecosystem.ToolWherewasi, true)
+}
+
+// readProjectState captures the current branch and HEAD commit
+func readProjectState(path string) *projectState {
+	state := &projectState{}
+
+	// symbolic-ref fails on a detached HEAD, which is tracked as its own "branch"
+	branch, err := gitOutput(path, "symbolic-ref", "--short", "-q", "HEAD")
+	if err != nil {
+		branch = "HEAD"
+	}
+	state.branch = branch
+
+	// An empty repository has no HEAD commit yet
+	state.commit, _ = gitOutput(path, "rev-parse", "HEAD")
+
+	return state
+}
+
+// newCommits lists commits reachable from to but not from, oldest first
+func newCommits(path, from, to string) []string {
+	output, err := gitOutput(path, "log", "--reverse", "--format=%h %s",
+		"-n", fmt.Sprintf("%d", maxCommitsPerPoll), from+".."+to)
+	if err != nil || output == "" {
+		return []string{to[:min(len(to), 7)]}
+	}
+	return strings.Split(output, "\n")
+}
+
+func gitOutput(path string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", path}, args...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
@@ -0,0 +1,79 @@
+package tracker
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/config"
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/QRY91/wherewasi/internal/workspace"
+)
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.email=test@example.com", "-c", "user.name=Test"}, args...)
+	if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
+
+func initRepo(t *testing.T, dir string) {
+	t.Helper()
+	git(t, "", "init", "-q", "-b", "main", dir)
+	git(t, dir, "commit", "-q", "--allow-empty", "-m", "Initial commit")
+}
+
+func TestPollRecordsActivity(t *testing.T) {
+	db := openTestDB(t)
+	root := t.TempDir()
+	api := filepath.Join(root, "api")
+	initRepo(t, api)
+	// Registered outside the roots, under a name other than its directory
+	faraway := filepath.Join(t.TempDir(), "checkout")
+	initRepo(t, faraway)
+	if err := db.TrackProject("faraway", faraway, ecosystem.ToolWherewasi, true); err != nil {
+		t.Fatalf("Failed to register project: %v", err)
+	}
+
+	tracker := New(db, Config{
+		Workspace: workspace.Options{Roots: []config.Root{{Path: root}}},
+		Logger:    quietLogger(),
+	})
+	since := time.Now().Add(-time.Minute)
+	activity := func(project string) []string {
+		t.Helper()
+		messages, err := db.GetProjectActivity(project, since, 10)
+		if err != nil {
+			t.Fatalf("Failed to get activity: %v", err)
+		}
+		var data []string
+		for _, message := range messages {
+			data = append(data, message.Data)
+		}
+		return data
+	}
+
+	// The first poll only establishes a baseline
+	if err := tracker.Poll(); err != nil {
+		t.Fatalf("Failed to poll: %v", err)
+	}
+	if got := activity("api"); len(got) != 0 {
+		t.Errorf("Expected no activity after the first poll, got %v", got)
+	}
+
+	git(t, api, "commit", "-q", "--allow-empty", "-m", "Add the parser")
+	git(t, faraway, "checkout", "-q", "-b", "feature")
+	if err := tracker.Poll(); err != nil {
+		t.Fatalf("Failed to poll: %v", err)
+	}
+
+	if got := activity("api"); len(got) != 1 || !strings.Contains(got[0], `"activity":"commit"`) || !strings.Contains(got[0], "Add the parser") {
+		t.Errorf("Expected the new commit recorded, got %v", got)
+	}
+	if got := activity("faraway"); len(got) != 1 || !strings.Contains(got[0], `"activity":"branch_switch"`) || !strings.Contains(got[0], "main → feature") {
+		t.Errorf("Expected the branch switch in the registered project recorded, got %v", got)
+	}
+}
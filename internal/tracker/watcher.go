@@ -0,0 +1,224 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/QRY91/wherewasi/internal/ignore"
+	"github.com/fsnotify/fsnotify"
+)
+
+// flushInterval batches journal writes; repeated events for a file within it are coalesced
+const flushInterval = 2 * time.Second
+
+// Watcher journals file events in ecosystem projects using filesystem notifications
+type Watcher struct {
+	mu       sync.Mutex
+	db       ecosystem.ContextStore
+	logger   *log.Logger
+	fsw      *fsnotify.Watcher
+	projects map[string]*watchedProject
+	pending  map[string]ecosystem.ActivityEvent
+}
+
+// watchedProject is a project root and the ignore rules collected while walking it
+type watchedProject struct {
+	name    string
+	root    string
+	matcher *ignore.Matcher
+}
+
+// NewWatcher creates a watcher writing to the activity journal
+func NewWatcher(db ecosystem.ContextStore, logger *log.Logger) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create filesystem watcher: %w", err)
+	}
+
+	return &Watcher{
+		db:       db,
+		logger:   logger,
+		fsw:      fsw,
+		projects: make(map[string]*watchedProject),
+		pending:  make(map[string]ecosystem.ActivityEvent),
+	}, nil
+}
+
+// Watching reports whether a project root is already being watched
+func (w *Watcher) Watching(root string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, ok := w.projects[root]
+	return ok
+}
+
+// AddProject watches every non-ignored directory of a project, journaling its events under name
+func (w *Watcher) AddProject(name, root string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	project := &watchedProject{
+		name:    name,
+		root:    root,
+		matcher: ignore.New(root),
+	}
+	w.projects[root] = project
+	return w.addTree(project, root)
+}
+
+// addTree registers dir and its non-ignored subdirectories, loading nested .gitignore files on the way.
+// The caller must hold w.mu.
+func (w *Watcher) addTree(project *watchedProject, dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Directories can vanish between the event and the walk
+			return nil
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(project.root, path)
+		if rel != "." {
+			if project.matcher.Match(rel, true) {
+				return filepath.SkipDir
+			}
+			project.matcher.AddFile(rel, ".gitignore")
+		}
+
+		if err := w.fsw.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// Run processes filesystem events until the context is cancelled
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.flush()
+			return nil
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				w.flush()
+				return nil
+			}
+			w.handle(event)
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				w.flush()
+				return nil
+			}
+			w.logger.Printf("watch error: %v", err)
+		case <-ticker.C:
+			w.flush()
+		}
+	}
+}
+
+// Close releases the underlying filesystem watches
+func (w *Watcher) Close() error {
+	return w.fsw.Close()
+}
+
+func (w *Watcher) handle(event fsnotify.Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	project := w.projectFor(event.Name)
+	if project == nil {
+		return
+	}
+
+	rel, err := filepath.Rel(project.root, event.Name)
+	if err != nil {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+
+	var kind string
+	switch {
+	case event.Has(fsnotify.Create):
+		if isDir(event.Name) {
+			if !project.matcher.Match(rel, true) {
+				if err := w.addTree(project, event.Name); err != nil {
+					w.logger.Printf("%s: %v", project.name, err)
+				}
+			}
+			return
+		}
+		kind = ecosystem.EventKindCreate
+	case event.Has(fsnotify.Write):
+		kind = ecosystem.EventKindWrite
+	case event.Has(fsnotify.Remove):
+		kind = ecosystem.EventKindRemove
+	case event.Has(fsnotify.Rename):
+		kind = ecosystem.EventKindRename
+	default:
+		return
+	}
+
+	if filepath.Base(rel) == ".gitignore" {
+		project.matcher.AddFile(filepath.Dir(rel), ".gitignore")
+	}
+	if project.matcher.Match(rel, false) {
+		return
+	}
+
+	w.pending[project.name+"\x00"+rel+"\x00"+kind] = ecosystem.ActivityEvent{
+		Project:   project.name,
+		Path:      rel,
+		Kind:      kind,
+		Timestamp: time.Now(),
+	}
+}
+
+// projectFor finds the watched project containing path
+func (w *Watcher) projectFor(path string) *watchedProject {
+	for root, project := range w.projects {
+		if strings.HasPrefix(path, root+string(filepath.Separator)) {
+			return project
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) flush() {
+	w.mu.Lock()
+	events := make([]ecosystem.ActivityEvent, 0, len(w.pending))
+	for _, event := range w.pending {
+		events = append(events, event)
+	}
+	w.pending = make(map[string]ecosystem.ActivityEvent)
+	w.mu.Unlock()
+
+	if len(events) == 0 {
+		return
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+
+	if err := w.db.RecordActivityEvents(events); err != nil {
+		w.logger.Printf("failed to journal %d event(s): %v", len(events), err)
+	}
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,118 @@
+package tracker
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+)
+
+func openTestDB(t *testing.T) *ecosystem.EcosystemDB {
+	t.Helper()
+	db, err := ecosystem.NewEcosystemDB(ecosystem.DatabaseConfig{
+		ToolName:     ecosystem.ToolWherewasi,
+		FallbackPath: filepath.Join(t.TempDir(), "test.sqlite"),
+		ForceLocal:   true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func quietLogger() *log.Logger {
+	return log.New(io.Discard, "", 0)
+}
+
+// writeFile creates or appends to a file below root, creating its directory
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherJournalsEdits(t *testing.T) {
+	db := openTestDB(t)
+	root := t.TempDir()
+	writeFile(t, root, ".gitignore", "*.log\nbuild/\n")
+	writeFile(t, root, "main.go", "package main\n")
+	writeFile(t, root, "src/lib.go", "package src\n")
+	writeFile(t, root, "build/out.txt", "")
+
+	watcher, err := NewWatcher(db, quietLogger())
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	defer watcher.Close()
+	if err := watcher.AddProject("api", root); err != nil {
+		t.Fatalf("Failed to watch project: %v", err)
+	}
+	if !watcher.Watching(root) {
+		t.Error("Expected the project root to be watched")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(ctx)
+		close(done)
+	}()
+
+	// Ignored files first: events arrive in order, so once the last write is pending these
+	// have been handled too
+	writeFile(t, root, "debug.log", "noise\n")
+	writeFile(t, root, "build/out.txt", "artifact\n")
+	for i := 0; i < 3; i++ {
+		writeFile(t, root, "main.go", "// edit\n")
+	}
+	writeFile(t, root, "src/lib.go", "// edit\n")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		watcher.mu.Lock()
+		_, last := watcher.pending["api\x00src/lib.go\x00"+ecosystem.EventKindWrite]
+		watcher.mu.Unlock()
+		if last {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the write to src/lib.go")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Stopping flushes what is still pending to the journal
+	cancel()
+	<-done
+
+	edits, err := db.GetRecentEdits("api", time.Now().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatalf("Failed to get recent edits: %v", err)
+	}
+	got := make(map[string]int)
+	for _, edit := range edits {
+		got[edit.Path] = edit.Events
+	}
+	// Repeated writes to a file within a flush are journaled once
+	want := map[string]int{"main.go": 1, "src/lib.go": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected journaled edits %v, got %+v", want, edits)
+	}
+}
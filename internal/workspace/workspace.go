@@ -0,0 +1,238 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/QRY91/wherewasi/internal/config"
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/QRY91/wherewasi/internal/ignore"
+)
+
+// DefaultDepth discovers repositories directly inside each root
+const DefaultDepth = 1
+
+// Project is a git repository discovered under an ecosystem root
+type Project struct {
+	Name string
+	Path string
+	Root string
+}
+
+// Options controls discovery; they come from the ecosystem section of config.yaml
+type Options struct {
+	Roots []config.Root
+	// Depth applies to roots without their own depth
+	Depth   int
+	Include []string
+	Exclude []string
+}
+
+// OptionsFromConfig builds discovery options, defaulting to fallbackRoot when no roots are configured
+func OptionsFromConfig(cfg *config.Config, fallbackRoot string) Options {
+	opts := Options{
+		Roots:   cfg.Ecosystem.Roots,
+		Depth:   cfg.Ecosystem.Depth,
+		Include: cfg.Ecosystem.Include,
+		Exclude: cfg.Ecosystem.Exclude,
+	}
+	if len(opts.Roots) == 0 {
+		opts.Roots = []config.Root{{Path: fallbackRoot}}
+	}
+	return opts
+}
+
+// DuplicateError reports a repository that was skipped because an earlier one has its name
+type DuplicateError struct {
+	Name    string
+	Kept    string
+	Skipped string
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("project name %s is used by both %s and %s; using the first (exclude one or rename its directory)", e.Name, e.Kept, e.Skipped)
+}
+
+// Discover walks each root up to its depth and returns the git repositories found, sorted by name.
+// Directories are not descended into once they are repositories. When two repositories share a
+// name, the one under the earlier root wins and the other is reported as a *DuplicateError.
+// Roots that cannot be scanned are reported in the error while the others are still returned.
+func Discover(opts Options) ([]Project, error) {
+	var projects []Project
+	var errs []error
+	seen := make(map[string]string)
+
+	for _, root := range opts.Roots {
+		rootPath, err := ExpandPath(root.Path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		depth := root.Depth
+		if depth <= 0 {
+			depth = opts.Depth
+		}
+		if depth <= 0 {
+			depth = DefaultDepth
+		}
+
+		found, err := discoverRoot(rootPath, depth, opts)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, project := range found {
+			if kept, ok := seen[project.Name]; ok {
+				errs = append(errs, &DuplicateError{Name: project.Name, Kept: kept, Skipped: project.Path})
+				continue
+			}
+			seen[project.Name] = project.Path
+			projects = append(projects, project)
+		}
+	}
+
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Name < projects[j].Name
+	})
+	return projects, errors.Join(errs...)
+}
+
+func discoverRoot(root string, depth int, opts Options) ([]Project, error) {
+	var projects []Project
+
+	var walk func(dir string, level int) error
+	walk = func(dir string, level int) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if dir == root {
+				return fmt.Errorf("failed to scan %s: %w", root, err)
+			}
+			// Unreadable subdirectories are skipped rather than failing discovery
+			return nil
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			rel, _ := filepath.Rel(root, path)
+			if matchesAny(opts.Exclude, root, rel) {
+				continue
+			}
+
+			if IsRepository(path) {
+				if len(opts.Include) == 0 || matchesAny(opts.Include, root, rel) {
+					projects = append(projects, Project{Name: entry.Name(), Path: path, Root: root})
+				}
+				continue
+			}
+			if level < depth {
+				if err := walk(path, level+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	return projects, walk(root, 1)
+}
+
+// matchesAny applies include/exclude globs. Patterns without a slash match the directory name,
+// absolute or ~ patterns match the full path, and other patterns match the path below the root.
+func matchesAny(patterns []string, root, rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		switch {
+		case strings.HasPrefix(pattern, "~") || filepath.IsAbs(pattern):
+			expanded, err := ExpandPath(pattern)
+			if err == nil && ignore.MatchGlob(filepath.ToSlash(expanded), filepath.ToSlash(filepath.Join(root, rel))) {
+				return true
+			}
+		case strings.Contains(pattern, "/"):
+			if ignore.MatchGlob(pattern, rel) {
+				return true
+			}
+		default:
+			if ignore.MatchGlob(pattern, filepath.Base(rel)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsRepository reports whether dir is the top level of a git repository
+func IsRepository(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// ExpandPath resolves a leading ~ and environment variables, returning an absolute path
+func ExpandPath(path string) (string, error) {
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand %s: %w", path, err)
+		}
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	return abs, nil
+}
+
+// Registered returns repositories in the projects table that discovery did not find, such as
+// those added with 'wherewasi projects add' outside the configured roots
+func Registered(db ecosystem.ContextStore, discovered []Project) ([]Project, error) {
+	known, err := db.GetProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, project := range discovered {
+		seen[project.Name] = true
+		seen[project.Path] = true
+	}
+
+	var extra []Project
+	for _, project := range known {
+		// Other tools record projects without a checkout; only real repositories join the ecosystem
+		if project.Path == "" || seen[project.Name] || seen[project.Path] || !IsRepository(project.Path) {
+			continue
+		}
+		extra = append(extra, Project{Name: project.Name, Path: project.Path})
+	}
+	return extra, nil
+}
+
+// Sync records discovered projects in the projects table.
+// Only new or moved projects are written, so last activity reflects real work rather than discovery.
+func Sync(db ecosystem.ContextStore, projects []Project) error {
+	known, err := db.GetProjects()
+	if err != nil {
+		return err
+	}
+	paths := make(map[string]string, len(known))
+	for _, project := range known {
+		paths[project.Name] = project.Path
+	}
+
+	for _, project := range projects {
+		if path, ok := paths[project.Name]; ok && path == project.Path {
+			continue
+		}
+		if err := db.TrackProject(project.Name, project.Path, ecosystem.ToolWherewasi, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
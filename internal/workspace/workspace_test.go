@@ -0,0 +1,185 @@
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/QRY91/wherewasi/internal/config"
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+)
+
+// makeRepos creates an empty .git directory for each path below root
+func makeRepos(t *testing.T, root string, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		if err := os.MkdirAll(filepath.Join(root, path, ".git"), 0755); err != nil {
+			t.Fatalf("Failed to create repository %s: %v", path, err)
+		}
+	}
+}
+
+func names(projects []Project) []string {
+	var result []string
+	for _, project := range projects {
+		result = append(result, project.Name)
+	}
+	return result
+}
+
+func TestDiscover(t *testing.T) {
+	work := t.TempDir()
+	src := t.TempDir()
+	makeRepos(t, work, "api", "web", "archive/old-api", "api/vendor/lib", ".hidden")
+	makeRepos(t, src, "github.com/acme/tools", "github.com/acme/api", "github.com/other/misc")
+	if err := os.MkdirAll(filepath.Join(work, "notes"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		opts     Options
+		expected []string
+		// duplicates are the names reported as used by more than one repository
+		duplicates []string
+	}{
+		{
+			name:     "direct children by default",
+			opts:     Options{Roots: []config.Root{{Path: work}}},
+			expected: []string{"api", "web"},
+		},
+		{
+			name:       "deeper roots",
+			opts:       Options{Roots: []config.Root{{Path: work, Depth: 2}, {Path: src, Depth: 3}}},
+			expected:   []string{"api", "misc", "old-api", "tools", "web"},
+			duplicates: []string{"api"},
+		},
+		{
+			name:     "global depth",
+			opts:     Options{Roots: []config.Root{{Path: src}}, Depth: 3},
+			expected: []string{"api", "misc", "tools"},
+		},
+		{
+			name:     "include paths",
+			opts:     Options{Roots: []config.Root{{Path: src, Depth: 3}}, Include: []string{"github.com/acme/*"}},
+			expected: []string{"api", "tools"},
+		},
+		{
+			name:     "exclude names and prune directories",
+			opts:     Options{Roots: []config.Root{{Path: work, Depth: 2}}, Exclude: []string{"archive", "web"}},
+			expected: []string{"api"},
+		},
+		{
+			name:     "exclude absolute paths",
+			opts:     Options{Roots: []config.Root{{Path: src, Depth: 3}}, Exclude: []string{filepath.Join(src, "github.com/other/**")}},
+			expected: []string{"api", "tools"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			projects, err := Discover(tc.opts)
+			if err != nil && tc.duplicates == nil {
+				t.Fatalf("Discover failed: %v", err)
+			}
+			if got := duplicateNames(err); !reflect.DeepEqual(got, tc.duplicates) {
+				t.Fatalf("Expected duplicates %v, got error %v", tc.duplicates, err)
+			}
+			if got := names(projects); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+
+	// The first root wins when names collide, and the collision names both paths
+	projects, err := Discover(Options{Roots: []config.Root{{Path: work}, {Path: src, Depth: 3}}})
+	for _, project := range projects {
+		if project.Name == "api" && project.Root != work {
+			t.Errorf("Expected api from the first root, got %s", project.Path)
+		}
+	}
+	var duplicate *DuplicateError
+	if !errors.As(err, &duplicate) {
+		t.Fatalf("Expected a duplicate name error, got %v", err)
+	}
+	if duplicate.Kept != filepath.Join(work, "api") || duplicate.Skipped != filepath.Join(src, "github.com/acme/api") {
+		t.Errorf("Expected both paths in the duplicate error, got %+v", duplicate)
+	}
+	if message := err.Error(); !strings.Contains(message, duplicate.Kept) || !strings.Contains(message, duplicate.Skipped) {
+		t.Errorf("Expected the message to list both paths, got %q", message)
+	}
+}
+
+// duplicateNames lists the names of the duplicate errors joined in err
+func duplicateNames(err error) []string {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, err := range joined.Unwrap() {
+		if duplicate, ok := err.(*DuplicateError); ok {
+			names = append(names, duplicate.Name)
+		}
+	}
+	return names
+}
+
+func TestDiscoverMissingRoot(t *testing.T) {
+	work := t.TempDir()
+	makeRepos(t, work, "api")
+
+	projects, err := Discover(Options{Roots: []config.Root{{Path: filepath.Join(work, "missing")}, {Path: work}}})
+	if err == nil {
+		t.Error("Expected an error for the missing root")
+	}
+	if got := names(projects); !reflect.DeepEqual(got, []string{"api"}) {
+		t.Errorf("Expected remaining roots to be discovered, got %v", got)
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	work, elsewhere := t.TempDir(), t.TempDir()
+	makeRepos(t, work, "api")
+	makeRepos(t, elsewhere, "faraway")
+
+	db, err := ecosystem.NewEcosystemDB(ecosystem.DatabaseConfig{
+		ToolName:     ecosystem.ToolWherewasi,
+		FallbackPath: filepath.Join(t.TempDir(), "test.sqlite"),
+		ForceLocal:   true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	discovered, err := Discover(Options{Roots: []config.Root{{Path: work}}})
+	if err != nil {
+		t.Fatalf("Failed to discover: %v", err)
+	}
+	if err := Sync(db, discovered); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+	for name, path := range map[string]string{
+		"faraway": filepath.Join(elsewhere, "faraway"),
+		// Projects other tools record without a checkout, or whose checkout is gone
+		"notes": "",
+		"gone":  filepath.Join(elsewhere, "gone"),
+	} {
+		if err := db.TrackProject(name, path, ecosystem.ToolUroboro, path != ""); err != nil {
+			t.Fatalf("Failed to track %s: %v", name, err)
+		}
+	}
+
+	registered, err := Registered(db, discovered)
+	if err != nil {
+		t.Fatalf("Failed to list registered projects: %v", err)
+	}
+	want := []Project{{Name: "faraway", Path: filepath.Join(elsewhere, "faraway")}}
+	if !reflect.DeepEqual(registered, want) {
+		t.Errorf("Expected only the repository outside the roots, got %+v", registered)
+	}
+}
@@ -1,16 +1,28 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/QRY91/wherewasi/internal/common"
+	"github.com/QRY91/wherewasi/internal/config"
+	"github.com/QRY91/wherewasi/internal/contextdoc"
 	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/QRY91/wherewasi/internal/gitinfo"
+	"github.com/QRY91/wherewasi/internal/provider"
+	"github.com/QRY91/wherewasi/internal/search"
 	"github.com/atotto/clipboard"
 	"github.com/spf13/cobra"
 )
@@ -26,12 +38,9 @@ development workflow - invisible until you need it.`,
 var startCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Start background tracking",
-	Long:  "Begin monitoring your project for context generation",
+	Long:  "Begin monitoring your ecosystem for commits, branch switches and file edits in the background",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("🚀 wherewasi shadow mode starting...")
-		fmt.Println("🥷 Passive tracking enabled")
-		fmt.Println("🪂 Ready for ripcord deployment: wherewasi pull")
-		// TODO: Implement background daemon
+		runStart(cmd)
 	},
 }
 
@@ -46,72 +55,130 @@ var pullCmd = &cobra.Command{
 		clipboard_flag, _ := cmd.Flags().GetBool("clipboard")
 		history_flag, _ := cmd.Flags().GetBool("history")
 		save_flag, _ := cmd.Flags().GetBool("save")
+		branch, _ := cmd.Flags().GetString("branch")
+		format, _ := cmd.Flags().GetString("format")
+		maxTokens, _ := cmd.Flags().GetInt("max-tokens")
+		limit, _ := cmd.Flags().GetInt("limit")
+		sinceValue, _ := cmd.Flags().GetString("since")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		regex, _ := cmd.Flags().GetBool("regex")
+		caseSensitive, _ := cmd.Flags().GetBool("case-sensitive")
+		perProject, _ := cmd.Flags().GetInt("per-project")
+		focusTarget, _ := cmd.Flags().GetString("focus")
+		query := search.Query{Pattern: keyword, Regex: regex, CaseSensitive: caseSensitive}
+
+		if format != "text" && format != "json" {
+			fail("Unknown format %q (use text or json)", format)
+		}
+		if maxTokens < 0 {
+			fail("--max-tokens must be zero (unlimited) or positive")
+		}
+		if limit < 1 {
+			fail("--limit must be positive")
+		}
+		if timeout < 0 {
+			fail("--timeout must be zero (no limit) or positive")
+		}
+		if perProject < 0 {
+			fail("--per-project must be zero (no limit) or positive")
+		}
+		if focusTarget != "" && (history_flag || keyword != "") {
+			fail("--focus cannot be combined with --history or --keyword")
+		}
+		if keyword != "" && !history_flag {
+			if _, err := query.Compile(); err != nil {
+				fail("%v", err)
+			}
+		}
+
+		// Keep stdout clean for machine-readable output
+		status := os.Stdout
+		if format == "json" {
+			status = os.Stderr
+			if !cmd.Flags().Changed("clipboard") {
+				clipboard_flag = false
+			}
+		}
 
-		fmt.Println("🪂 Pulling ripcord...")
+		fmt.Fprintln(status, "🪂 Pulling ripcord...")
 
 		// Handle history search
 		if history_flag {
-			if db == nil {
-				fmt.Println("⚠️  Database not available - history search disabled")
-				return
-			}
-			if keyword != "" {
-				results, err := db.SearchStoredContexts(keyword)
-				if err != nil {
-					fmt.Printf("⚠️  Could not search history: %v\n", err)
-				} else if len(results) > 0 {
-					fmt.Println("📚 CONTEXT HISTORY SEARCH:")
-					for _, result := range results {
-						fmt.Printf("  • [%s] %s | %s\n", result.Project, result.Timestamp.Format("2006-01-02T15:04"), result.SessionInfo)
-					}
-				} else {
-					fmt.Println("📚 No matching contexts found in history")
-				}
-				return
-			} else {
-				// Show recent contexts for current project
-				currentProject := getProjectName()
-				results, err := db.GetRecentContexts(currentProject, 5)
-				if err != nil {
-					fmt.Printf("⚠️  Could not get history: %v\n", err)
-				} else if len(results) > 0 {
-					fmt.Printf("📚 RECENT CONTEXTS (%s):\n", currentProject)
-					for _, result := range results {
-						fmt.Printf("  • 📅 %s | %s\n", result.Timestamp.Format("2006-01-02T15:04"), result.SessionInfo)
-					}
-				} else {
-					fmt.Println("📚 No context history found for this project")
-				}
-				return
+			since, err := parseSince(sinceValue)
+			if err != nil {
+				fail("%v", err)
 			}
+			showHistory(keyword, historyFilter{Project: project, Branch: branch, Since: since, Limit: limit})
+			return
 		}
 
-		context := generateEnhancedContext(project, days, keyword)
+		ctx, cancel := pullContext(timeout)
+		defer cancel()
+		var doc *contextdoc.Document
+		var timings []provider.Timing
+		var err error
+		if focusTarget != "" {
+			focus, resolveErr := resolveFocus(ctx, project, focusTarget)
+			if resolveErr != nil {
+				fmt.Fprintf(status, "⚠️  %v\n", resolveErr)
+				exitWithError(resolveErr)
+			}
+			doc, timings, err = buildFocusDocument(ctx, project, focus)
+		} else {
+			doc, timings, err = buildContextDocument(ctx, project, days, query, perProject)
+		}
+		if err != nil {
+			fmt.Fprintf(status, "⚠️  %v\n", err)
+		}
+		if verbose {
+			showSectionTimings(status, timings)
+		}
+		if !doc.FitTokens(maxTokens, format) {
+			fmt.Fprintf(status, "⚠️  Context is still ~%d tokens after trimming (budget %d)\n", doc.Tokens(format), maxTokens)
+		}
+		context := doc.Text()
 
 		// Save context if requested (default true for production usage)
 		if save_flag && db != nil {
-			sessionInfo := detectActiveSession()
+			sessionInfo := doc.Session
 			if sessionInfo == "" {
-				sessionInfo = "Context pull"
+				sessionInfo = defaultSessionInfo
 			}
 			currentProject := getProjectName()
-			_, err := db.SaveContext(currentProject, context, sessionInfo, keyword)
+			_, err := db.SaveContext(currentProject, context, sessionInfo, keyword, getGitState())
+			if err != nil {
+				fmt.Fprintf(status, "⚠️  Could not save context: %v\n", err)
+			}
+			if result, err := db.VacuumIfDue(vacuumInterval); err != nil {
+				fmt.Fprintf(status, "⚠️  Could not compact database: %v\n", err)
+			} else if result != nil && result.Reclaimed() > 0 {
+				fmt.Fprintf(status, "🧹 Compacted database, reclaimed %s\n", formatBytes(result.Reclaimed()))
+			}
+		}
+
+		output := "\n" + context
+		if format == "json" {
+			data, err := doc.JSON()
 			if err != nil {
-				fmt.Printf("⚠️  Could not save context: %v\n", err)
+				fmt.Fprintf(status, "⚠️  %v\n", err)
+				exitWithError(err)
 			}
+			output = data
+			context = data
 		}
 
 		if clipboard_flag {
 			err := clipboard.WriteAll(context)
 			if err != nil {
-				fmt.Printf("⚠️  Could not copy to clipboard: %v\n", err)
-				fmt.Println("📋 Context output (copy manually):")
-				fmt.Println("\n" + context)
+				fmt.Fprintf(status, "⚠️  Could not copy to clipboard: %v\n", err)
+				fmt.Fprintln(status, "📋 Context output (copy manually):")
+				fmt.Println(output)
 			} else {
-				fmt.Println("📋 Context copied to clipboard! Paste and build.")
+				fmt.Fprintln(status, "📋 Context copied to clipboard! Paste and build.")
 			}
 		} else {
-			fmt.Println("\n" + context)
+			fmt.Println(output)
 		}
 	},
 }
@@ -122,227 +189,198 @@ var statusCmd = &cobra.Command{
 	Long:  "Display what's currently being tracked",
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("🪂 wherewasi ripcord status:")
-		fmt.Println("  🥷 Shadow mode: active")
+		if pid, running := daemonStatus(); running {
+			fmt.Printf("  🥷 Shadow mode: active (PID %d)\n", pid)
+		} else {
+			fmt.Println("  💤 Shadow mode: inactive (run: wherewasi start)")
+		}
 		fmt.Println("  📊 Projects tracked: detecting...")
 		fmt.Println("  🧠 Context ready: pull to deploy")
 		showTrackedProjects()
 	},
 }
 
-func generateEnhancedContext(project string, days int, keyword string) string {
-	var context strings.Builder
-
-	// Header with ecosystem context
-	context.WriteString("--- AI CONTEXT DEPLOYMENT ---\n")
-
-	if project != "" {
-		context.WriteString(fmt.Sprintf("🎯 FOCUSED ON: %s\n", project))
-		if !isValidProject(project) {
-			context.WriteString("⚠️  Project not found in current ecosystem\n")
-		}
-	} else {
-		context.WriteString(fmt.Sprintf("🏠 CURRENT PROJECT: %s\n", getProjectName()))
-		context.WriteString(fmt.Sprintf("📍 LOCATION: %s\n", getCurrentDir()))
+// pullContext limits building a context to timeout; zero means no limit
+func pullContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return context.WithCancel(context.Background())
 	}
+	return context.WithTimeout(context.Background(), timeout)
+}
 
-	// Active session detection
-	sessionContext := detectActiveSession()
-	if sessionContext != "" {
-		context.WriteString(fmt.Sprintf("⚡ ACTIVE SESSION: %s\n", sessionContext))
-	}
-
-	// Ecosystem awareness
-	context.WriteString("\n🧠 QRY ECOSYSTEM CONTEXT:\n")
-	context.WriteString("- Building unified local developer AI system\n")
-	context.WriteString("- Tools: wherewasi(context), uroboro(content), doggowoof(alerts), qomoboro(time)\n")
-	context.WriteString("- Recent breakthrough: Ecosystem intelligence discovery\n")
-	context.WriteString("- Current focus: Ripcord implementation for instant AI context\n")
-
-	// Time-filtered context
-	if days > 0 {
-		context.WriteString(fmt.Sprintf("\n⏰ LAST %d DAYS:\n", days))
-		commits := getCommitsSince(days, project)
-		if len(commits) > 0 {
-			for _, commit := range commits {
-				if keyword == "" || strings.Contains(strings.ToLower(commit), strings.ToLower(keyword)) {
-					context.WriteString(fmt.Sprintf("  • %s\n", commit))
-				}
-			}
-		} else {
-			context.WriteString("  • No commits found in timeframe\n")
+// showSectionTimings prints how long each context section took, in run order
+func showSectionTimings(w io.Writer, timings []provider.Timing) {
+	// Sections run concurrently, so the slowest one bounds the whole build
+	var slowest time.Duration
+	fmt.Fprintln(w, "⏱️  Section timings:")
+	for _, timing := range timings {
+		line := fmt.Sprintf("  • %-10s %s", timing.Name, formatDuration(timing.Duration))
+		switch {
+		case timing.TimedOut():
+			line += " (timed out)"
+		case timing.Err != nil:
+			line += fmt.Sprintf(" (failed: %v)", timing.Err)
 		}
-	} else {
-		// Default recent commits
-		commits := getRecentCommits(5)
-		if len(commits) > 0 {
-			context.WriteString("\n📝 RECENT COMMITS:\n")
-			for _, commit := range commits {
-				if keyword == "" || strings.Contains(strings.ToLower(commit), strings.ToLower(keyword)) {
-					context.WriteString(fmt.Sprintf("  • %s\n", commit))
-				}
-			}
+		fmt.Fprintln(w, line)
+		if timing.Duration > slowest {
+			slowest = timing.Duration
 		}
 	}
+	fmt.Fprintf(w, "  • %-10s %s\n", "slowest", formatDuration(slowest))
+}
 
-	// Current state
-	if changes := getUncommittedChanges(); len(changes) > 0 {
-		context.WriteString("\n🔄 UNCOMMITTED CHANGES:\n")
-		for _, change := range changes {
-			if keyword == "" || strings.Contains(strings.ToLower(change), strings.ToLower(keyword)) {
-				context.WriteString(fmt.Sprintf("  • %s\n", change))
-			}
-		}
-	}
+// buildContextDocument fills the header and runs the configured context providers until
+// ctx is done. The document is always usable; the error reports config or provider problems
+// and the timings how long each section took.
+func buildContextDocument(ctx context.Context, project string, days int, query search.Query, perProject int) (*contextdoc.Document, []provider.Timing, error) {
+	doc := newContextDocument(project)
+	doc.Keyword = query.Pattern
 
-	// Project structure
-	context.WriteString("\n📁 KEY FILES:\n")
-	keyFiles := getKeyFiles()
-	for _, file := range keyFiles {
-		context.WriteString(fmt.Sprintf("  • %s\n", file))
+	var errs []error
+	cfg, err := config.Load()
+	if err != nil {
+		errs = append(errs, err)
+		cfg = &config.Config{}
+	}
+	providers, err := provider.Default().Resolve(cfg.Sections)
+	if err != nil {
+		errs = append(errs, err)
 	}
 
-	// Recent development insights from chat history
-	chatInsights := getRecentChatInsights()
-	if len(chatInsights) > 0 {
-		context.WriteString("\n💭 RECENT DEVELOPMENT INSIGHTS:\n")
-		for _, insight := range chatInsights {
-			context.WriteString(fmt.Sprintf("  • %s\n", insight))
-		}
+	req := provider.Request{
+		Project:       project,
+		Days:          days,
+		Keyword:       query.Pattern,
+		Regex:         query.Regex,
+		CaseSensitive: query.CaseSensitive,
+		PerProject:    perProject,
+		Config:        cfg,
 	}
+	timings, err := provider.Build(ctx, providers, req, doc)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	recordSectionTimings(timings, doc.Project)
+	return doc, timings, errors.Join(errs...)
+}
 
-	// Enhanced search context if keyword provided
-	if keyword != "" {
-		context.WriteString(fmt.Sprintf("\n🔍 CROSS-PROJECT SEARCH: '%s'\n", keyword))
-		searchResults := searchCrossProject(keyword, project)
-		if len(searchResults) > 0 {
-			for _, result := range searchResults {
-				context.WriteString(fmt.Sprintf("  • %s\n", result))
-			}
-		} else {
-			context.WriteString("  • No matches found across ecosystem\n")
+// newContextDocument fills the header shared by every pull: the project, where it is and the
+// active session
+func newContextDocument(project string) *contextdoc.Document {
+	doc := contextdoc.New()
+	if project != "" {
+		doc.Project = project
+		doc.Focused = true
+		if found, ok := findProject(project); ok {
+			doc.Project = found.Name
+			doc.ProjectFound = true
 		}
+	} else {
+		doc.Project = getProjectName()
+		doc.ProjectFound = true
+		doc.Location = getCurrentDir()
+		doc.Git = getGitState()
 	}
 
-	context.WriteString("\n🎯 READY FOR AI COLLABORATION\n")
-	context.WriteString("--- END CONTEXT ---")
-
-	return context.String()
+	// Active session detection
+	doc.Session = detectActiveSession()
+	return doc
 }
 
-func isValidProject(project string) bool {
-	parentDir := filepath.Dir(getCurrentDir())
-	projectPath := filepath.Join(parentDir, project)
-	_, err := os.Stat(projectPath)
-	return err == nil
+// keywordQuery is how a request's keyword is matched
+func keywordQuery(req provider.Request) search.Query {
+	return search.Query{Pattern: req.Keyword, Regex: req.Regex, CaseSensitive: req.CaseSensitive}
 }
 
-func getCommitsSince(days int, project string) []string {
-	since := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
-
-	var cmd *exec.Cmd
-	if project != "" && isValidProject(project) {
-		parentDir := filepath.Dir(getCurrentDir())
-		projectPath := filepath.Join(parentDir, project)
-		cmd = exec.Command("git", "-C", projectPath, "log", "--oneline", "--since", since)
-	} else {
-		cmd = exec.Command("git", "log", "--oneline", "--since", since)
+// keywordFilter matches text against the request's keyword; an empty keyword matches everything
+func keywordFilter(req provider.Request) func(text string) bool {
+	if req.Keyword == "" {
+		return func(string) bool { return true }
 	}
-
-	output, err := cmd.Output()
+	re, err := keywordQuery(req).Compile()
 	if err != nil {
-		return []string{"No git history found for timeframe"}
+		return func(string) bool { return false }
 	}
+	return re.MatchString
+}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	var commits []string
-	for _, line := range lines {
-		if line != "" {
-			commits = append(commits, line)
-		}
+// projectPath returns the root of a project given by name, alias or path, or of the current
+// project when project is "". It returns "" for unknown projects.
+func projectPath(project string) string {
+	if project == "" {
+		return getProjectRoot()
 	}
-	return commits
+	found, _ := findProject(project)
+	return found.Path
 }
 
-func searchInProject(keyword string) []string {
-	return searchInProjectWithPath(".", keyword)
+func getCommitsSince(ctx context.Context, days int, project string) ([]contextdoc.Commit, error) {
+	dir := ""
+	if found, ok := findProject(project); ok {
+		dir = found.Path
+	}
+	return readCommits(ctx, dir, gitinfo.LogOptions{Since: time.Now().AddDate(0, 0, -days)})
 }
 
-func searchInProjectWithPath(projectPath, keyword string) []string {
-	// Enhanced grep search with line numbers and multiple file types including chat histories
-	cmd := exec.Command("grep", "-r", "-n", "-i",
-		"--include=*.go", "--include=*.md", "--include=*.txt",
-		"--include=*.json", "--include=*.yaml", "--include=*.yml",
-		keyword, projectPath)
-	output, err := cmd.Output()
+// readCommits lists commits of the repository at dir, or the current one when dir is ""
+func readCommits(ctx context.Context, dir string, opts gitinfo.LogOptions) ([]contextdoc.Commit, error) {
+	repo, err := gitinfo.Open(ctx, dir)
 	if err != nil {
-		return []string{}
+		return nil, err
 	}
+	defer repo.Close()
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	var results []string
-	chatHistoryCount := 0
-
-	for i, line := range lines {
-		if line != "" && i < 20 { // Increased to 20 results
-			// Parse file:line:content format
-			parts := strings.SplitN(line, ":", 3)
-			if len(parts) >= 3 {
-				file := strings.TrimPrefix(parts[0], projectPath+"/")
-				lineNum := parts[1]
-				content := strings.TrimSpace(parts[2])
-
-				// Special handling for chat history files (cursor_*.md)
-				isChatHistory := strings.HasPrefix(file, "cursor_") && strings.HasSuffix(file, ".md")
-				if isChatHistory {
-					chatHistoryCount++
-					if chatHistoryCount <= 3 { // Limit chat history results
-						// Enhanced context for chat histories
-						lineRange := getChatHistoryContext(filepath.Join(projectPath, file), lineNum, keyword)
-						results = append(results, fmt.Sprintf("💬 %s:%s → %s", file, lineRange, content[:60]+"..."))
-					}
-				} else {
-					// Regular file handling
-					if len(content) > 80 {
-						content = content[:77] + "..."
-					}
-					results = append(results, fmt.Sprintf("%s:%s → %s", file, lineNum, content))
-				}
-			}
-		}
+	log, err := repo.Log(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	commits := make([]contextdoc.Commit, 0, len(log))
+	for _, commit := range log {
+		commits = append(commits, contextdoc.Commit{
+			SHA:     commit.ShortSHA(),
+			Subject: commit.Subject,
+			Body:    commit.Body,
+			Author:  commit.Author,
+			Date:    commit.Date,
+		})
 	}
-	return results
+	return commits, nil
 }
 
-func getChatHistoryContext(filePath, lineNum, keyword string) string {
-	// Try to determine conversation context around the line
-	line, err := strconv.Atoi(lineNum)
-	if err != nil {
-		return lineNum
+// truncate shortens s to at most limit characters, marking the cut with "...". It cuts on rune
+// boundaries so multi-byte text stays valid UTF-8.
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
 	}
+	runes := []rune(s)
+	// Limits too small for the ellipsis just cut
+	if limit < 3 {
+		return string(runes[:max(limit, 0)])
+	}
+	return string(runes[:limit-3]) + "..."
+}
 
-	// Look for section headers or conversation boundaries
-	contextStart := max(1, line-50)
-	contextEnd := line + 50
+// chatHistoryWindow is how many lines around a chat history hit are searched for a heading
+const chatHistoryWindow = 50
 
-	// Read a chunk around the match to find conversation boundaries
-	cmd := exec.Command("sed", "-n", fmt.Sprintf("%d,%dp", contextStart, contextEnd), filePath)
-	output, err := cmd.Output()
+// chatHistoryRange returns the conversation around a hit in a chat history file: from the first
+// heading in the lines before it, or the surrounding window when there is none
+func chatHistoryRange(path string, line int) string {
+	start, end := max(1, line-chatHistoryWindow), line+chatHistoryWindow
+	file, err := os.Open(path)
 	if err != nil {
-		return lineNum
+		return strconv.Itoa(line)
 	}
+	defer file.Close()
 
-	lines := strings.Split(string(output), "\n")
-	for i, contextLine := range lines {
-		// Look for conversation markers or section headers
-		if strings.Contains(contextLine, "# ") || strings.Contains(contextLine, "## ") {
-			actualLine := contextStart + i
-			if actualLine <= line {
-				return fmt.Sprintf("%d", actualLine) + "-" + lineNum
-			}
+	scanner := bufio.NewScanner(file)
+	for current := 1; current <= line && scanner.Scan(); current++ {
+		if current >= start && strings.Contains(scanner.Text(), "# ") {
+			return fmt.Sprintf("%d-%d", current, line)
 		}
 	}
-
-	return fmt.Sprintf("%d-%d", contextStart, contextEnd)
+	return fmt.Sprintf("%d-%d", start, end)
 }
 
 func max(a, b int) int {
@@ -355,8 +393,8 @@ func max(a, b int) int {
 func detectActiveSession() string {
 	var sessionInfo []string
 
-	// Check for recent file modifications (last 30 minutes)
-	recentFiles := getRecentlyModifiedFiles(30)
+	// Check the activity journal for recent edits (last 24 hours)
+	recentFiles := getRecentlyEditedFiles(24*time.Hour, 5)
 	if len(recentFiles) > 0 {
 		sessionInfo = append(sessionInfo, fmt.Sprintf("Recent edits: %s", strings.Join(recentFiles, ", ")))
 	}
@@ -383,25 +421,47 @@ func detectActiveSession() string {
 	return ""
 }
 
-func getRecentlyModifiedFiles(minutes int) []string {
-	// Find files modified in last N minutes
-	cmd := exec.Command("find", ".", "-name", "*.go", "-o", "-name", "*.md", "-mmin", fmt.Sprintf("-%d", minutes))
-	output, err := cmd.Output()
+// getRecentlyEditedFiles reads the activity journal written by the tracking daemon
+func getRecentlyEditedFiles(window time.Duration, limit int) []string {
+	if db == nil {
+		return []string{}
+	}
+
+	project := filepath.Base(getProjectRoot())
+	edits, err := db.GetRecentEdits(project, time.Now().Add(-window), limit)
 	if err != nil {
 		return []string{}
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
 	var files []string
-	for _, line := range lines {
-		if line != "" && line != "." {
-			file := strings.TrimPrefix(line, "./")
-			files = append(files, file)
-		}
+	for _, edit := range edits {
+		files = append(files, fmt.Sprintf("%s (%s ago)", edit.Path, formatAge(time.Since(edit.LastEdit))))
 	}
 	return files
 }
 
+// getProjectRoot returns the git top-level directory, or the current directory outside a repo
+func getProjectRoot() string {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	output, err := cmd.Output()
+	if err != nil {
+		return getCurrentDir()
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// formatAge renders a duration at minute/hour/day granularity
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
 func getChatHistoryFiles() []string {
 	files, err := filepath.Glob("cursor_*.md")
 	if err != nil {
@@ -433,24 +493,73 @@ func getMostRecentFile(files []string) string {
 }
 
 func getGitWorkingStatus() string {
-	// Check if there are staged changes (ready to commit)
-	cmd := exec.Command("git", "diff", "--cached", "--name-only")
-	output, err := cmd.Output()
-	if err == nil && strings.TrimSpace(string(output)) != "" {
+	status, err := readGitStatus(context.Background())
+	switch {
+	case err != nil:
+		return ""
+	case status.Staged():
 		return "Staged changes ready to commit"
+	case status.Dirty():
+		return "Active development in progress"
 	}
+	return ""
+}
 
-	// Check if there's active work (modified files)
-	cmd = exec.Command("git", "status", "--porcelain")
-	output, err = cmd.Output()
-	if err == nil && strings.TrimSpace(string(output)) != "" {
-		return "Active development in progress"
+// readGitStatus reads the branch and working tree state of the current repository
+func readGitStatus(ctx context.Context) (*gitinfo.Status, error) {
+	repo, err := gitinfo.Open(ctx, "")
+	if err != nil {
+		return nil, err
 	}
+	defer repo.Close()
+	return repo.Status(ctx)
+}
 
-	return ""
+// getGitState reads branch, HEAD, dirty flag and upstream divergence for the current repository,
+// or nil outside a repository or before the first commit
+func getGitState() *ecosystem.GitState {
+	status, err := readGitStatus(context.Background())
+	if err != nil || status.Commit == "" {
+		return nil
+	}
+
+	state := &ecosystem.GitState{Commit: status.Commit, Branch: status.Branch, Dirty: status.Dirty()}
+	if status.Detached() {
+		state.Branch = "HEAD"
+	}
+	if status.HasUpstream() {
+		state.HasUpstream = true
+		state.Ahead, state.Behind = status.Ahead, status.Behind
+	}
+	return state
+}
+
+// formatSessionGit renders the git stamp of a saved session for history listings
+func formatSessionGit(session ecosystem.ContextSession) string {
+	git := sessionGitState(session)
+	if git == nil {
+		return ""
+	}
+	return " | 🌿 " + git.String()
+}
+
+// sessionGitState rebuilds the git state a session was saved with, or nil outside a repository
+func sessionGitState(session ecosystem.ContextSession) *ecosystem.GitState {
+	if session.GitBranch == nil {
+		return nil
+	}
+	git := &ecosystem.GitState{Branch: *session.GitBranch, Dirty: session.GitDirty}
+	if session.GitCommit != nil {
+		git.Commit = *session.GitCommit
+	}
+	if session.GitAhead != nil && session.GitBehind != nil {
+		git.HasUpstream = true
+		git.Ahead, git.Behind = *session.GitAhead, *session.GitBehind
+	}
+	return git
 }
 
-func getRecentChatInsights() []string {
+func getRecentChatInsights(ctx context.Context) []string {
 	var insights []string
 
 	// Get most recent chat file
@@ -475,13 +584,19 @@ func getRecentChatInsights() []string {
 	}
 
 	// Extract key insights from recent chat
-	insights = extractChatInsights(mostRecent)
+	insights = extractChatInsights(ctx, mostRecent)
 	return insights
 }
 
-func extractChatInsights(filename string) []string {
+func extractChatInsights(ctx context.Context, filename string) []string {
 	var insights []string
 
+	data, err := os.ReadFile(filename)
+	if err != nil || search.IsBinary(data) {
+		return insights
+	}
+	lines := strings.Split(string(data), "\n")
+
 	// Look for key patterns that indicate current work
 	patterns := []string{
 		"implementing", "enhanced", "added", "fixed", "testing",
@@ -489,26 +604,26 @@ func extractChatInsights(filename string) []string {
 	}
 
 	for _, pattern := range patterns {
-		cmd := exec.Command("grep", "-i", "-n", pattern, filename)
-		output, err := cmd.Output()
-		if err != nil {
-			continue
+		if ctx.Err() != nil {
+			break
 		}
 
-		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-		for i, line := range lines {
-			if line != "" && i < 3 { // Limit to 3 insights per pattern
-				// Extract meaningful context
-				parts := strings.SplitN(line, ":", 3)
-				if len(parts) >= 3 {
-					content := strings.TrimSpace(parts[2])
-					if len(content) > 20 && len(content) < 150 {
-						// Clean up markdown and formatting
-						content = strings.ReplaceAll(content, "**", "")
-						content = strings.ReplaceAll(content, "*", "")
-						insights = append(insights, content)
-					}
-				}
+		matched := 0
+		for _, line := range lines {
+			if matched == 3 { // Limit to 3 insights per pattern
+				break
+			}
+			if !strings.Contains(strings.ToLower(line), pattern) {
+				continue
+			}
+			matched++
+			// Extract meaningful context
+			content := strings.TrimSpace(line)
+			if len(content) > 20 && len(content) < 150 {
+				// Clean up markdown and formatting
+				content = strings.ReplaceAll(content, "**", "")
+				content = strings.ReplaceAll(content, "*", "")
+				insights = append(insights, content)
 			}
 		}
 
@@ -520,79 +635,189 @@ func extractChatInsights(filename string) []string {
 	return insights
 }
 
+// getTrackedActivity formats activity recorded by the daemon or git hooks for a project
+func getTrackedActivity(project string, window time.Duration, limit int) []string {
+	if db == nil {
+		return nil
+	}
+	if project == "" {
+		project = getProjectName()
+	}
+
+	messages, err := db.GetProjectActivity(project, time.Now().Add(-window), limit)
+	if err != nil {
+		return nil
+	}
+
+	var entries []string
+	seen := make(map[string]bool)
+	for _, msg := range messages {
+		var activity ecosystem.ProjectActivityMessageData
+		if err := msg.ParseMessageData(&activity); err != nil {
+			continue
+		}
+		// The daemon and git hooks can both observe the same commit or checkout
+		key := activity.Activity + "\x00" + activity.Detail
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		entry := fmt.Sprintf("%s %s: %s", msg.CreatedAt.Local().Format("01-02 15:04"), activity.Activity, activity.Detail)
+		if len(activity.Files) > 0 {
+			files := activity.Files
+			if len(files) > 5 {
+				files = append(files[:5:5], fmt.Sprintf("+%d more", len(activity.Files)-5))
+			}
+			entry += fmt.Sprintf(" (%s)", strings.Join(files, ", "))
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
 // Database instance (will be initialized in main)
-var db *ecosystem.EcosystemDB
+var db ecosystem.ContextStore
 
-func searchCrossProject(keyword string, project string) []string {
-	var allResults []string
+// Cross-project search limits: hits overall, the default per project, and chat history hits per project
+const (
+	maxSearchHits         = 20
+	defaultPerProjectHits = 5
+	maxChatSearchHits     = 3
+)
 
-	// Always search current project first
-	currentProject := getProjectName()
-	currentResults := searchInProjectWithPath(".", keyword)
-	for _, result := range currentResults {
-		allResults = append(allResults, fmt.Sprintf("[%s] %s", currentProject, result))
+// How far back commits count towards ranking a file as recently committed
+const (
+	commitSignalWindow = 30 * 24 * time.Hour
+	commitSignalLimit  = 200
+)
+
+// searchCrossProject searches the current project and the --project target or every other
+// ecosystem project, keeping the best ranked hits with at most perProject (0: no limit) per project
+func searchCrossProject(ctx context.Context, query search.Query, project string, perProject int) ([]contextdoc.SearchHit, error) {
+	targets := searchTargets(project)
+	hits, err := searchHits(ctx, targets, query, search.Options{MaxPerFile: maxSearchHits})
+	if err != nil {
+		return nil, err
 	}
+	hits = search.Rank(hits, rankingSignals(ctx, targets, hits), time.Now())
 
-	if project != "" && isValidProject(project) {
-		// Search specific project
-		parentDir := filepath.Dir(getCurrentDir())
-		projectPath := filepath.Join(parentDir, project)
-		results := searchInProjectWithPath(projectPath, keyword)
-		for _, result := range results {
-			allResults = append(allResults, fmt.Sprintf("[%s] %s", project, result))
+	roots := make(map[string]string, len(targets))
+	for _, target := range targets {
+		roots[target.Project] = target.Root
+	}
+	var results []contextdoc.SearchHit
+	counts := make(map[string]int)
+	chats := make(map[string]int)
+	for _, hit := range hits {
+		if len(results) >= maxSearchHits {
+			break
 		}
-	} else {
-		// Search all ecosystem projects (excluding current)
-		parentDir := filepath.Dir(getCurrentDir())
-		entries, err := os.ReadDir(parentDir)
-		if err != nil {
-			return allResults // Return current project results at least
-		}
-
-		for _, entry := range entries {
-			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") &&
-				entry.Name() != currentProject && hasGitRepo(filepath.Join(parentDir, entry.Name())) {
-				projectPath := filepath.Join(parentDir, entry.Name())
-				results := searchInProjectWithPath(projectPath, keyword)
-				for _, result := range results {
-					allResults = append(allResults, fmt.Sprintf("[%s] %s", entry.Name(), result))
-					if len(allResults) >= 20 { // Increased limit for comprehensive search
-						return allResults
-					}
-				}
+		if perProject > 0 && counts[hit.Project] >= perProject {
+			continue
+		}
+		result := contextdoc.SearchHit{
+			Project: hit.Project,
+			File:    hit.File,
+			Line:    hit.Line,
+			Column:  hit.Column,
+			Score:   math.Round(hit.Score*100) / 100,
+		}
+		// Chat histories (cursor_*.md) are capped and shown with the surrounding conversation
+		if strings.HasPrefix(hit.File, "cursor_") && strings.HasSuffix(hit.File, ".md") {
+			if chats[hit.Project] >= maxChatSearchHits {
+				continue
 			}
+			chats[hit.Project]++
+			result.LineRange = chatHistoryRange(filepath.Join(roots[hit.Project], hit.File), hit.Line)
+			result.Snippet = truncate(hit.Snippet, 60)
+		} else {
+			result.Snippet = truncate(hit.Snippet, 80)
 		}
+		counts[hit.Project]++
+		results = append(results, result)
 	}
+	return results, nil
+}
 
-	return allResults
+// searchHits searches targets through the persistent index when the database is available.
+// The index refreshes changed repositories first, so results match a full search.
+func searchHits(ctx context.Context, targets []search.Target, query search.Query, opts search.Options) ([]search.Hit, error) {
+	if db != nil {
+		return search.NewIndex(db).Search(ctx, targets, query, opts)
+	}
+	return search.Search(ctx, targets, query, opts)
 }
 
-func showTrackedProjects() {
-	parentDir := filepath.Dir(getCurrentDir())
-	entries, err := os.ReadDir(parentDir)
-	if err != nil {
-		fmt.Println("  ⚠️  Could not scan project directory")
-		return
+// rankingSignals gathers what ranking knows about the projects with hits: the first target is
+// the current project, activity comes from the projects table and recent commits from git
+func rankingSignals(ctx context.Context, targets []search.Target, hits []search.Hit) map[string]search.ProjectSignals {
+	matched := make(map[string]bool)
+	for _, hit := range hits {
+		matched[hit.Project] = true
+	}
+	activity := make(map[string]time.Time)
+	if db != nil {
+		if projects, err := db.GetProjects(); err == nil {
+			for _, project := range projects {
+				if project.LastActivity != nil {
+					activity[project.Name] = *project.LastActivity
+				}
+			}
+		}
 	}
 
-	fmt.Println("  📂 Ecosystem projects:")
-	projectCount := 0
-	for _, entry := range entries {
-		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
-			projectPath := filepath.Join(parentDir, entry.Name())
-			if hasGitRepo(projectPath) {
-				fmt.Printf("    • %s\n", entry.Name())
-				projectCount++
+	signals := make(map[string]search.ProjectSignals, len(matched))
+	since := time.Now().Add(-commitSignalWindow)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		if !matched[target.Project] {
+			continue
+		}
+		wg.Add(1)
+		go func(current bool, target search.Target) {
+			defer wg.Done()
+			committed := search.CommitTimes(ctx, target.Root, since, commitSignalLimit)
+			mu.Lock()
+			defer mu.Unlock()
+			signals[target.Project] = search.ProjectSignals{
+				Current:      current,
+				LastActivity: activity[target.Project],
+				Committed:    committed,
 			}
+		}(i == 0, target)
+	}
+	wg.Wait()
+	return signals
+}
+
+// searchTargets lists what a keyword search covers: the current project first, then the given
+// project or, without one, every other ecosystem project
+func searchTargets(project string) []search.Target {
+	currentProject := getProjectName()
+	targets := []search.Target{{Project: currentProject, Root: getProjectRoot()}}
+	if found, ok := findProject(project); ok {
+		if found.Name != currentProject {
+			targets = append(targets, search.Target{Project: found.Name, Root: found.Path})
 		}
+		return targets
 	}
-	fmt.Printf("  🎯 Total: %d projects tracked\n", projectCount)
+	for _, other := range ecosystemProjects() {
+		if other.Name != currentProject {
+			targets = append(targets, search.Target{Project: other.Name, Root: other.Path})
+		}
+	}
+	return targets
 }
 
-func hasGitRepo(path string) bool {
-	gitPath := filepath.Join(path, ".git")
-	_, err := os.Stat(gitPath)
-	return err == nil
+func showTrackedProjects() {
+	projects := ecosystemProjects()
+
+	fmt.Println("  📂 Ecosystem projects:")
+	for _, project := range projects {
+		fmt.Printf("    • %s (%s)\n", project.Name, project.Path)
+	}
+	fmt.Printf("  🎯 Total: %d projects tracked\n", len(projects))
 }
 
 func getProjectName() string {
@@ -600,38 +825,27 @@ func getProjectName() string {
 	return filepath.Base(dir)
 }
 
-func getRecentCommits(count int) []string {
-	cmd := exec.Command("git", "log", "--oneline", "-n", fmt.Sprintf("%d", count))
-	output, err := cmd.Output()
-	if err != nil {
-		return []string{"No git history found"}
-	}
-
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	var commits []string
-	for _, line := range lines {
-		if line != "" {
-			commits = append(commits, line)
-		}
-	}
-	return commits
+func getRecentCommits(ctx context.Context, count int) ([]contextdoc.Commit, error) {
+	return readCommits(ctx, "", gitinfo.LogOptions{Limit: count})
 }
 
-func getUncommittedChanges() []string {
-	cmd := exec.Command("git", "status", "--porcelain")
-	output, err := cmd.Output()
+// getUncommittedChanges lists changed and untracked files in the current repository
+func getUncommittedChanges(ctx context.Context) ([]contextdoc.Change, error) {
+	status, err := readGitStatus(ctx)
 	if err != nil {
-		return []string{}
+		return nil, err
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	var changes []string
-	for _, line := range lines {
-		if line != "" {
-			changes = append(changes, line)
+	changes := make([]contextdoc.Change, 0, len(status.Entries))
+	for _, entry := range status.Entries {
+		path := entry.Path
+		// Renames read "old -> new", as in git status --short
+		if entry.OrigPath != "" {
+			path = entry.OrigPath + " -> " + entry.Path
 		}
+		changes = append(changes, contextdoc.Change{Status: entry.Code(), Path: path})
 	}
-	return changes
+	return changes, nil
 }
 
 func getKeyFiles() []string {
@@ -664,38 +878,175 @@ func init() {
 	// Add flags to pull command
 	pullCmd.Flags().StringP("project", "p", "", "Target specific project in ecosystem")
 	pullCmd.Flags().IntP("days", "d", 0, "Include last N days of history (default: recent commits)")
-	pullCmd.Flags().StringP("keyword", "k", "", "Filter context by keyword")
+	pullCmd.Flags().StringP("keyword", "k", "", "Filter context by keyword (with --history: \"phrases\", prefix* and AND/OR/NOT)")
 	pullCmd.Flags().BoolP("clipboard", "c", true, "Copy to clipboard (default: true)")
-	pullCmd.Flags().Bool("history", false, "Search context history instead of generating new")
+	pullCmd.Flags().Bool("history", false, "Search context history instead of generating new (see also: wherewasi history)")
 	pullCmd.Flags().BoolP("save", "s", true, "Save context to history (default: true)")
+	pullCmd.Flags().String("branch", "", "Only show history saved on this git branch (with --history)")
+	pullCmd.Flags().Int("limit", ecosystem.DefaultSearchLimit, "Maximum history entries to show (with --history)")
+	pullCmd.Flags().String("since", "", "Only show history saved since an age (36h, 7d, 2w) or date (with --history)")
+	pullCmd.Flags().Int("max-tokens", 0, "Trim search hits, insights and older commits until the context fits about N tokens (0: unlimited)")
+	pullCmd.Flags().String("format", "text", "Output format: text or json (json prints to stdout unless --clipboard is set)")
+	pullCmd.Flags().Duration("timeout", pullTarget, "Time budget for building the context; unfinished sections are marked timed out (0: no limit)")
+	pullCmd.Flags().BoolP("verbose", "v", false, "Print how long each context section took")
+	pullCmd.Flags().Bool("regex", false, "Treat --keyword as a regular expression instead of literal text")
+	pullCmd.Flags().Bool("case-sensitive", false, "Match --keyword case-sensitively")
+	pullCmd.Flags().Int("per-project", defaultPerProjectHits, "Show at most N --keyword hits per project (0: no limit)")
+	pullCmd.Flags().String("focus", "", "Centre the context on a file, directory or Go symbol (Name or Type.Method): its history, diffs, references, tests and notes")
+
+	// Add flags to start/restart commands
+	for _, cmd := range []*cobra.Command{startCmd, restartCmd} {
+		cmd.Flags().Duration("interval", 30*time.Second, "How often to poll projects for activity")
+		cmd.Flags().StringSlice("root", nil, "Directory containing ecosystem projects, replacing the configured roots")
+	}
+	startCmd.Flags().Bool("foreground", false, "Run the tracker in the foreground instead of as a daemon")
+	restartCmd.Flags().Bool("foreground", false, "Run the tracker in the foreground instead of as a daemon")
+
+	describeCmd.Flags().StringP("project", "p", "", "Describe another ecosystem project instead of the current one")
+	describeCmd.Flags().Bool("repo", false, "Write the project description to .wherewasi.yaml in the repository")
+	describeCmd.Flags().Bool("ecosystem", false, "Set the ecosystem description (one line per argument)")
+	describeCmd.Flags().String("name", "", "Ecosystem name shown in the context title")
+	describeCmd.Flags().Bool("clear", false, "Remove the description")
+	describeCmd.Flags().Bool("edit", false, "Open the config file (or .wherewasi.yaml with --repo) in $EDITOR")
 
 	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(restartCmd)
 	rootCmd.AddCommand(pullCmd)
 	rootCmd.AddCommand(statusCmd)
+
+	hooksCmd.AddCommand(hooksInstallCmd)
+	hooksCmd.AddCommand(hooksUninstallCmd)
+	hooksCmd.AddCommand(hooksStatusCmd)
+	rootCmd.AddCommand(hooksCmd)
+	rootCmd.AddCommand(hookEventCmd)
+	rootCmd.AddCommand(describeCmd)
+
+	projectsListCmd.Flags().Int("limit", 0, "Show at most N projects (0: all)")
+	projectsAddCmd.Flags().String("name", "", "Project name (default: directory name)")
+	projectsAddCmd.Flags().String("tool", ecosystem.ToolWherewasi, "Primary tool managing the project")
+	projectsAddCmd.Flags().String("description", "", "Project description")
+	projectsAliasCmd.Flags().Bool("remove", false, "Remove the given alias")
+	projectsDescribeCmd.Flags().Bool("clear", false, "Remove the description")
+
+	projectsCmd.AddCommand(projectsListCmd)
+	projectsCmd.AddCommand(projectsAddCmd)
+	projectsCmd.AddCommand(projectsRemoveCmd)
+	projectsCmd.AddCommand(projectsAliasCmd)
+	projectsCmd.AddCommand(projectsDescribeCmd)
+	projectsCmd.AddCommand(projectsShowCmd)
+	rootCmd.AddCommand(projectsCmd)
+
+	dbMigrateCmd.Flags().Bool("status", false, "Show applied, pending and unknown migrations")
+	dbMigrateCmd.Flags().Bool("dry-run", false, "Show pending migrations without applying them")
+	dbPruneCmd.Flags().String("older-than", "", "Prune contexts older than an age (90d, 12w) or date")
+	dbPruneCmd.Flags().Int("keep-per-project", 0, "Keep at most the newest N contexts per project (0: no limit)")
+	dbPruneCmd.Flags().Bool("compress", false, "Also gzip-compress the contexts that are kept")
+	dbPruneCmd.Flags().Bool("dry-run", false, "Show how many contexts would be pruned without deleting them")
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbPruneCmd)
+	dbCmd.AddCommand(dbBackupCmd)
+	rootCmd.AddCommand(dbCmd)
+
+	exportCmd.Flags().StringP("output", "o", "-", "File to write (default: stdout)")
+	exportCmd.Flags().String("format", "", "jsonl or tar (default: tar for .tar files, otherwise jsonl)")
+	exportCmd.Flags().StringP("project", "p", "", "Only this project (name, alias or path)")
+	exportCmd.Flags().String("since", "", "Only rows saved since an age (30d, 2w) or date")
+	exportCmd.Flags().String("until", "", "Only rows saved before an age (30d, 2w) or date")
+	importCmd.Flags().String("on-conflict", ecosystem.ConflictMerge, "For existing projects: merge, keep or replace")
+	importCmd.Flags().Bool("dry-run", false, "Show what would be imported without changing the database")
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+
+	historyListCmd.Flags().StringP("keyword", "k", "", "Full-text search: \"phrases\", prefix* and AND/OR/NOT")
+	historyListCmd.Flags().StringP("project", "p", "", "Only this project (name, alias or path; default: current project)")
+	historyListCmd.Flags().Bool("all", false, "List every project")
+	historyListCmd.Flags().String("branch", "", "Only contexts saved on this git branch")
+	historyListCmd.Flags().String("since", "", "Only contexts saved since an age (36h, 7d, 2w) or date")
+	historyListCmd.Flags().Int("limit", ecosystem.DefaultSearchLimit, "Maximum entries to show")
+
+	historyCmd.AddCommand(historyListCmd)
+	historyCmd.AddCommand(historyShowCmd)
+	historyCmd.AddCommand(historyDiffCmd)
+	historyCmd.AddCommand(historyCopyCmd)
+	historyCmd.AddCommand(historyDeleteCmd)
+	rootCmd.AddCommand(historyCmd)
+
+	resumeCmd.Flags().BoolP("clipboard", "c", true, "Copy the briefing to clipboard (default: true)")
+	rootCmd.AddCommand(resumeCmd)
+
+	noteListCmd.Flags().StringP("project", "p", "", "Only this project (name, alias or path; default: current project)")
+	noteListCmd.Flags().Bool("all", false, "List notes for every project")
+	noteListCmd.Flags().Bool("done", false, "Include notes marked done")
+	noteListCmd.Flags().String("branch", "", "Only notes left on this git branch")
+	noteListCmd.Flags().Int("limit", 20, "Maximum notes to show")
+
+	noteCmd.AddCommand(noteListCmd)
+	noteCmd.AddCommand(noteDoneCmd)
+	rootCmd.AddCommand(noteCmd)
+	rootCmd.AddCommand(checkpointCmd)
+
+	statsCmd.Flags().String("since", "30d", "Only usage since an age (7d, 2w) or date (empty: all time)")
+	statsCmd.Flags().Int("top", 5, "Entries to show per table (0: all)")
+	rootCmd.AddCommand(statsCmd)
+
+	indexRebuildCmd.Flags().StringP("project", "p", "", "Only this project (name, alias or path)")
+	indexCmd.AddCommand(indexRebuildCmd)
+	indexCmd.AddCommand(indexStatusCmd)
+	rootCmd.AddCommand(indexCmd)
 }
 
-func main() {
-	// Initialize ecosystem database with fallback to local
-	var err error
-	config := ecosystem.DatabaseConfig{
-		ToolName:     ecosystem.ToolWherewasi,
-		FallbackPath: filepath.Join(common.GetDataDir(), "context.sqlite"),
-		ForceLocal:   false,
+// skipMigrationsAnnotation marks commands that open the database without migrating it
+const skipMigrationsAnnotation = "wherewasi/skip-migrations"
+
+// openDatabase initializes the ecosystem database with fallback to local
+func openDatabase(cmd *cobra.Command, args []string) {
+	dbConfig := ecosystem.DatabaseConfig{
+		ToolName:       ecosystem.ToolWherewasi,
+		FallbackPath:   filepath.Join(common.GetDataDir(), "context.sqlite"),
+		ForceLocal:     false,
+		SkipMigrations: cmd.Annotations[skipMigrationsAnnotation] == "true",
+		LegacyPath:     common.GetDefaultDBPath(),
 	}
+	// A broken config is reported by the commands that use it
+	if cfg, err := config.Load(); err == nil {
+		dbConfig.Compression = cfg.Storage.Compression
+		invocation.disabled = cfg.Usage.Disabled
+	}
+	invocation.cmd = cmd
 	
-	db, err = ecosystem.NewEcosystemDB(config)
+	// A failed open leaves db nil rather than holding a nil *EcosystemDB
+	store, err := ecosystem.NewEcosystemDB(dbConfig)
 	if err != nil {
-		fmt.Printf("⚠️  Failed to initialize ecosystem database: %v\n", err)
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to initialize ecosystem database: %v\n", err)
 		// Continue without persistence
 	} else {
+		db = store
+		// Connection notices go to stderr so command output stays pipeable
 		if db.IsShared() {
-			fmt.Printf("🔗 Connected to shared ecosystem database: %s\n", db.DatabasePath())
+			fmt.Fprintf(os.Stderr, "🔗 Connected to shared ecosystem database: %s\n", db.DatabasePath())
 		} else {
-			fmt.Printf("📁 Using local database: %s\n", db.DatabasePath())
+			fmt.Fprintf(os.Stderr, "📁 Using local database: %s\n", db.DatabasePath())
+		}
+		if imported := db.LegacyImport(); imported != nil {
+			fmt.Fprintf(os.Stderr, "📦 Imported %d contexts, %d projects, %d messages and %d usage records from legacy database (backup: %s)\n",
+				imported.Contexts, imported.Projects, imported.Messages, imported.Usage, imported.Backup)
 		}
 	}
+}
 
-	if err := rootCmd.Execute(); err != nil {
+func main() {
+	// The database is opened once the command is known, so 'db migrate' can inspect it before migrating
+	rootCmd.PersistentPreRun = openDatabase
+
+	invocation.start = time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	// Usage errors such as a wrong number of arguments happen before the database is opened
+	if err != nil && invocation.cmd == nil && cmd != nil {
+		openDatabase(cmd, nil)
+	}
+	recordInvocation(err)
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -1,16 +1,27 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/QRY91/wherewasi/internal/contextdoc"
+	"github.com/QRY91/wherewasi/internal/ecosystem"
 )
 
+// cliBinary is the binary TestCLICommands builds and runCLI runs
+const cliBinary = "./wherewasi_test"
+
 func TestCLICommands(t *testing.T) {
 	// Build the binary for testing
-	binary := "./wherewasi_test"
+	binary := cliBinary
 	cmd := exec.Command("go", "build", "-o", binary, ".")
 	err := cmd.Run()
 	if err != nil {
@@ -49,16 +60,57 @@ func TestCLICommands(t *testing.T) {
 	})
 
 	t.Run("Start", func(t *testing.T) {
+		tmpHome := t.TempDir()
 		cmd := exec.Command(binary, "start")
+		cmd.Env = append(os.Environ(), "HOME="+tmpHome)
 		output, err := cmd.CombinedOutput()
 		if err != nil {
 			t.Fatalf("Start command failed: %v", err)
 		}
+		defer func() {
+			cmd := exec.Command(binary, "stop")
+			cmd.Env = append(os.Environ(), "HOME="+tmpHome)
+			cmd.Run()
+		}()
 
 		outputStr := string(output)
 		if !strings.Contains(outputStr, "Ready for ripcord deployment") {
 			t.Error("Start should confirm ripcord deployment")
 		}
+
+		pidPath := filepath.Join(tmpHome, ".local", "share", "wherewasi", "daemon.pid")
+		if _, err := os.Stat(pidPath); os.IsNotExist(err) {
+			t.Error("Start should write a daemon PID file")
+		}
+
+		if output, err := runCLI(t, tmpHome, "", "start", "--foreground"); err == nil || !strings.Contains(output, "Tracking already active") {
+			t.Errorf("Expected a foreground start next to the daemon to be refused, got: %v\n%s", err, output)
+		}
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		tmpHome := t.TempDir()
+		cmd := exec.Command(binary, "start")
+		cmd.Env = append(os.Environ(), "HOME="+tmpHome)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("Start command failed: %v\n%s", err, output)
+		}
+
+		cmd = exec.Command(binary, "stop")
+		cmd.Env = append(os.Environ(), "HOME="+tmpHome)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("Stop command failed: %v", err)
+		}
+
+		if !strings.Contains(string(output), "Shadow mode stopped") {
+			t.Errorf("Stop should confirm the daemon was stopped, got: %s", output)
+		}
+
+		pidPath := filepath.Join(tmpHome, ".local", "share", "wherewasi", "daemon.pid")
+		if _, err := os.Stat(pidPath); !os.IsNotExist(err) {
+			t.Error("Stop should remove the daemon PID file")
+		}
 	})
 
 	t.Run("PullDryRun", func(t *testing.T) {
@@ -125,6 +177,264 @@ func TestCLICommands(t *testing.T) {
 		}
 	})
 
+	t.Run("HistorySearch", func(t *testing.T) {
+		tmpHome := t.TempDir()
+
+		cmd := exec.Command(binary, "pull", "--save", "--clipboard=false")
+		cmd.Env = append(os.Environ(), "HOME="+tmpHome)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("Failed to save context for history search: %v\n%s", err, output)
+		}
+
+		cmd = exec.Command(binary, "pull", "--history", "-k", `"READY FOR AI" OR nonexistent`, "--since", "1h", "--limit", "5")
+		cmd.Env = append(os.Environ(), "HOME="+tmpHome)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("History search failed: %v\n%s", err, output)
+		}
+		if !strings.Contains(string(output), "CONTEXT HISTORY SEARCH") || !strings.Contains(string(output), "**READY FOR AI**") {
+			t.Errorf("Expected a highlighted history match, got:\n%s", output)
+		}
+
+		cmd = exec.Command(binary, "pull", "--history", "-k", "READY", "--project", "not-a-project")
+		cmd.Env = append(os.Environ(), "HOME="+tmpHome)
+		output, _ = cmd.CombinedOutput()
+		if !strings.Contains(string(output), "No matching contexts") {
+			t.Errorf("Expected --project to filter the search, got:\n%s", output)
+		}
+	})
+
+	t.Run("HistoryCommands", func(t *testing.T) {
+		tmpHome := t.TempDir()
+		// A checkpoint saves a second context; an identical pull would refresh the first
+		for _, args := range [][]string{{"pull", "--save", "--clipboard=false"}, {"checkpoint", "second"}} {
+			if output, err := runCLI(t, tmpHome, "", args...); err != nil {
+				t.Fatalf("Failed to save context: %v\n%s", err, output)
+			}
+		}
+
+		output, err := runCLI(t, tmpHome, "", "history", "list")
+		if err != nil || !strings.Contains(output, "#1 ") || !strings.Contains(output, "#2 ") {
+			t.Errorf("Expected both contexts listed with IDs, got:\n%s", output)
+		}
+
+		output, err = runCLI(t, tmpHome, "", "history", "show", "#1")
+		if err != nil || !strings.Contains(output, "CONTEXT #1") || !strings.Contains(output, "--- AI CONTEXT DEPLOYMENT ---") {
+			t.Errorf("Expected the stored context, got:\n%s", output)
+		}
+
+		output, err = runCLI(t, tmpHome, "", "history", "diff", "1", "2")
+		if err != nil || !strings.Contains(output, "CONTEXT #1 → #2") {
+			t.Errorf("Expected a diff between the pulls, got:\n%s", output)
+		}
+
+		if output, err := runCLI(t, tmpHome, "", "history", "delete", "1"); err != nil || !strings.Contains(output, "Deleted context #1") {
+			t.Errorf("Expected the context to be deleted, got:\n%s", output)
+		}
+		if output, err := runCLI(t, tmpHome, "", "history", "show", "1"); err == nil {
+			t.Errorf("Expected showing a deleted context to fail, got:\n%s", output)
+		}
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		tmpHome := t.TempDir()
+		if output, err := runCLI(t, tmpHome, "", "resume", "--clipboard=false"); err != nil || !strings.Contains(output, "No saved context") {
+			t.Errorf("Expected resume without history to point at pull, got: %v\n%s", err, output)
+		}
+
+		if output, err := runCLI(t, tmpHome, "", "pull", "--save", "--clipboard=false"); err != nil {
+			t.Fatalf("pull failed: %v\n%s", err, output)
+		}
+		output, err := runCLI(t, tmpHome, "", "resume", "--clipboard=false")
+		if err != nil {
+			t.Fatalf("resume failed: %v\n%s", err, output)
+		}
+		for _, want := range []string{"--- WHERE WAS I? ---", "LAST PULL:", "No commits since then", "--- END BRIEFING ---"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected briefing to contain %q, got:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("NotesAndCheckpoints", func(t *testing.T) {
+		tmpHome := t.TempDir()
+		if output, err := runCLI(t, tmpHome, "", "note", "Check", "the", "daemon", "shutdown"); err != nil || !strings.Contains(output, "Note #1 saved") {
+			t.Fatalf("Expected the note to be saved, got: %v\n%s", err, output)
+		}
+		if output, err := runCLI(t, tmpHome, "", "checkpoint", "Halfway through the parser"); err != nil || !strings.Contains(output, "Checkpoint #2 saved") {
+			t.Fatalf("Expected the checkpoint to be saved, got: %v\n%s", err, output)
+		}
+
+		output, err := runCLI(t, tmpHome, "", "pull", "--save=false", "--clipboard=false")
+		if err != nil || !strings.Contains(output, "📌 NOTES:") || !strings.Contains(output, "#1 ") || !strings.Contains(output, "⛳") {
+			t.Errorf("Expected open notes in the pulled context, got:\n%s", output)
+		}
+		output, err = runCLI(t, tmpHome, "", "history", "list")
+		if err != nil || !strings.Contains(output, "Checkpoint: Halfway through the parser") {
+			t.Errorf("Expected the checkpoint context in history, got:\n%s", output)
+		}
+
+		if output, err := runCLI(t, tmpHome, "", "note", "done", "#1"); err != nil || !strings.Contains(output, "Note #1 done") {
+			t.Errorf("Expected the note to be marked done, got:\n%s", output)
+		}
+		if output, err := runCLI(t, tmpHome, "", "note", "done", "1"); err == nil {
+			t.Errorf("Expected marking a done note again to fail, got:\n%s", output)
+		}
+		output, _ = runCLI(t, tmpHome, "", "note", "list")
+		if strings.Contains(output, "daemon shutdown") || !strings.Contains(output, "parser") {
+			t.Errorf("Expected only the checkpoint listed as open, got:\n%s", output)
+		}
+		output, _ = runCLI(t, tmpHome, "", "note", "list", "--done")
+		if !strings.Contains(output, "daemon shutdown") || !strings.Contains(output, "✅ done") {
+			t.Errorf("Expected --done to include the finished note, got:\n%s", output)
+		}
+
+		// Notes starting with a subcommand name are saved after --, and rejected by the subcommand otherwise
+		if output, err := runCLI(t, tmpHome, "", "note", "--", "list", "the", "parser", "edge", "cases"); err != nil || !strings.Contains(output, "Note #3 saved") {
+			t.Errorf("Expected a note starting with list to be saved after --, got: %v\n%s", err, output)
+		}
+		if output, err := runCLI(t, tmpHome, "", "note", "list", "the", "parser"); err == nil {
+			t.Errorf("Expected note list to reject extra words, got:\n%s", output)
+		}
+		if output, _ := runCLI(t, tmpHome, "", "note", "list"); !strings.Contains(output, "list the parser edge cases") {
+			t.Errorf("Expected the note starting with list to be listed, got:\n%s", output)
+		}
+	})
+
+	t.Run("DbPrune", func(t *testing.T) {
+		tmpHome := t.TempDir()
+		for _, args := range [][]string{
+			{"pull", "--save", "--clipboard=false"},
+			{"pull", "--save", "--clipboard=false"},
+			{"checkpoint"},
+			{"note", "done", "1"},
+			{"pull", "--save", "--clipboard=false"},
+		} {
+			if output, err := runCLI(t, tmpHome, "", args...); err != nil {
+				t.Fatalf("%v failed: %v\n%s", args, err, output)
+			}
+		}
+		output, _ := runCLI(t, tmpHome, "", "history", "list")
+		if strings.Count(output, "📅") != 3 {
+			t.Errorf("Expected the repeated pull to be deduplicated into 3 contexts, got:\n%s", output)
+		}
+
+		if output, err := runCLI(t, tmpHome, "", "db", "prune"); err == nil {
+			t.Errorf("Expected prune without limits to fail, got:\n%s", output)
+		}
+		output, err := runCLI(t, tmpHome, "", "db", "prune", "--keep-per-project", "1", "--dry-run")
+		if err != nil || !strings.Contains(output, "Would prune 2 context(s)") {
+			t.Errorf("Expected a dry run to count 2 contexts, got: %v\n%s", err, output)
+		}
+		output, err = runCLI(t, tmpHome, "", "db", "prune", "--keep-per-project", "1", "--older-than", "90d", "--compress")
+		if err != nil || !strings.Contains(output, "Pruned 2 context(s)") || !strings.Contains(output, "Compressed 1 context(s)") || !strings.Contains(output, "Reclaimed") {
+			t.Errorf("Expected contexts pruned, compressed and space reported, got: %v\n%s", err, output)
+		}
+		output, _ = runCLI(t, tmpHome, "", "history", "show", "3")
+		if !strings.Contains(output, "--- AI CONTEXT DEPLOYMENT ---") {
+			t.Errorf("Expected the compressed context to read back, got:\n%s", output)
+		}
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		tmpHome := t.TempDir()
+		if output, err := runCLI(t, tmpHome, "", "pull", "--clipboard=false"); err != nil {
+			t.Fatalf("pull failed: %v\n%s", err, output)
+		}
+		runCLI(t, tmpHome, "", "history", "show", "999")
+		output, err := runCLI(t, tmpHome, "", "stats")
+		if err != nil {
+			t.Fatalf("stats failed: %v\n%s", err, output)
+		}
+		for _, want := range []string{"passes the 10-second test: 1 of 1 pulls", "MOST PULLED", "history show: 1 runs, 1 failed (100%)", "SLOWEST SECTIONS", "commits: mean"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected stats to contain %q, got:\n%s", want, output)
+			}
+		}
+
+		configDir := filepath.Join(tmpHome, ".config", "wherewasi")
+		if err := os.MkdirAll(configDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("usage:\n  disabled: true\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runCLI(t, tmpHome, "", "pull", "--clipboard=false")
+		output, _ = runCLI(t, tmpHome, "", "stats")
+		if !strings.Contains(output, "1 of 1 pulls") || !strings.Contains(output, "stats: 1 runs") {
+			t.Errorf("Expected nothing recorded with usage disabled, got:\n%s", output)
+		}
+	})
+
+	t.Run("UsageErrors", func(t *testing.T) {
+		tmpHome := t.TempDir()
+		// A failing command and a usage error caught by cobra before the command runs
+		if output, err := runCLI(t, tmpHome, "", "history", "show", "999"); err == nil || !strings.Contains(output, "No saved context #999") {
+			t.Errorf("Expected history show 999 to fail, got: %v\n%s", err, output)
+		}
+		if output, err := runCLI(t, tmpHome, "", "history", "show"); err == nil {
+			t.Errorf("Expected history show without an ID to fail, got:\n%s", output)
+		}
+
+		usageDB, err := ecosystem.NewEcosystemDB(ecosystem.DatabaseConfig{
+			ToolName:     ecosystem.ToolWherewasi,
+			FallbackPath: filepath.Join(tmpHome, ".local", "share", "wherewasi", "context.sqlite"),
+			ForceLocal:   true,
+		})
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		defer usageDB.Close()
+		records, err := usageDB.GetUsage(ecosystem.ToolWherewasi, time.Time{})
+		if err != nil {
+			t.Fatalf("Failed to read usage: %v", err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("Expected both failures recorded, got %+v", records)
+		}
+		for i, want := range []string{"No saved context #999", "accepts 1 arg(s), received 0"} {
+			if records[i].Command != "history show" || records[i].Success || !strings.Contains(records[i].ErrorMessage, want) {
+				t.Errorf("Expected history show recorded as failed with %q, got %+v", want, records[i])
+			}
+		}
+	})
+
+	t.Run("ExportImport", func(t *testing.T) {
+		dir := t.TempDir()
+		source, target := filepath.Join(dir, "source"), filepath.Join(dir, "target")
+		exported := filepath.Join(dir, "history.tar")
+
+		for _, args := range [][]string{{"note", "Check the parser"}, {"checkpoint", "Halfway"}, {"export", "-o", exported}} {
+			if output, err := runCLI(t, source, "", args...); err != nil {
+				t.Fatalf("%v failed: %v\n%s", args, err, output)
+			}
+		}
+
+		output, err := runCLI(t, target, "", "import", exported)
+		if err != nil || !strings.Contains(output, "Imported 1 contexts, 0 projects, 2 notes") {
+			t.Fatalf("Expected the export to import, got: %v\n%s", err, output)
+		}
+		output, err = runCLI(t, target, "", "import", exported)
+		if err != nil || !strings.Contains(output, "3 already present, skipped") {
+			t.Errorf("Expected a second import to skip everything, got: %v\n%s", err, output)
+		}
+		if output, _ := runCLI(t, target, "", "note", "list"); !strings.Contains(output, "Check the parser") || !strings.Contains(output, "Halfway") {
+			t.Errorf("Expected imported notes, got:\n%s", output)
+		}
+
+		backup := filepath.Join(dir, "backup.sqlite")
+		output, err = runCLI(t, target, "", "db", "backup", backup)
+		if err != nil || !strings.Contains(output, "Backed up") {
+			t.Fatalf("Expected a backup, got: %v\n%s", err, output)
+		}
+		if _, err := os.Stat(backup); err != nil {
+			t.Errorf("Expected the backup file: %v", err)
+		}
+		if output, err := runCLI(t, target, "", "db", "backup", backup); err == nil {
+			t.Errorf("Expected an existing backup not to be overwritten, got:\n%s", output)
+		}
+	})
+
 	t.Run("KeywordSearch", func(t *testing.T) {
 		cmd := exec.Command(binary, "pull", "--keyword", "test", "--clipboard=false", "--save=false")
 		output, err := cmd.CombinedOutput()
@@ -139,6 +449,216 @@ func TestCLICommands(t *testing.T) {
 		}
 	})
 
+	t.Run("KeywordSearchRegex", func(t *testing.T) {
+		tmpHome := t.TempDir()
+		output, err := runCLI(t, tmpHome, "", "pull", "-k", "func (main|init)\\(", "--regex", "--case-sensitive", "--format", "json", "--clipboard=false", "--save=false")
+		if err != nil {
+			t.Fatalf("Regex search failed: %v\n%s", err, output)
+		}
+		if !strings.Contains(output, `"file": "main.go"`) {
+			t.Errorf("Expected a hit in main.go, got:\n%s", output)
+		}
+
+		output, err = runCLI(t, tmpHome, "", "pull", "-k", "func (", "--regex", "--clipboard=false", "--save=false")
+		if err == nil || !strings.Contains(output, "invalid search pattern") {
+			t.Errorf("Expected an invalid regex to fail, got:\n%s", output)
+		}
+	})
+
+	t.Run("SearchIndex", func(t *testing.T) {
+		tmpHome := t.TempDir()
+		current := searchEcosystem(t, tmpHome)
+		if output, err := runCLI(t, tmpHome, current, "index", "status"); err != nil || !strings.Contains(output, "The search index is empty") {
+			t.Errorf("Expected an empty index, got: %v\n%s", err, output)
+		}
+		output, err := runCLI(t, tmpHome, current, "index", "rebuild")
+		if err != nil {
+			t.Fatalf("index rebuild failed: %v\n%s", err, output)
+		}
+		for _, want := range []string{"• alpha: 1 files", "• beta: 2 files", "• gamma: 2 files", "✅ Indexed 5 files in 3 project(s)"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected the rebuild to contain %q, got:\n%s", want, output)
+			}
+		}
+
+		output, err = runCLI(t, tmpHome, current, "pull", "-k", "ripcord", "--clipboard=false", "--save=false")
+		if err != nil {
+			t.Fatalf("pull failed: %v\n%s", err, output)
+		}
+		var hits []string
+		for _, line := range strings.Split(output, "\n") {
+			if strings.HasPrefix(line, "  • [") {
+				hits = append(hits, strings.TrimPrefix(line, "  • "))
+			}
+		}
+		want := []string{
+			"[alpha] main.go:3 → // ripcord pulls the context",
+			"[alpha] main.go:4 → func ripcord() {}",
+			"[beta] notes.txt:1 → line 1 pulls the ripcord",
+			"[beta] notes.txt:2 → line 2 pulls the ripcord",
+			"[beta] notes.txt:3 → line 3 pulls the ripcord",
+			"[beta] notes.txt:4 → line 4 pulls the ripcord",
+			"[beta] README.md:1 → line 1 pulls the ripcord",
+			"[gamma] docs.txt:1 → line 1 pulls the ripcord",
+		}
+		if !slices.Equal(hits, want) {
+			t.Errorf("Expected indexed search hits\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(hits, "\n"))
+		}
+
+		if output, err := runCLI(t, tmpHome, current, "index", "status"); err != nil || !strings.Contains(output, "Search index: 3 project(s), 5 files") {
+			t.Errorf("Expected every ecosystem project in the index, got: %v\n%s", err, output)
+		}
+	})
+
+	t.Run("RankedSearch", func(t *testing.T) {
+		tmpHome := t.TempDir()
+		current := searchEcosystem(t, tmpHome)
+		search := func(perProject string) []string {
+			t.Helper()
+			output, err := runCLI(t, tmpHome, current, "pull", "-k", "ripcord", "--per-project", perProject, "--format", "json", "--clipboard=false", "--save=false")
+			if err != nil {
+				t.Fatalf("Ranked search failed: %v\n%s", err, output)
+			}
+			var doc struct {
+				SearchHits []contextdoc.SearchHit `json:"search_hits"`
+			}
+			// Database notices on stderr come before the document
+			start := strings.Index(output, "{")
+			if start < 0 {
+				t.Fatalf("Expected a JSON document, got:\n%s", output)
+			}
+			if err := json.NewDecoder(strings.NewReader(output[start:])).Decode(&doc); err != nil {
+				t.Fatalf("Failed to parse JSON: %v\n%s", err, output)
+			}
+			var hits []string
+			for i, hit := range doc.SearchHits {
+				if i > 0 && hit.Score > doc.SearchHits[i-1].Score {
+					t.Errorf("Expected hits best first, got %+v", doc.SearchHits)
+				}
+				hits = append(hits, fmt.Sprintf("%s %s:%d", hit.Project, hit.File, hit.Line))
+			}
+			return hits
+		}
+
+		// The current project ranks first, then files by match density and entry points
+		all := []string{
+			"alpha main.go:3", "alpha main.go:4",
+			"beta notes.txt:1", "beta notes.txt:2", "beta notes.txt:3", "beta notes.txt:4",
+			"beta README.md:1",
+			"gamma docs.txt:1",
+		}
+		if hits := search("0"); !slices.Equal(hits, all) {
+			t.Errorf("Expected every hit without a per-project cap\n%v\ngot\n%v", all, hits)
+		}
+		capped := []string{
+			"alpha main.go:3", "alpha main.go:4",
+			"beta notes.txt:1", "beta notes.txt:2", "beta notes.txt:3",
+			"gamma docs.txt:1",
+		}
+		if hits := search("3"); !slices.Equal(hits, capped) {
+			t.Errorf("Expected the per-project cap to keep the best 3 hits of each project\n%v\ngot\n%v", capped, hits)
+		}
+
+		if output, err := runCLI(t, tmpHome, current, "pull", "-k", "ripcord", "--per-project", "-1", "--clipboard=false", "--save=false"); err == nil || !strings.Contains(output, "--per-project must be") {
+			t.Errorf("Expected a negative cap to be rejected, got:\n%s", output)
+		}
+	})
+
+	t.Run("FocusedPull", func(t *testing.T) {
+		tmpHome := t.TempDir()
+		pull := func(target string) string {
+			t.Helper()
+			output, err := runCLI(t, tmpHome, "", "pull", "--focus", target, "--clipboard=false", "--save=false")
+			if err != nil {
+				t.Fatalf("Focused pull on %s failed: %v\n%s", target, err, output)
+			}
+			return output
+		}
+
+		output := pull("internal/search")
+		for _, want := range []string{"🔬 FOCUS: directory internal/search", "🔗 REFERENCES:", "main.go", "🧪 TESTS:", "internal/search/search_test.go"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected the directory focus to contain %q, got:\n%s", want, output)
+			}
+		}
+		if strings.Contains(output, "RECENT COMMITS") {
+			t.Errorf("Expected the focus sections to replace the usual ones, got:\n%s", output)
+		}
+
+		output = pull("searchCrossProject")
+		for _, want := range []string{"🔬 FOCUS: symbol searchCrossProject (main.go:", "📜 DECLARATION: func searchCrossProject(", "providers.go"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected the symbol focus to contain %q, got:\n%s", want, output)
+			}
+		}
+
+		if output, err := runCLI(t, tmpHome, "", "pull", "--focus", "noSuchSymbolAnywhere", "--clipboard=false", "--save=false"); err == nil || !strings.Contains(output, "is not a file, directory or Go symbol") {
+			t.Errorf("Expected an unknown focus to fail, got:\n%s", output)
+		}
+		if output, err := runCLI(t, tmpHome, "", "pull", "--focus", "main.go", "-k", "ripcord", "--clipboard=false", "--save=false"); err == nil || !strings.Contains(output, "cannot be combined") {
+			t.Errorf("Expected --focus with --keyword to be rejected, got:\n%s", output)
+		}
+	})
+
+	t.Run("TimeoutAndVerbose", func(t *testing.T) {
+		tmpHome := t.TempDir()
+		output, err := runCLI(t, tmpHome, "", "pull", "--verbose", "--clipboard=false", "--save=false")
+		if err != nil {
+			t.Fatalf("Verbose pull failed: %v\n%s", err, output)
+		}
+		if !strings.Contains(output, "Section timings:") || !strings.Contains(output, "• commits") {
+			t.Errorf("Expected per-section timings, got:\n%s", output)
+		}
+
+		output, err = runCLI(t, tmpHome, "", "pull", "--timeout", "1ns", "--clipboard=false", "--save=false")
+		if err != nil {
+			t.Fatalf("Pull with a timeout failed: %v\n%s", err, output)
+		}
+		if !strings.Contains(output, "COMMITS (timed out)") || !strings.Contains(output, "--- END CONTEXT ---") {
+			t.Errorf("Expected a complete context with timed out sections, got:\n%s", output)
+		}
+	})
+
+	t.Run("ProjectAlias", func(t *testing.T) {
+		tmpHome := t.TempDir()
+		repo := filepath.Join(t.TempDir(), "faraway")
+		if err := exec.Command("git", "init", "-q", repo).Run(); err != nil {
+			t.Fatalf("Failed to create repository: %v", err)
+		}
+
+		for _, args := range [][]string{
+			{"projects", "add", repo},
+			{"projects", "alias", "faraway", "fa"},
+		} {
+			cmd := exec.Command(binary, args...)
+			cmd.Env = append(os.Environ(), "HOME="+tmpHome)
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("%v failed: %v\n%s", args, err, output)
+			}
+		}
+
+		cmd := exec.Command(binary, "projects", "list")
+		cmd.Env = append(os.Environ(), "HOME="+tmpHome)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("Projects list failed: %v", err)
+		}
+		if !strings.Contains(string(output), "faraway (aka fa)") {
+			t.Errorf("Projects list should show the alias, got:\n%s", output)
+		}
+
+		cmd = exec.Command(binary, "pull", "--project", "fa", "--clipboard=false", "--save=false")
+		cmd.Env = append(os.Environ(), "HOME="+tmpHome)
+		output, err = cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("Pull with alias failed: %v", err)
+		}
+		outputStr := string(output)
+		if !strings.Contains(outputStr, "FOCUSED ON: faraway") || strings.Contains(outputStr, "Project not found") {
+			t.Errorf("Pull should resolve the alias to the project, got:\n%s", outputStr)
+		}
+	})
+
 	t.Run("InvalidCommand", func(t *testing.T) {
 		cmd := exec.Command(binary, "nonexistent")
 		_, err := cmd.CombinedOutput()
@@ -148,6 +668,21 @@ func TestCLICommands(t *testing.T) {
 	})
 }
 
+// runCLI runs the binary built by TestCLICommands with home as HOME, in dir or the package
+// directory when dir is empty, and returns its combined output
+func runCLI(t *testing.T, home, dir string, args ...string) (string, error) {
+	t.Helper()
+	binary, err := filepath.Abs(cliBinary)
+	if err != nil {
+		t.Fatalf("Failed to locate the test binary: %v", err)
+	}
+	cmd := exec.Command(binary, args...)
+	cmd.Env = append(os.Environ(), "HOME="+home)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
 func TestProjectDetection(t *testing.T) {
 	binary := "./wherewasi_test_project"
 	cmd := exec.Command("go", "build", "-o", binary, ".")
@@ -225,3 +760,97 @@ func TestFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in    string
+		limit int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"exactly ten", 11, "exactly ten"},
+		{"pull the ripcord now", 10, "pull th..."},
+		// Each of these is several bytes; a byte cut would split one
+		{"ripcord → déploiement → ✅✅✅", 12, "ripcord →..."},
+		{"日本語のテキストです", 6, "日本語..."},
+		{"ripcord", 3, "..."},
+		{"ripcord", 2, "ri"},
+		{"日本語", 1, "日"},
+		{"ripcord", 0, ""},
+	}
+	for _, test := range tests {
+		got := truncate(test.in, test.limit)
+		if got != test.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", test.in, test.limit, got, test.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %d) returned invalid UTF-8 %q", test.in, test.limit, got)
+		}
+	}
+}
+
+// searchEcosystem writes a config for home whose ecosystem root holds three repositories with
+// known "ripcord" hits, committed and modified long ago so only the current project and the
+// matches themselves rank them, and returns the alpha repository to search from:
+//
+//	alpha/main.go     lines 3-4 of 4    current project and entry point
+//	beta/notes.txt    lines 1-4 of 16
+//	beta/README.md    line 1 of 4       entry point
+//	gamma/docs.txt    line 1 of 9
+func searchEcosystem(t *testing.T, home string) string {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), "ecosystem")
+	lines := func(n int, hits ...int) string {
+		var text strings.Builder
+		for i := 1; i <= n; i++ {
+			line := fmt.Sprintf("line %d", i)
+			if slices.Contains(hits, i) {
+				line += " pulls the ripcord"
+			}
+			text.WriteString(line + "\n")
+		}
+		return text.String()
+	}
+	repos := map[string]map[string]string{
+		"alpha": {"main.go": "package main\n\n// ripcord pulls the context\nfunc ripcord() {}\n"},
+		"beta":  {"notes.txt": lines(16, 1, 2, 3, 4), "README.md": lines(4, 1)},
+		"gamma": {"docs.txt": lines(9, 1), "todo.txt": lines(3)},
+	}
+	long := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	for name, files := range repos {
+		dir := filepath.Join(root, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for file, content := range files {
+			path := filepath.Join(dir, file)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, long, long); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, args := range [][]string{
+			{"init", "-q"},
+			{"add", "."},
+			{"-c", "user.email=test@example.com", "-c", "user.name=Test", "commit", "-q", "-m", "Initial commit"},
+		} {
+			cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+			cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+long.Format(time.RFC3339), "GIT_COMMITTER_DATE="+long.Format(time.RFC3339))
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\n%s", args, err, output)
+			}
+		}
+	}
+
+	configDir := filepath.Join(home, ".config", "wherewasi")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := fmt.Sprintf("ecosystem:\n  roots:\n    - path: %s\n", root)
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(root, "alpha")
+}
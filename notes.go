@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/contextdoc"
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/QRY91/wherewasi/internal/provider"
+	"github.com/QRY91/wherewasi/internal/search"
+	"github.com/spf13/cobra"
+)
+
+// Notes section in pulled contexts
+const (
+	sectionNotes = "notes"
+	titleNotes   = "📌 NOTES"
+	// contextNoteLimit is how many open notes a pull includes
+	contextNoteLimit = 5
+)
+
+var noteCmd = &cobra.Command{
+	Use:   "note [text...]",
+	Short: "Leave a note for later on the current project and branch",
+	Long: `Save a timestamped note for the current project and branch. Open notes show up in every pull until marked done. Without text, lists open notes.
+
+A note starting with "list" or "done" would run that subcommand instead; put -- before the text to save it as a note:
+  wherewasi note -- list the parser edge cases`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireDB()
+		text := strings.TrimSpace(strings.Join(args, " "))
+		if text == "" {
+			showNotes(ecosystem.NoteFilter{Project: getProjectName(), Limit: 20})
+			return
+		}
+
+		note, err := addNote(ecosystem.NoteKindNote, text, nil)
+		if err != nil {
+			fail("Could not save note: %v", err)
+		}
+		fmt.Printf("📌 Note #%d saved for %s%s\n", note.ID, note.Project, noteBranchSuffix(*note))
+	},
+}
+
+var noteListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List notes, newest first",
+	// Extra words are most likely a note starting with "list"; see noteCmd
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		project, _ := cmd.Flags().GetString("project")
+		all, _ := cmd.Flags().GetBool("all")
+		done, _ := cmd.Flags().GetBool("done")
+		branch, _ := cmd.Flags().GetString("branch")
+		limit, _ := cmd.Flags().GetInt("limit")
+		if limit < 1 {
+			fail("--limit must be positive")
+		}
+		requireDB()
+
+		filter := ecosystem.NoteFilter{Branch: branch, IncludeDone: done, Limit: limit}
+		switch {
+		case project != "":
+			filter.Project = canonicalProjectName(project)
+		case !all:
+			filter.Project = getProjectName()
+		}
+		showNotes(filter)
+	},
+}
+
+var noteDoneCmd = &cobra.Command{
+	Use:   "done <id>...",
+	Short: "Mark notes as done so they no longer show up in pulls",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireDB()
+		var errs []error
+		for _, arg := range args {
+			id, err := parseID("note", arg)
+			if err == nil {
+				err = db.MarkNoteDone(id)
+			}
+			if err != nil {
+				fmt.Printf("⚠️  Could not mark %s done: %v\n", arg, err)
+				err = fmt.Errorf("could not mark %s done: %w", arg, err)
+				errs = append(errs, err)
+				continue
+			}
+			fmt.Printf("✅ Note #%d done\n", id)
+		}
+		if len(errs) > 0 {
+			exitWithError(errors.Join(errs...))
+		}
+	},
+}
+
+var checkpointCmd = &cobra.Command{
+	Use:   "checkpoint [message...]",
+	Short: "Save the current context with a note on where you are",
+	Long:  "Pull and save the current context without copying it, and leave a checkpoint note linked to it. The note shows up in pulls until marked done.",
+	Args:  cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireDB()
+		message := strings.TrimSpace(strings.Join(args, " "))
+
+		doc, _, err := buildContextDocument(context.Background(), "", 0, search.Query{}, defaultPerProjectHits)
+		if err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+		sessionInfo := "Checkpoint"
+		if message != "" {
+			sessionInfo += ": " + message
+		}
+		session, err := db.SaveContext(getProjectName(), doc.Text(), sessionInfo, "", doc.Git)
+		if err != nil {
+			fail("Could not save context: %v", err)
+		}
+
+		body := message
+		if body == "" {
+			body = fmt.Sprintf("Checkpoint at context #%d", session.ID)
+		}
+		note, err := addNote(ecosystem.NoteKindCheckpoint, body, &session.ID)
+		if err != nil {
+			fail("Could not save checkpoint note: %v", err)
+		}
+		fmt.Printf("⛳ Checkpoint #%d saved for %s%s (context #%d)\n", note.ID, note.Project, noteBranchSuffix(*note), session.ID)
+	},
+}
+
+// addNote saves a note for the current project, stamped with the current branch and commit
+func addNote(kind, body string, contextID *int64) (*ecosystem.Note, error) {
+	note := ecosystem.Note{Project: getProjectName(), Kind: kind, Body: body, ContextID: contextID}
+	if git := getGitState(); git != nil {
+		note.Branch, note.Commit = git.Branch, git.Commit
+	}
+	return db.AddNote(note)
+}
+
+// showNotes prints the notes matching filter
+func showNotes(filter ecosystem.NoteFilter) {
+	notes, err := db.GetNotes(filter)
+	if err != nil {
+		fail("Could not get notes: %v", err)
+	}
+
+	scope := filter.Project
+	if scope == "" {
+		scope = "all projects"
+	}
+	if len(notes) == 0 {
+		fmt.Printf("📌 No open notes for %s (add one with: wherewasi note \"...\")\n", scope)
+		return
+	}
+
+	fmt.Printf("📌 NOTES (%s):\n", scope)
+	for _, note := range notes {
+		entry := formatNote(note, formatAge(time.Since(note.CreatedAt))+" ago")
+		if filter.Project == "" {
+			entry = fmt.Sprintf("[%s] %s", note.Project, entry)
+		}
+		if note.DoneAt != nil {
+			entry += fmt.Sprintf(" ✅ done %s ago", formatAge(time.Since(*note.DoneAt)))
+		}
+		fmt.Printf("  • #%d %s\n", note.ID, entry)
+	}
+}
+
+// formatNote renders a note as "<when> on main: text", flagging checkpoints
+func formatNote(note ecosystem.Note, when string) string {
+	marker := ""
+	if note.Kind == ecosystem.NoteKindCheckpoint {
+		marker = "⛳ "
+	}
+	return fmt.Sprintf("%s%s%s: %s", marker, when, noteBranchSuffix(note), note.Body)
+}
+
+func noteBranchSuffix(note ecosystem.Note) string {
+	if note.Branch == "" {
+		return ""
+	}
+	return " on " + note.Branch
+}
+
+// getOpenNotes lists the newest open notes for a project, formatted for contexts and briefings.
+// Notes carry their creation time rather than an age, so repeated pulls produce the same text.
+func getOpenNotes(project string, limit int) []string {
+	if db == nil {
+		return nil
+	}
+	notes, err := db.GetNotes(ecosystem.NoteFilter{Project: project, Limit: limit})
+	if err != nil {
+		return nil
+	}
+
+	entries := make([]string, 0, len(notes))
+	for _, note := range notes {
+		entries = append(entries, fmt.Sprintf("#%d %s", note.ID, formatNote(note, note.CreatedAt.Local().Format("01-02 15:04"))))
+	}
+	return entries
+}
+
+// provideNotes adds the open notes left on the project
+func provideNotes(ctx context.Context, req provider.Request, doc *contextdoc.Document) error {
+	if notes := getOpenNotes(doc.Project, contextNoteLimit); len(notes) > 0 {
+		doc.AddSection(sectionNotes, titleNotes, notes...)
+	}
+	return nil
+}
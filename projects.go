@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/config"
+	"github.com/QRY91/wherewasi/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var projectsCmd = &cobra.Command{
+	Use:   "projects",
+	Short: "Manage the projects that make up the ecosystem",
+	Long:  "List, add, remove, alias and describe projects in the projects table. Discovered repositories are added automatically.",
+}
+
+var projectsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List projects, most recently active first",
+	Run: func(cmd *cobra.Command, args []string) {
+		requireDB()
+		limit, _ := cmd.Flags().GetInt("limit")
+		if limit <= 0 {
+			// SQLite treats a negative limit as no limit
+			limit = -1
+		}
+
+		ecosystemProjects()
+		projects, err := db.GetRecentProjects(limit)
+		if err != nil {
+			fail("Could not list projects: %v", err)
+		}
+		aliases, err := db.GetProjectAliases()
+		if err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+
+		if len(projects) == 0 {
+			fmt.Println("📂 No projects yet (add one with: wherewasi projects add <path>)")
+			return
+		}
+		fmt.Printf("📂 Projects (%d):\n", len(projects))
+		for _, project := range projects {
+			fmt.Printf("  • %s", project.Name)
+			if len(aliases[project.Name]) > 0 {
+				fmt.Printf(" (aka %s)", strings.Join(aliases[project.Name], ", "))
+			}
+			fmt.Printf(" | %s | %s\n", formatLastActivity(project.LastActivity), valueOr(project.Path, "no path"))
+		}
+	},
+}
+
+var projectsAddCmd = &cobra.Command{
+	Use:   "add <path>",
+	Short: "Add a project outside the configured roots",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireDB()
+		name, _ := cmd.Flags().GetString("name")
+		tool, _ := cmd.Flags().GetString("tool")
+		description, _ := cmd.Flags().GetString("description")
+
+		path, err := workspace.ExpandPath(args[0])
+		if err != nil {
+			fail("%v", err)
+		}
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			fail("%s is not a directory", path)
+		}
+		if name == "" {
+			name = filepath.Base(path)
+		}
+
+		if err := db.TrackProject(name, path, tool, workspace.IsRepository(path)); err != nil {
+			fail("Could not add project: %v", err)
+		}
+		if description != "" {
+			if err := db.SetProjectDescription(name, description); err != nil {
+				fail("Could not set description: %v", err)
+			}
+		}
+
+		fmt.Printf("✅ Added %s (%s)\n", name, path)
+		if !workspace.IsRepository(path) {
+			fmt.Println("⚠️  Not a git repository - commits and search will be unavailable")
+		}
+	},
+}
+
+var projectsRemoveCmd = &cobra.Command{
+	Use:   "remove <project>",
+	Short: "Remove a project and its aliases",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireDB()
+		name := canonicalProjectName(args[0])
+
+		if err := db.RemoveProject(name); err != nil {
+			fail("Could not remove project: %v", err)
+		}
+		fmt.Printf("🧹 Removed %s\n", name)
+
+		// Discovery would add it straight back
+		discovered, _ := workspace.Discover(workspaceOptions())
+		for _, project := range discovered {
+			if project.Name == name {
+				fmt.Printf("⚠️  %s is under an ecosystem root and will be rediscovered; add it to ecosystem.exclude in %s\n", name, config.Path())
+				break
+			}
+		}
+	},
+}
+
+var projectsAliasCmd = &cobra.Command{
+	Use:   "alias <project> <alias>",
+	Short: "Add a short name for a project (use --remove <alias> to delete one)",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if remove, _ := cmd.Flags().GetBool("remove"); remove {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		requireDB()
+
+		if remove, _ := cmd.Flags().GetBool("remove"); remove {
+			if err := db.RemoveProjectAlias(args[0]); err != nil {
+				fail("Could not remove alias: %v", err)
+			}
+			fmt.Printf("🧹 Removed alias %s\n", args[0])
+			return
+		}
+
+		name := canonicalProjectName(args[0])
+		if project, err := db.GetProject(name); err != nil || project == nil {
+			fail("Unknown project %s (see: wherewasi projects list)", args[0])
+		}
+		if err := db.AddProjectAlias(args[1], name); err != nil {
+			fail("Could not add alias: %v", err)
+		}
+		fmt.Printf("✅ %s → %s\n", args[1], name)
+	},
+}
+
+var projectsDescribeCmd = &cobra.Command{
+	Use:   "describe <project> [description]",
+	Short: "Show or set a project's description in the projects table",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireDB()
+		clear, _ := cmd.Flags().GetBool("clear")
+		name := canonicalProjectName(args[0])
+
+		if len(args) > 1 || clear {
+			description := strings.Join(args[1:], " ")
+			if clear {
+				description = ""
+			}
+			if err := db.SetProjectDescription(name, description); err != nil {
+				fail("Could not set description: %v", err)
+			}
+			fmt.Println("✅ Description updated")
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			cfg = &config.Config{}
+		}
+		showProjectDescription(cfg, name, projectPath(args[0]))
+	},
+}
+
+var projectsShowCmd = &cobra.Command{
+	Use:   "show <project>",
+	Short: "Show a project's details, git state and recent activity",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireDB()
+		name := canonicalProjectName(args[0])
+
+		project, err := db.GetProject(name)
+		if err != nil {
+			fail("%v", err)
+		}
+		if project == nil {
+			fail("Unknown project %s (see: wherewasi projects list)", args[0])
+		}
+		aliases, _ := db.GetProjectAliases()
+
+		fmt.Printf("📂 %s\n", project.Name)
+		fmt.Printf("  📍 Path: %s\n", valueOr(project.Path, "none"))
+		if len(aliases[project.Name]) > 0 {
+			fmt.Printf("  🏷️  Aliases: %s\n", strings.Join(aliases[project.Name], ", "))
+		}
+		fmt.Printf("  🛠️  Primary tool: %s\n", valueOr(derefString(project.PrimaryTool), "unknown"))
+		fmt.Printf("  ⏰ Last activity: %s\n", formatLastActivity(project.LastActivity))
+
+		if project.Path != "" && workspace.IsRepository(project.Path) {
+			branch := gitValue(project.Path, "symbolic-ref", "--short", "-q", "HEAD")
+			commit := gitValue(project.Path, "log", "-1", "--format=%h %s")
+			fmt.Printf("  🌿 Branch: %s @ %s\n", valueOr(branch, "detached"), commit)
+			fmt.Printf("  🪝 Hooks: %s\n", hookStatus(project.Path))
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			cfg = &config.Config{}
+		}
+		showProjectDescription(cfg, project.Name, project.Path)
+
+		if activity := getTrackedActivity(project.Name, 7*24*time.Hour, 5); len(activity) > 0 {
+			fmt.Println("  📡 Recent activity (7d):")
+			for _, entry := range activity {
+				fmt.Printf("    • %s\n", entry)
+			}
+		}
+	},
+}
+
+// requireDB exits when the database could not be opened
+func requireDB() {
+	if db == nil {
+		fail("Database not available")
+	}
+}
+
+// canonicalProjectName resolves an alias or path to a project name, passing unknown names through
+func canonicalProjectName(ref string) string {
+	if found, ok := findProject(ref); ok {
+		return found.Name
+	}
+	return ref
+}
+
+func showProjectDescription(cfg *config.Config, name, root string) {
+	description, source, err := projectDescription(cfg, name, root)
+	switch {
+	case err != nil:
+		fmt.Printf("  ⚠️  %v\n", err)
+	case description == "":
+		fmt.Println("  📖 No description (set with: wherewasi projects describe <project> \"...\")")
+	default:
+		fmt.Printf("  📖 %s (from %s)\n", description, source)
+	}
+}
+
+func formatLastActivity(t *time.Time) string {
+	if t == nil {
+		return "never active"
+	}
+	return formatAge(time.Since(*t)) + " ago"
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/contextdoc"
+	"github.com/QRY91/wherewasi/internal/gitinfo"
+	"github.com/QRY91/wherewasi/internal/provider"
+)
+
+// The built-in context sections, registered in their default order.
+// Sections can be reordered or disabled in config.yaml; further providers register the same way.
+func init() {
+	provider.Register(provider.Func(contextdoc.SectionEcosystem, provideEcosystem))
+	provider.Register(provider.Func(sectionNotes, provideNotes))
+	provider.Register(provider.Func(contextdoc.SectionCommits, provideCommits))
+	provider.Register(provider.Func(contextdoc.SectionChanges, provideChanges))
+	provider.Register(provider.Func(contextdoc.SectionKeyFiles, provideKeyFiles))
+	provider.Register(provider.Func(contextdoc.SectionActivity, provideActivity))
+	provider.Register(provider.Func(contextdoc.SectionInsights, provideInsights))
+	provider.Register(provider.Func(contextdoc.SectionSearch, provideSearch))
+}
+
+// provideEcosystem adds the configured ecosystem description and the project's own description
+func provideEcosystem(ctx context.Context, req provider.Request, doc *contextdoc.Document) error {
+	doc.EcosystemName = req.Config.Ecosystem.Name
+	doc.Ecosystem = req.Config.Ecosystem.Description
+
+	description, _, err := projectDescription(req.Config, doc.Project, projectPath(req.Project))
+	doc.Description = description
+	return err
+}
+
+// provideCommits adds commits from the --days window, or the most recent commits by default
+func provideCommits(ctx context.Context, req provider.Request, doc *contextdoc.Document) error {
+	var commits []contextdoc.Commit
+	var err error
+	if req.Days > 0 {
+		doc.CommitDays = req.Days
+		commits, err = getCommitsSince(ctx, req.Days, req.Project)
+	} else {
+		commits, err = getRecentCommits(ctx, 5)
+	}
+	if err != nil {
+		doc.CommitError = "No git history found"
+		switch {
+		case errors.Is(err, gitinfo.ErrNotRepository):
+			doc.CommitError = "Not a git repository"
+		case errors.Is(err, gitinfo.ErrNoCommits):
+			doc.CommitError = "No commits yet"
+		default:
+			return err
+		}
+	}
+	matches := keywordFilter(req)
+	for _, commit := range commits {
+		if matches(commit.SHA + " " + commit.Subject) {
+			doc.Commits = append(doc.Commits, commit)
+		}
+	}
+	return nil
+}
+
+func provideChanges(ctx context.Context, req provider.Request, doc *contextdoc.Document) error {
+	changes, err := getUncommittedChanges(ctx)
+	// Outside a repository there is nothing to report
+	if err != nil && !errors.Is(err, gitinfo.ErrNotRepository) {
+		return err
+	}
+	matches := keywordFilter(req)
+	for _, change := range changes {
+		if matches(change.Status + " " + change.Path) {
+			doc.Changes = append(doc.Changes, change)
+		}
+	}
+	return nil
+}
+
+func provideKeyFiles(ctx context.Context, req provider.Request, doc *contextdoc.Document) error {
+	doc.KeyFiles = getKeyFiles()
+	return nil
+}
+
+// provideActivity adds what the background tracker and git hooks recorded
+func provideActivity(ctx context.Context, req provider.Request, doc *contextdoc.Document) error {
+	doc.Activity = getTrackedActivity(doc.Project, 24*time.Hour, 10)
+	return nil
+}
+
+// provideInsights adds recent development insights from chat history
+func provideInsights(ctx context.Context, req provider.Request, doc *contextdoc.Document) error {
+	doc.Insights = getRecentChatInsights(ctx)
+	return nil
+}
+
+func provideSearch(ctx context.Context, req provider.Request, doc *contextdoc.Document) error {
+	if req.Keyword == "" {
+		return nil
+	}
+	hits, err := searchCrossProject(ctx, keywordQuery(req), req.Project, req.PerProject)
+	doc.SearchHits = hits
+	return err
+}
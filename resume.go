@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/contextdoc"
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/QRY91/wherewasi/internal/gitinfo"
+	"github.com/atotto/clipboard"
+	"github.com/spf13/cobra"
+)
+
+// defaultSessionInfo is what pull saves when there is no active session to describe
+const defaultSessionInfo = "Context pull"
+
+// resumeCommitLimit is how many commits since the last pull are listed
+const resumeCommitLimit = 20
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Where was I? Brief me on what changed since my last pull",
+	Long:  "Compare the last saved context for the current project with the repository now: time since the pull, new commits, branch changes, working tree changes and the notes you left.",
+	Run: func(cmd *cobra.Command, args []string) {
+		clipboardFlag, _ := cmd.Flags().GetBool("clipboard")
+		requireDB()
+
+		project := getProjectName()
+		sessions, err := db.ListContexts(ecosystem.ContextSearch{Project: project, Limit: 1})
+		if err != nil {
+			fail("Could not load history: %v", err)
+		}
+		if len(sessions) == 0 {
+			fmt.Printf("📚 No saved context for %s yet - run 'wherewasi pull' to leave yourself a trail\n", project)
+			return
+		}
+
+		changes, err := getUncommittedChanges(context.Background())
+		if err != nil && !errors.Is(err, gitinfo.ErrNotRepository) {
+			fmt.Printf("⚠️  Could not read working tree: %v\n", err)
+		}
+		briefing := buildResumeBriefing("", sessions[0], getGitState(), changes)
+		briefing.Notes = append(briefing.Notes, getOpenNotes(project, contextNoteLimit)...)
+		fmt.Println(briefing.Text())
+
+		if clipboardFlag {
+			if err := clipboard.WriteAll(briefing.Text()); err != nil {
+				fmt.Printf("⚠️  Could not copy to clipboard: %v\n", err)
+			} else {
+				fmt.Println("📋 Briefing copied to clipboard! Paste and pick up where you left off.")
+			}
+		}
+	},
+}
+
+// resumeBriefing compares the last saved context with the repository now
+type resumeBriefing struct {
+	Project string
+	Last    ecosystem.ContextSession
+	Age     time.Duration
+
+	Then, Now *ecosystem.GitState
+	// Commits made since the last pull, newest first and at most resumeCommitLimit; CommitCount
+	// is how many there are in all. Orphaned counts commits from then that are no longer on HEAD.
+	Commits     []contextdoc.Commit
+	CommitCount int
+	Orphaned    int
+
+	StillDirty, NewlyDirty, Cleaned []contextdoc.Change
+	Notes                           []string
+}
+
+// buildResumeBriefing gathers what changed between a saved session and the current git state
+// of the repository at dir, or the current one when dir is ""
+func buildResumeBriefing(dir string, last ecosystem.ContextSession, now *ecosystem.GitState, changes []contextdoc.Change) *resumeBriefing {
+	then := contextdoc.Parse(last.ContextData)
+	briefing := &resumeBriefing{
+		Project: last.Project,
+		Last:    last,
+		Age:     time.Since(last.Timestamp),
+		Then:    sessionGitState(last),
+		Now:     now,
+	}
+
+	// Git knows exactly what happened since the saved commit; the parsed context is the fallback
+	// when that commit is gone, e.g. after a rebase
+	current := &contextdoc.Document{Changes: changes}
+	if commits, count, orphaned, err := commitsSinceSession(dir, briefing.Then); err == nil {
+		briefing.Commits, briefing.CommitCount, briefing.Orphaned = commits, count, orphaned
+	} else if recent, err := readCommits(context.Background(), dir, gitinfo.LogOptions{Limit: resumeCommitLimit}); err == nil {
+		current.Commits = recent
+	}
+	diff := contextdoc.Compare(then, current)
+	if briefing.Commits == nil {
+		briefing.Commits, briefing.CommitCount = diff.NewCommits, len(diff.NewCommits)
+	}
+	briefing.NewlyDirty, briefing.Cleaned = diff.Dirtied, diff.Cleaned
+
+	dirtyNow := make(map[string]bool, len(changes))
+	for _, change := range diff.Dirtied {
+		dirtyNow[change.Path] = true
+	}
+	for _, change := range changes {
+		if !dirtyNow[change.Path] {
+			briefing.StillDirty = append(briefing.StillDirty, change)
+		}
+	}
+
+	if last.SessionInfo != "" && last.SessionInfo != defaultSessionInfo {
+		briefing.Notes = append(briefing.Notes, last.SessionInfo)
+	}
+	if then.Session != "" && then.Session != last.SessionInfo {
+		briefing.Notes = append(briefing.Notes, then.Session)
+	}
+	briefing.Notes = append(briefing.Notes, then.Insights...)
+	return briefing
+}
+
+// commitsSinceSession lists the latest commits on HEAD after the saved commit with how many there
+// are in all, and counts saved commits HEAD no longer has
+func commitsSinceSession(dir string, then *ecosystem.GitState) ([]contextdoc.Commit, int, int, error) {
+	if then == nil || then.Commit == "" {
+		return nil, 0, 0, fmt.Errorf("no commit recorded")
+	}
+	ctx := context.Background()
+	repo, err := gitinfo.Open(ctx, dir)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer repo.Close()
+
+	since := then.Commit + "..HEAD"
+	commits, err := readCommits(ctx, repo.Root(), gitinfo.LogOptions{Revisions: []string{since}, Limit: resumeCommitLimit})
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to list commits since %s: %w", then.Commit, err)
+	}
+	count, err := repo.Count(ctx, since)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to count commits since %s: %w", then.Commit, err)
+	}
+
+	orphaned, _ := repo.Count(ctx, "HEAD.."+then.Commit)
+	return commits, count, orphaned, nil
+}
+
+// Text renders the briefing for the terminal and the clipboard
+func (b *resumeBriefing) Text() string {
+	var text strings.Builder
+	text.WriteString("--- WHERE WAS I? ---\n")
+	text.WriteString(fmt.Sprintf("🏠 PROJECT: %s\n", b.Project))
+	text.WriteString(fmt.Sprintf("⏱️  LAST PULL: %s ago (#%d, %s)\n", formatAge(b.Age), b.Last.ID, b.Last.Timestamp.Local().Format("2006-01-02T15:04")))
+
+	switch {
+	case b.Then == nil || b.Now == nil:
+	case b.Then.Branch != b.Now.Branch:
+		text.WriteString(fmt.Sprintf("🌿 BRANCH CHANGED: %s → %s\n", b.Then.String(), b.Now.String()))
+	default:
+		text.WriteString(fmt.Sprintf("🌿 BRANCH: %s\n", b.Now.String()))
+	}
+
+	if len(b.Commits) > 0 {
+		text.WriteString(fmt.Sprintf("\n📝 COMMITS SINCE THEN (%d):\n", b.CommitCount))
+		for _, commit := range b.Commits {
+			text.WriteString(fmt.Sprintf("  • %s %s\n", commit.SHA, commit.Subject))
+		}
+		if more := b.CommitCount - len(b.Commits); more > 0 {
+			text.WriteString(fmt.Sprintf("  • … and %d more\n", more))
+		}
+	} else {
+		text.WriteString("\n📝 No commits since then\n")
+	}
+	if b.Orphaned > 0 {
+		text.WriteString(fmt.Sprintf("  ⚠️  %d commit(s) from then are no longer on this branch\n", b.Orphaned))
+	}
+
+	if len(b.StillDirty)+len(b.NewlyDirty)+len(b.Cleaned) > 0 {
+		text.WriteString("\n🔄 WORKING TREE:\n")
+		for _, change := range b.StillDirty {
+			text.WriteString(fmt.Sprintf("  • still uncommitted: %s %s\n", change.Status, change.Path))
+		}
+		for _, change := range b.NewlyDirty {
+			text.WriteString(fmt.Sprintf("  • newly changed: %s %s\n", change.Status, change.Path))
+		}
+		for _, change := range b.Cleaned {
+			text.WriteString(fmt.Sprintf("  • committed or reverted: %s\n", change.Path))
+		}
+	}
+
+	if len(b.Notes) > 0 {
+		text.WriteString("\n💭 WHERE YOU LEFT OFF:\n")
+		for _, note := range b.Notes {
+			text.WriteString(fmt.Sprintf("  • %s\n", note))
+		}
+	}
+
+	text.WriteString("--- END BRIEFING ---")
+	return text.String()
+}
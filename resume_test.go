@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/contextdoc"
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+)
+
+// savedSession is a session as pull saves it: the rendered context and its git stamp
+func savedSession(doc *contextdoc.Document, branch, commit string) ecosystem.ContextSession {
+	session := ecosystem.ContextSession{
+		ID:          1,
+		Project:     "wherewasi",
+		Timestamp:   time.Now().Add(-2 * time.Hour),
+		ContextData: doc.Text(),
+		SessionInfo: defaultSessionInfo,
+	}
+	if branch != "" {
+		session.GitBranch, session.GitCommit = &branch, &commit
+	}
+	return session
+}
+
+func changePaths(changes []contextdoc.Change) []string {
+	var paths []string
+	for _, change := range changes {
+		paths = append(paths, change.Status+" "+change.Path)
+	}
+	return paths
+}
+
+func TestBuildResumeBriefing(t *testing.T) {
+	thenDoc := &contextdoc.Document{
+		Project: "wherewasi",
+		Changes: []contextdoc.Change{{Status: "M", Path: "a.go"}, {Status: "M", Path: "b.go"}, {Status: "M", Path: "c.go"}},
+	}
+	insightDoc := &contextdoc.Document{Project: "wherewasi", Insights: []string{"Parser needs a second pass"}}
+
+	tests := []struct {
+		name    string
+		session ecosystem.ContextSession
+		now     *ecosystem.GitState
+		changes []contextdoc.Change
+
+		still, newly, cleaned []string
+		notes                 []string
+		text, notText         []string
+	}{
+		{
+			name:    "working tree split",
+			session: savedSession(thenDoc, "", ""),
+			changes: []contextdoc.Change{{Status: "M", Path: "a.go"}, {Status: "A", Path: "c.go"}, {Status: "??", Path: "d.go"}},
+			still:   []string{"M a.go"},
+			newly:   []string{"A c.go", "?? d.go"},
+			cleaned: []string{"M b.go"},
+			text:    []string{"still uncommitted: M a.go", "newly changed: A c.go", "newly changed: ?? d.go", "committed or reverted: b.go", "No commits since then"},
+		},
+		{
+			name:    "branch changed",
+			session: savedSession(insightDoc, "main", "abc1234def"),
+			now:     &ecosystem.GitState{Branch: "feature", Commit: "0123456789"},
+			notes:   []string{"Parser needs a second pass"},
+			text:    []string{"🌿 BRANCH CHANGED: main @ abc1234 → feature @ 0123456"},
+		},
+		{
+			name:    "same branch",
+			session: savedSession(&contextdoc.Document{Project: "wherewasi"}, "main", "abc1234def"),
+			now:     &ecosystem.GitState{Branch: "main", Commit: "abc1234def", Dirty: true},
+			text:    []string{"🌿 BRANCH: main @ abc1234 (dirty)"},
+			notText: []string{"BRANCH CHANGED"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Outside a repository only the saved context is compared
+			briefing := buildResumeBriefing(t.TempDir(), test.session, test.now, test.changes)
+			if got := changePaths(briefing.StillDirty); !reflect.DeepEqual(got, test.still) {
+				t.Errorf("Expected still dirty %v, got %v", test.still, got)
+			}
+			if got := changePaths(briefing.NewlyDirty); !reflect.DeepEqual(got, test.newly) {
+				t.Errorf("Expected newly dirty %v, got %v", test.newly, got)
+			}
+			if got := changePaths(briefing.Cleaned); !reflect.DeepEqual(got, test.cleaned) {
+				t.Errorf("Expected cleaned %v, got %v", test.cleaned, got)
+			}
+			if !reflect.DeepEqual(briefing.Notes, test.notes) {
+				t.Errorf("Expected notes %v, got %v", test.notes, briefing.Notes)
+			}
+			text := briefing.Text()
+			for _, want := range test.text {
+				if !strings.Contains(text, want) {
+					t.Errorf("Expected the briefing to contain %q:\n%s", want, text)
+				}
+			}
+			for _, unwanted := range test.notText {
+				if strings.Contains(text, unwanted) {
+					t.Errorf("Expected the briefing not to contain %q:\n%s", unwanted, text)
+				}
+			}
+		})
+	}
+}
+
+func TestResumeCommitsSinceSession(t *testing.T) {
+	repo := initRepo(t)
+	commit := func(subject string) string {
+		t.Helper()
+		if output, err := exec.Command("git", "-C", repo, "commit", "-q", "--allow-empty", "-m", subject).CombinedOutput(); err != nil {
+			t.Fatalf("Commit failed: %v\n%s", err, output)
+		}
+		return gitValue(repo, "rev-parse", "HEAD")
+	}
+	subjects := func(commits []contextdoc.Commit) []string {
+		var result []string
+		for _, commit := range commits {
+			result = append(result, commit.Subject)
+		}
+		return result
+	}
+
+	initial := gitValue(repo, "rev-parse", "HEAD")
+	saved := commit("Saved")
+	branch := gitValue(repo, "symbolic-ref", "--short", "HEAD")
+	thenDoc := &contextdoc.Document{Project: "wherewasi", Commits: []contextdoc.Commit{
+		{SHA: saved[:7], Subject: "Saved"},
+		{SHA: initial[:7], Subject: "Initial commit"},
+	}}
+	session := savedSession(thenDoc, branch, saved)
+
+	commit("After the pull")
+	briefing := buildResumeBriefing(repo, session, nil, nil)
+	if got := subjects(briefing.Commits); !reflect.DeepEqual(got, []string{"After the pull"}) || briefing.CommitCount != 1 || briefing.Orphaned != 0 {
+		t.Errorf("Expected the commit made after the pull, got %v (%d in all, %d orphaned)", got, briefing.CommitCount, briefing.Orphaned)
+	}
+
+	// Only the latest commits are listed, with the true count
+	for i := 0; i < resumeCommitLimit+1; i++ {
+		commit(fmt.Sprintf("Busy %d", i))
+	}
+	briefing = buildResumeBriefing(repo, session, nil, nil)
+	if len(briefing.Commits) != resumeCommitLimit || briefing.CommitCount != resumeCommitLimit+2 {
+		t.Errorf("Expected %d of %d commits, got %d of %d", resumeCommitLimit, resumeCommitLimit+2, len(briefing.Commits), briefing.CommitCount)
+	}
+	text := briefing.Text()
+	if !strings.Contains(text, fmt.Sprintf("COMMITS SINCE THEN (%d)", resumeCommitLimit+2)) || !strings.Contains(text, "… and 2 more") {
+		t.Errorf("Expected the true commit count in the briefing:\n%s", text)
+	}
+
+	// Rewriting history leaves the saved commit off the branch
+	if output, err := exec.Command("git", "-C", repo, "reset", "-q", "--hard", initial).CombinedOutput(); err != nil {
+		t.Fatalf("Reset failed: %v\n%s", err, output)
+	}
+	commit("Rewritten")
+	briefing = buildResumeBriefing(repo, session, nil, nil)
+	if got := subjects(briefing.Commits); !reflect.DeepEqual(got, []string{"Rewritten"}) || briefing.Orphaned != 1 {
+		t.Errorf("Expected the rewritten commit and 1 orphaned, got %v and %d orphaned", got, briefing.Orphaned)
+	}
+	if !strings.Contains(briefing.Text(), "1 commit(s) from then are no longer on this branch") {
+		t.Errorf("Expected the orphaned commit to be reported:\n%s", briefing.Text())
+	}
+
+	// When the saved commit is gone entirely, the commits listed in the saved context are the reference
+	gone := savedSession(thenDoc, branch, strings.Repeat("0", 40))
+	briefing = buildResumeBriefing(repo, gone, nil, nil)
+	if got := subjects(briefing.Commits); !reflect.DeepEqual(got, []string{"Rewritten"}) || briefing.CommitCount != 1 || briefing.Orphaned != 0 {
+		t.Errorf("Expected the fallback to find the rewritten commit, got %v (%d in all, %d orphaned)", got, briefing.CommitCount, briefing.Orphaned)
+	}
+}
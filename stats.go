@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/spf13/cobra"
+)
+
+// pullTarget is the NORTHSTAR "10-Second Test": context for any project in under 10 seconds
+const pullTarget = 10 * time.Second
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show how fast pulls are and which commands fail",
+	Long: `Show pull latency against the 10-second test, the most pulled projects, command
+failure rates and the slowest context sections.
+
+Every command records its duration and outcome in the local database; nothing leaves
+the machine. Set usage.disabled: true in the config file to stop recording.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sinceValue, _ := cmd.Flags().GetString("since")
+		top, _ := cmd.Flags().GetInt("top")
+		since, err := parseSince(sinceValue)
+		if err != nil {
+			fail("--since: %v", err)
+		}
+		requireDB()
+
+		records, err := db.GetUsage(ecosystem.ToolWherewasi, since)
+		if err != nil {
+			fail("%v", err)
+		}
+		showStats(ecosystem.SummarizeUsage(records, "pull", pullTarget), sinceValue, top)
+	},
+}
+
+// showStats prints a usage summary, listing at most top entries per table
+func showStats(summary ecosystem.UsageSummary, since string, top int) {
+	period := "all time"
+	if since != "" {
+		period = "last " + since
+	}
+	fmt.Printf("📊 WHEREWASI STATS (%s)\n", period)
+
+	fmt.Println("\n⏱️  PULL LATENCY")
+	if summary.Pulls.Count == 0 {
+		fmt.Println("  No successful pulls recorded yet")
+	} else {
+		verdict := "✅ passes"
+		if summary.Pulls.P90 > pullTarget {
+			verdict = "❌ fails"
+		}
+		fmt.Printf("  %d pulls: p50 %s, p90 %s, p99 %s, max %s\n", summary.Pulls.Count,
+			formatDuration(summary.Pulls.P50), formatDuration(summary.Pulls.P90),
+			formatDuration(summary.Pulls.P99), formatDuration(summary.Pulls.Max))
+		fmt.Printf("  %s the 10-second test: %d of %d pulls (%.0f%%) within %s\n", verdict,
+			summary.PullsWithin, summary.Pulls.Count, 100*float64(summary.PullsWithin)/float64(summary.Pulls.Count),
+			formatDuration(pullTarget))
+	}
+
+	if len(summary.Projects) > 0 {
+		fmt.Println("\n🎯 MOST PULLED")
+		for _, project := range limitStats(summary.Projects, top) {
+			fmt.Printf("  • %s: %d\n", project.Project, project.Count)
+		}
+	}
+
+	if len(summary.Commands) > 0 {
+		fmt.Println("\n🧯 COMMANDS")
+		for _, command := range limitStats(summary.Commands, top) {
+			line := fmt.Sprintf("  • %s: %d runs, %d failed (%.0f%%)", command.Command, command.Runs,
+				command.Failures, 100*command.FailureRate())
+			if command.LastError != "" {
+				line += " — last: " + command.LastError
+			}
+			fmt.Println(line)
+		}
+	}
+
+	if len(summary.Sections) > 0 {
+		fmt.Println("\n🐢 SLOWEST SECTIONS")
+		for _, section := range limitStats(summary.Sections, top) {
+			line := fmt.Sprintf("  • %s: mean %s, p90 %s, max %s", section.Name, formatDuration(section.Mean),
+				formatDuration(section.P90), formatDuration(section.Max))
+			if section.Failures > 0 {
+				line += fmt.Sprintf(", %d failed", section.Failures)
+			}
+			fmt.Println(line)
+		}
+	}
+}
+
+// limitStats keeps the first top entries; top <= 0 keeps them all
+func limitStats[T any](entries []T, top int) []T {
+	if top > 0 && len(entries) > top {
+		return entries[:top]
+	}
+	return entries
+}
+
+// formatDuration rounds a duration for display: 850ms, 1.2s, 12s
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < 10*time.Second:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Second).String()
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/QRY91/wherewasi/internal/ecosystem"
+	"github.com/QRY91/wherewasi/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+// invocation is the command being run, recorded in usage_stats when it finishes
+var invocation struct {
+	cmd       *cobra.Command
+	start     time.Time
+	sessionID string
+	disabled  bool
+	recorded  bool
+}
+
+// usageSessionID ties a command's row to the section timings recorded while it ran
+func usageSessionID() string {
+	if invocation.sessionID == "" {
+		buf := make([]byte, 8)
+		if _, err := rand.Read(buf); err == nil {
+			invocation.sessionID = hex.EncodeToString(buf)
+		}
+	}
+	return invocation.sessionID
+}
+
+// usageEnabled reports whether usage can and may be recorded
+func usageEnabled() bool {
+	return db != nil && !invocation.disabled && invocation.cmd != nil
+}
+
+// commandName is the command path without the root, e.g. "db prune"
+func commandName(cmd *cobra.Command) string {
+	name := strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name())
+	if name = strings.TrimSpace(name); name == "" {
+		return rootCmd.Name()
+	}
+	return name
+}
+
+// usageProject is the project a command ran against: its --project flag or the current project
+func usageProject() string {
+	if flag := invocation.cmd.Flags().Lookup("project"); flag != nil && flag.Value.String() != "" {
+		return canonicalProjectName(flag.Value.String())
+	}
+	return getProjectName()
+}
+
+// recordInvocation stores the command's duration and outcome; it runs once per process and
+// never fails the command
+func recordInvocation(err error) {
+	if invocation.recorded || !usageEnabled() {
+		return
+	}
+	invocation.recorded = true
+
+	errorMsg := ""
+	if err != nil {
+		errorMsg = err.Error()
+	}
+	duration := time.Since(invocation.start)
+	db.TrackUsage(ecosystem.ToolWherewasi, commandName(invocation.cmd), usageProject(), usageSessionID(),
+		int(duration.Milliseconds()), err == nil, errorMsg)
+}
+
+// recordSectionTimings stores how long each context section took to build
+func recordSectionTimings(timings []provider.Timing, project string) {
+	if !usageEnabled() {
+		return
+	}
+	for _, timing := range timings {
+		errorMsg := ""
+		if timing.Err != nil {
+			errorMsg = timing.Err.Error()
+		}
+		db.TrackUsage(ecosystem.ToolWherewasi, ecosystem.UsageSectionPrefix+timing.Name, project, usageSessionID(),
+			int(timing.Duration.Milliseconds()), timing.Err == nil, errorMsg)
+	}
+}
+
+// fail prints a warning, records it as the command's error and exits with status 1
+func fail(format string, args ...interface{}) {
+	err := fmt.Errorf(format, args...)
+	fmt.Printf("⚠️  %v\n", err)
+	exitWithError(err)
+}
+
+// exitWithError records the command as failed with err and exits with status 1; the caller
+// has already reported err
+func exitWithError(err error) {
+	recordInvocation(err)
+	os.Exit(1)
+}
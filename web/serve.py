@@ -47,7 +47,7 @@ project intelligence gathering.
 main.go → Core CLI with SQLite context storage
 web/index.html → HTMX demo with parachute animations
 README.md → Usage examples and ecosystem integration
-internal/database/ → Cross-project tracking logic
+internal/ecosystem/ → Cross-project tracking logic
 
 💬 RECENT CONVERSATIONS:
 AI_SESSION.md:156-234 → Context generation architecture
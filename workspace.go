@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/QRY91/wherewasi/internal/config"
+	"github.com/QRY91/wherewasi/internal/workspace"
+)
+
+// sourceProjectsTable labels descriptions stored with 'wherewasi projects describe'
+const sourceProjectsTable = "projects table"
+
+var (
+	discoverOnce       sync.Once
+	discoveredProjects []workspace.Project
+)
+
+// workspaceOptions reads the configured ecosystem roots, defaulting to the parent of the current project
+func workspaceOptions() workspace.Options {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		cfg = &config.Config{}
+	}
+	return workspace.OptionsFromConfig(cfg, filepath.Dir(getProjectRoot()))
+}
+
+// ecosystemProjects discovers the ecosystem once per run and records it in the projects table,
+// so every command works from the same set of projects. Repositories added with
+// 'wherewasi projects add' are included even when they are outside the configured roots.
+func ecosystemProjects() []workspace.Project {
+	discoverOnce.Do(func() {
+		projects, err := workspace.Discover(workspaceOptions())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		}
+		if db != nil {
+			if err := workspace.Sync(db, projects); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Could not record projects: %v\n", err)
+			}
+			projects = append(projects, registeredProjects(projects)...)
+			sort.Slice(projects, func(i, j int) bool {
+				return projects[i].Name < projects[j].Name
+			})
+		}
+		discoveredProjects = projects
+	})
+	return discoveredProjects
+}
+
+// registeredProjects returns repositories in the projects table that discovery did not find
+func registeredProjects(discovered []workspace.Project) []workspace.Project {
+	extra, err := workspace.Registered(db, discovered)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+	}
+	return extra
+}
+
+// findProject resolves a project by name, alias or path. Paths to repositories outside the
+// ecosystem resolve too, named after their directory.
+func findProject(ref string) (workspace.Project, bool) {
+	if ref == "" {
+		return workspace.Project{}, false
+	}
+
+	name := ref
+	if db != nil {
+		if target, err := db.ResolveProjectAlias(ref); err == nil && target != "" {
+			name = target
+		}
+	}
+	for _, project := range ecosystemProjects() {
+		if project.Name == name {
+			return project, true
+		}
+	}
+
+	if !looksLikePath(ref) {
+		return workspace.Project{}, false
+	}
+	path, err := workspace.ExpandPath(ref)
+	if err != nil || !workspace.IsRepository(path) {
+		return workspace.Project{}, false
+	}
+	for _, project := range ecosystemProjects() {
+		if project.Path == path {
+			return project, true
+		}
+	}
+	return workspace.Project{Name: filepath.Base(path), Path: path}, true
+}
+
+func looksLikePath(ref string) bool {
+	return strings.ContainsRune(ref, filepath.Separator) || strings.Contains(ref, "/") ||
+		strings.HasPrefix(ref, "~") || ref == "." || ref == ".."
+}
+
+// projectDescription resolves a description from the user config, the projects table,
+// .wherewasi.yaml, then the README
+func projectDescription(cfg *config.Config, name, root string) (string, string, error) {
+	if description := cfg.UserDescription(name); description != "" {
+		return description, config.SourceUser, nil
+	}
+	if db != nil {
+		project, err := db.GetProject(name)
+		if err != nil {
+			return "", "", err
+		}
+		if project != nil && project.Description != nil && *project.Description != "" {
+			return *project.Description, sourceProjectsTable, nil
+		}
+	}
+	return config.RepoDescription(root)
+}